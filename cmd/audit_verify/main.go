@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/audit_chain"
+	"github.com/Turalchik/authentication-service/internal/token_signer"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"log"
+	"os"
+)
+
+// audit_verify проверяет выгрузку журнала аутентификации (GET /api/v1/admin/audit/export) без доступа к базе:
+// пересчитывает hash chain и подписи контрольных точек и печатает первый разрыв. Код выхода 1 — цепочка нарушена.
+//
+//	curl -H "Authorization: Bearer $TOKEN" http://localhost:8080/api/v1/admin/audit/export > audit.ndjson
+//	curl http://localhost:8080/.well-known/jwks.json > jwks.json
+//	go run ./cmd/audit_verify -file audit.ndjson -jwks jwks.json
+//	JWT_SECRET_KEY=... go run ./cmd/audit_verify -file audit.ndjson
+func main() {
+	file := flag.String("file", "", "выгрузка журнала в формате JSON Lines (по умолчанию stdin)")
+	jwksFile := flag.String("jwks", "", "JWKS с публичными ключами сервиса, включая ключи, выведенные из ротации")
+	hmacSecret := flag.String("hmac-secret", os.Getenv("JWT_SECRET_KEY"), "общий секрет HS512, если сервис подписывает им (по умолчанию JWT_SECRET_KEY)")
+	flag.Parse()
+
+	keys, err := loadKeys(*jwksFile, *hmacSecret)
+	if err != nil {
+		log.Fatalf("can't load verification keys: %v", err)
+	}
+	if len(keys) == 0 {
+		log.Fatal("no verification keys: pass -jwks or -hmac-secret")
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("can't open export: %v", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	report, err := audit_chain.Verify(input, keys)
+	if err != nil {
+		log.Fatalf("can't read export: %v", err)
+	}
+
+	fmt.Printf("unchained records (before the chain): %d\n", report.Unchained)
+	fmt.Printf("chained records: %d\n", report.Chained)
+	fmt.Printf("checkpoints: %d\n", report.Checkpoints)
+	if report.Checkpoints > 0 {
+		fmt.Printf("last checkpoint covers audit_id: %d\n", report.LastCheckpointAuditID)
+	}
+	fmt.Printf("records after the last checkpoint: %d\n", report.Uncovered)
+
+	if report.Broken != nil {
+		fmt.Printf("BROKEN at line %d, audit_id %d: %s\n", report.Broken.Line, report.Broken.AuditID, report.Broken.Reason)
+		os.Exit(1)
+	}
+	fmt.Println("OK")
+}
+
+// keySet принимает подпись, если её подтверждает любой из ключей: контрольные точки могли быть подписаны до ротации
+type keySet []*token_signer.TokenSigner
+
+func (keys keySet) Verify(tokenStr string, claims jwt.Claims) error {
+	for _, key := range keys {
+		if err := key.Verify(tokenStr, claims); err == nil {
+			return nil
+		}
+	}
+	return apperrors.ErrInvalidToken
+}
+
+func loadKeys(jwksFile, hmacSecret string) (keySet, error) {
+	var keys keySet
+	if jwksFile != "" {
+		raw, err := os.ReadFile(jwksFile)
+		if err != nil {
+			return nil, err
+		}
+		var jwks token_signer.JWKS
+		if err = json.Unmarshal(raw, &jwks); err != nil {
+			return nil, err
+		}
+		for _, jwk := range jwks.Keys {
+			key, err := token_signer.NewTokenSignerFromJWK(jwk)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", jwk.KeyID, err)
+			}
+			keys = append(keys, key)
+		}
+	}
+	if hmacSecret != "" {
+		keys = append(keys, token_signer.NewHMACTokenSigner([]byte(hmacSecret)))
+	}
+	return keys, nil
+}
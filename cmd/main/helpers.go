@@ -1,41 +1,528 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/auth_service"
+	"github.com/Turalchik/authentication-service/internal/handlers"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"github.com/Turalchik/authentication-service/internal/secret_box"
+	"github.com/Turalchik/authentication-service/internal/token_signer"
+	"log/slog"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Config struct {
-	TTLAccessToken time.Duration
-	JWTSecretKey   []byte
-	WebhookURL     string
+	// debug, info, warn или error
+	LogLevel slog.Level
+
+	TTLAccessToken    time.Duration
+	JWTSecretKey      []byte
+	JWTSigningKeyFile string
+
+	// повторы доставки webhooks; получатели, ключи подписи и фильтры событий — в подписках
+	WebhookMaxAttempts int
+
+	// получатель из конфига до подписок: при старте из него создаётся подписка на все события
+	WebhookURL    string
+	WebhookSecret string
+
+	// как часто подписывать конец hash chain журнала аутентификации
+	AuditCheckpointInterval time.Duration
+
+	// выдача токенов по голому user_id (GET /api/v1/auth/tokens) — только для доверенных внутренних вызовов
+	TrustedUserIDLogin bool
+
+	// ключ AES-256 для TOTP секретов в базе; без него второй фактор недоступен
+	TOTPEncryptionKey []byte
+
+	// ротация ключей подписи
+	JWTPreviousSecretKeys      []string
+	JWTPreviousSigningKeyFiles []string
+	JWTNextSecretKey           string
+	JWTNextSigningKeyFile      string
+	JWTKeyRotateAt             time.Time
+	JWTKeyRetireAfter          time.Duration
 
 	RedisAddr     string
 	RedisPassword string
 	RedisDB       int
+
+	// где живёт всё состояние, которое иначе хранится в Redis: отозванные токены, коды авторизации и лимиты.
+	// redis или memory (один экземпляр, dev окружение, без Redis)
+	StateStore             string
+	RevocationStoreMaxKeys int
+
+	// до этого момента black-list проверяется ещё и по ключам старого формата (сам токен вместо jti)
+	RevocationLegacyKeysUntil time.Time
+
+	// дедлайны на один метод repo и одну операцию Redis; 0 — без собственного дедлайна
+	DBTimeout    time.Duration
+	RedisTimeout time.Duration
+
+	// сколько после SIGTERM дообслуживать запросы и отправлять webhooks до закрытия соединений
+	ShutdownTimeout time.Duration
+
+	// адрес gRPC сервера, рядом с HTTP на :8080
+	GRPCAddr string
+	// gRPC reflection на порту без TLS и аутентификации — только для отладки
+	GRPCReflection bool
+
+	RateLimits handlers.RateLimits
+
+	// сети прокси и балансировщиков, которым доверяется X-Forwarded-For
+	TrustedProxies []netip.Prefix
+
+	// адрес OTLP/HTTP collector; пусто — спаны не экспортируются, traceparent всё равно передаётся
+	OTLPEndpoint string
 }
 
+// defaultWebhookMaxAttempts — с задержкой от 10s до 1h это примерно сутки попыток
+const defaultWebhookMaxAttempts = 12
+
+const defaultAuditCheckpointInterval = time.Hour
+
+// таймауты по умолчанию: Redis отвечает за миллисекунды, а в транзакции repo бывает несколько запросов
+const (
+	defaultDBTimeout    = 5 * time.Second
+	defaultRedisTimeout = 500 * time.Millisecond
+)
+
+const defaultGRPCPort = 9090
+
+const (
+	stateStoreRedis  = "redis"
+	stateStoreMemory = "memory"
+)
+
+// defaultRevocationStoreMaxKeys — порядка десяти мегабайт при ключах jti:<uuid>
+const defaultRevocationStoreMaxKeys = 100000
+
+// minLegacyRevocationWindow — ключи старого формата есть и у MFA токенов, а они живут 5 минут
+const minLegacyRevocationWindow = 5 * time.Minute
+
+// memoryStoreJanitorInterval — как часто in-memory хранилища удаляют истёкшие отзывы, коды и окна лимитов
+const memoryStoreJanitorInterval = time.Minute
+
+// defaultShutdownTimeout укладывается в terminationGracePeriodSeconds Kubernetes по умолчанию (30s)
+const defaultShutdownTimeout = 25 * time.Second
+
+// таймауты HTTP сервера: медленный клиент не должен держать соединение и горутину бесконечно
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 10 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 2 * time.Minute
+
+	readinessCheckTimeout = 2 * time.Second
+)
+
+// лимиты по умолчанию: ручки, которые выдают токены или проверяют секреты, ограничены сильнее
+var (
+	defaultRateLimitPerIP      = handlers.RateLimit{Limit: 300, Window: time.Minute}
+	defaultRateLimitPerUser    = handlers.RateLimit{Limit: 120, Window: time.Minute}
+	defaultRateLimitPerSession = handlers.RateLimit{Limit: 10, Window: time.Minute}
+	defaultRateLimitRoutes     = map[string]handlers.RateLimit{
+		"/api/v1/auth/tokens":     {Limit: 30, Window: time.Minute},
+		"/api/v1/auth/refresh":    {Limit: 30, Window: time.Minute},
+		"/api/v1/auth/login":      {Limit: 10, Window: time.Minute},
+		"/api/v1/auth/register":   {Limit: 10, Window: time.Minute},
+		"/api/v1/auth/mfa/verify": {Limit: 10, Window: time.Minute},
+		"/oauth2/authorize":       {Limit: 20, Window: time.Minute},
+		"/oauth2/token":           {Limit: 30, Window: time.Minute},
+		"/oauth2/introspect":      {Limit: 120, Window: time.Minute},
+		"/oauth2/revoke":          {Limit: 30, Window: time.Minute},
+
+		"/auth.v1.AuthService/CreateTokens":  {Limit: 30, Window: time.Minute},
+		"/auth.v1.AuthService/RefreshTokens": {Limit: 30, Window: time.Minute},
+	}
+)
+
 func GetConfigFromEnv() (*Config, error) {
+	logLevel, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		return nil, err
+	}
+
 	ttlAccessToken, err := strconv.Atoi(os.Getenv("TTL_ACCESS_TOKEN"))
 	if err != nil {
 		return nil, err
 	}
 
-	redisDB, err := strconv.Atoi(os.Getenv("REDIS_DB"))
+	// при STATE_STORE=memory Redis не нужен, и REDIS_* можно не задавать
+	var redisDB int
+	if v := os.Getenv("REDIS_DB"); v != "" {
+		redisDB, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// по умолчанию старый ключ живёт столько же, сколько последний подписанный им access токен
+	keyRetireAfter := time.Second * time.Duration(ttlAccessToken)
+	if v := os.Getenv("JWT_KEY_RETIRE_AFTER"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		keyRetireAfter = time.Second * time.Duration(seconds)
+	}
+
+	var trustedUserIDLogin bool
+	if v := os.Getenv("TRUSTED_USER_ID_LOGIN"); v != "" {
+		trustedUserIDLogin, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	webhookMaxAttempts := defaultWebhookMaxAttempts
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		webhookMaxAttempts, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	auditCheckpointInterval := defaultAuditCheckpointInterval
+	if v := os.Getenv("AUDIT_CHECKPOINT_INTERVAL"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		if seconds <= 0 {
+			return nil, fmt.Errorf("invalid AUDIT_CHECKPOINT_INTERVAL %q", v)
+		}
+		auditCheckpointInterval = time.Second * time.Duration(seconds)
+	}
+
+	dbTimeout, err := parseTimeoutMS("DB_TIMEOUT_MS", defaultDBTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	redisTimeout, err := parseTimeoutMS("REDIS_TIMEOUT_MS", defaultRedisTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := parseTimeoutMS("SHUTDOWN_TIMEOUT_MS", defaultShutdownTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if shutdownTimeout == 0 {
+		return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT_MS %q", os.Getenv("SHUTDOWN_TIMEOUT_MS"))
+	}
+
+	grpcPort := defaultGRPCPort
+	if v := os.Getenv("GRPC_PORT"); v != "" {
+		grpcPort, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		if grpcPort <= 0 || grpcPort > 65535 || grpcPort == 8080 {
+			return nil, fmt.Errorf("invalid GRPC_PORT %q", v)
+		}
+	}
+
+	var grpcReflection bool
+	if v := os.Getenv("GRPC_REFLECTION"); v != "" {
+		grpcReflection, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stateStore := os.Getenv("STATE_STORE")
+	// REVOCATION_STORE — прежнее имя STATE_STORE, из времён, когда в памяти мог жить только black-list
+	if v := os.Getenv("REVOCATION_STORE"); v != "" {
+		if stateStore != "" && stateStore != v {
+			return nil, fmt.Errorf("STATE_STORE %q conflicts with REVOCATION_STORE %q", stateStore, v)
+		}
+		stateStore = v
+	}
+	if stateStore == "" {
+		stateStore = stateStoreRedis
+	}
+	if stateStore != stateStoreRedis && stateStore != stateStoreMemory {
+		return nil, fmt.Errorf("invalid STATE_STORE %q", stateStore)
+	}
+
+	revocationStoreMaxKeys := defaultRevocationStoreMaxKeys
+	if v := os.Getenv("REVOCATION_STORE_MAX_KEYS"); v != "" {
+		revocationStoreMaxKeys, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		if revocationStoreMaxKeys <= 0 {
+			return nil, fmt.Errorf("invalid REVOCATION_STORE_MAX_KEYS %q", v)
+		}
+	}
+
+	// по умолчанию старые ключи проверяются, пока не истекут токены, отозванные до запуска этого экземпляра;
+	// при поэтапном обновлении старые экземпляры пишут такие ключи до конца выкатки — тогда задаётся явно
+	revocationLegacyKeysUntil := time.Now().Add(max(time.Second*time.Duration(ttlAccessToken), minLegacyRevocationWindow))
+	if v := os.Getenv("REVOCATION_LEGACY_KEYS_UNTIL"); v != "" {
+		revocationLegacyKeysUntil, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var totpEncryptionKey []byte
+	if v := os.Getenv("TOTP_ENCRYPTION_KEY"); v != "" {
+		totpEncryptionKey, err = base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var keyRotateAt time.Time
+	if v := os.Getenv("JWT_KEY_ROTATE_AT"); v != "" {
+		keyRotateAt, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rateLimits := handlers.RateLimits{
+		PerIP:      defaultRateLimitPerIP,
+		PerUser:    defaultRateLimitPerUser,
+		PerSession: defaultRateLimitPerSession,
+		PerRoute:   defaultRateLimitRoutes,
+	}
+	if v := os.Getenv("RATE_LIMIT_PER_IP"); v != "" {
+		if rateLimits.PerIP, err = parseRateLimit(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_PER_USER"); v != "" {
+		if rateLimits.PerUser, err = parseRateLimit(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_PER_SESSION"); v != "" {
+		if rateLimits.PerSession, err = parseRateLimit(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_ROUTES"); v != "" {
+		if rateLimits.PerRoute, err = parseRouteRateLimits(v); err != nil {
+			return nil, err
+		}
+	}
+
+	trustedProxies, err := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
 	if err != nil {
 		return nil, err
 	}
 
 	cfg := &Config{
-		TTLAccessToken: time.Second * time.Duration(ttlAccessToken),
-		JWTSecretKey:   []byte(os.Getenv("JWT_SECRET_KEY")),
-		WebhookURL:     os.Getenv("WEBHOOK_URL"),
-		
+		LogLevel: logLevel,
+
+		TTLAccessToken:    time.Second * time.Duration(ttlAccessToken),
+		JWTSecretKey:      []byte(os.Getenv("JWT_SECRET_KEY")),
+		JWTSigningKeyFile: os.Getenv("JWT_SIGNING_KEY_FILE"),
+
+		WebhookMaxAttempts:      webhookMaxAttempts,
+		WebhookURL:              os.Getenv("WEBHOOK_URL"),
+		WebhookSecret:           os.Getenv("WEBHOOK_SECRET"),
+		AuditCheckpointInterval: auditCheckpointInterval,
+
+		TrustedUserIDLogin: trustedUserIDLogin,
+		TOTPEncryptionKey:  totpEncryptionKey,
+
+		JWTPreviousSecretKeys:      splitList(os.Getenv("JWT_PREVIOUS_SECRET_KEYS")),
+		JWTPreviousSigningKeyFiles: splitList(os.Getenv("JWT_PREVIOUS_SIGNING_KEY_FILES")),
+		JWTNextSecretKey:           os.Getenv("JWT_NEXT_SECRET_KEY"),
+		JWTNextSigningKeyFile:      os.Getenv("JWT_NEXT_SIGNING_KEY_FILE"),
+		JWTKeyRotateAt:             keyRotateAt,
+		JWTKeyRetireAfter:          keyRetireAfter,
+
 		RedisAddr:     os.Getenv("REDIS_ADDR"),
 		RedisPassword: os.Getenv("REDIS_PASSWORD"),
 		RedisDB:       redisDB,
+
+		StateStore:             stateStore,
+		RevocationStoreMaxKeys: revocationStoreMaxKeys,
+
+		RevocationLegacyKeysUntil: revocationLegacyKeysUntil,
+
+		DBTimeout:    dbTimeout,
+		RedisTimeout: redisTimeout,
+
+		ShutdownTimeout: shutdownTimeout,
+
+		GRPCAddr:       fmt.Sprintf(":%d", grpcPort),
+		GRPCReflection: grpcReflection,
+
+		RateLimits:     rateLimits,
+		TrustedProxies: trustedProxies,
+
+		OTLPEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
 	}
 
 	return cfg, nil
 }
+
+// NewKeyRing собирает ключи подписи из конфига: активный, предыдущие (только для проверки)
+// и, если задан JWT_KEY_ROTATE_AT, следующий, который станет активным в указанный момент
+func NewKeyRing(cfg *Config) (*token_signer.KeyRing, error) {
+	active, err := newTokenSigner(cfg.JWTSigningKeyFile, cfg.JWTSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	keyRing := token_signer.NewKeyRing(active)
+
+	for _, path := range cfg.JWTPreviousSigningKeyFiles {
+		previous, err := token_signer.NewTokenSignerFromPEMFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keyRing.AddPreviousKey(previous, cfg.JWTKeyRetireAfter)
+	}
+	for _, secret := range cfg.JWTPreviousSecretKeys {
+		keyRing.AddPreviousKey(token_signer.NewHMACTokenSigner([]byte(secret)), cfg.JWTKeyRetireAfter)
+	}
+
+	if !cfg.JWTKeyRotateAt.IsZero() {
+		next, err := newTokenSigner(cfg.JWTNextSigningKeyFile, []byte(cfg.JWTNextSecretKey))
+		if err != nil {
+			return nil, err
+		}
+		keyRing.ScheduleRotation(next, cfg.JWTKeyRotateAt, cfg.JWTKeyRetireAfter)
+	}
+
+	return keyRing, nil
+}
+
+// NewSecretBox возвращает шифратор TOTP секретов или nil, если TOTP_ENCRYPTION_KEY не задан
+func NewSecretBox(cfg *Config) (auth_service.SecretBox, error) {
+	if len(cfg.TOTPEncryptionKey) == 0 {
+		return nil, nil
+	}
+	return secret_box.NewSecretBox(cfg.TOTPEncryptionKey)
+}
+
+// newTokenSigner — асимметричная подпись, если задан PEM файл с приватным ключом, иначе HS512 общим секретом
+func newTokenSigner(signingKeyFile string, secretKey []byte) (*token_signer.TokenSigner, error) {
+	if signingKeyFile != "" {
+		return token_signer.NewTokenSignerFromPEMFile(signingKeyFile)
+	}
+	return token_signer.NewHMACTokenSigner(secretKey), nil
+}
+
+// parseRateLimit разбирает лимит вида <число>/<окно>, например 10/1m; 0/1m — без ограничения
+func parseRateLimit(s string) (handlers.RateLimit, error) {
+	limitStr, windowStr, found := strings.Cut(strings.TrimSpace(s), "/")
+	if !found {
+		return handlers.RateLimit{}, fmt.Errorf("invalid rate limit %q, want <limit>/<window>", s)
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		return handlers.RateLimit{}, err
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil {
+		return handlers.RateLimit{}, err
+	}
+	if limit < 0 || window <= 0 {
+		return handlers.RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	return handlers.RateLimit{Limit: limit, Window: window}, nil
+}
+
+// parseRouteRateLimits разбирает список вида /api/v1/auth/login=10/1m,/api/v1/auth/refresh=30/1m
+func parseRouteRateLimits(s string) (map[string]handlers.RateLimit, error) {
+	rateLimits := make(map[string]handlers.RateLimit)
+	for _, item := range splitList(s) {
+		route, limitStr, found := strings.Cut(item, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid route rate limit %q, want <route>=<limit>/<window>", item)
+		}
+
+		rateLimit, err := parseRateLimit(limitStr)
+		if err != nil {
+			return nil, err
+		}
+		rateLimits[strings.TrimSpace(route)] = rateLimit
+	}
+	return rateLimits, nil
+}
+
+// parseTrustedProxies разбирает список сетей и адресов через запятую, например 10.0.0.0/8,192.168.1.10
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range splitList(s) {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// parseTimeoutMS читает таймаут в миллисекундах; пустая переменная — значение по умолчанию, 0 — без таймаута
+func parseTimeoutMS(name string, defaultTimeout time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return defaultTimeout, nil
+	}
+	milliseconds, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if milliseconds < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return time.Millisecond * time.Duration(milliseconds), nil
+}
+
+// wait ждёт wg, но не дольше ctx
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
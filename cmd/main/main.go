@@ -1,15 +1,40 @@
 package main
 
 import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/audit_service"
 	"github.com/Turalchik/authentication-service/internal/auth_service"
+	"github.com/Turalchik/authentication-service/internal/authorization_code_store"
 	"github.com/Turalchik/authentication-service/internal/database"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/Turalchik/authentication-service/internal/grpc_handlers"
 	"github.com/Turalchik/authentication-service/internal/handlers"
+	"github.com/Turalchik/authentication-service/internal/health"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"github.com/Turalchik/authentication-service/internal/memory_authorization_code_store"
+	"github.com/Turalchik/authentication-service/internal/memory_rate_limit_store"
+	"github.com/Turalchik/authentication-service/internal/memory_revocation_store"
+	"github.com/Turalchik/authentication-service/internal/metrics"
+	"github.com/Turalchik/authentication-service/internal/rate_limit_store"
 	"github.com/Turalchik/authentication-service/internal/redisdb"
 	"github.com/Turalchik/authentication-service/internal/repo"
 	"github.com/Turalchik/authentication-service/internal/token_revocation_store"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"github.com/Turalchik/authentication-service/internal/webhook_dispatcher"
+	"github.com/Turalchik/authentication-service/internal/webhook_service"
+	"github.com/go-redis/redis/v8"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	grpchealth "google.golang.org/grpc/health"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 // @title Authentication Service
@@ -24,31 +49,206 @@ import (
 // @name Authorization
 
 func main() {
+	// уровень известен только после чтения конфига, а ошибки конфига тоже должны попасть в JSON лог
+	logLevel := new(slog.LevelVar)
+	logger := logging.NewLogger(os.Stdout, logLevel)
+	// log.Printf фоновых задач идёт через тот же обработчик, с вырезанием секретов
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+
 	cfg, err := GetConfigFromEnv()
 	if err != nil {
-		log.Fatalf("can't load variables from environment with error: %v", err)
+		fatal(logger, "can't load config from environment", err)
 	}
+	logLevel.Set(cfg.LogLevel)
 
 	dsn := database.NewPostgresDSN()
 	db, err := database.NewDatabase(dsn, "pgx")
 	if err != nil {
-		log.Fatalf("Can't create database: %v", err)
+		fatal(logger, "can't create database", err)
 	}
-	redisClient, err := redisdb.NewRedisClient(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	keyRing, err := NewKeyRing(cfg)
 	if err != nil {
-		log.Fatalf("Can't create redis client: %v", err)
+		fatal(logger, "can't load signing keys", err)
 	}
 
-	repository := repo.NewRepo(db)
-	revocationStore := token_revocation_store.NewTokenRevocationStore(redisClient, "")
-	authService := auth_service.NewAuthService(repository, revocationStore, cfg.TTLAccessToken, cfg.JWTSecretKey, cfg.WebhookURL)
-	handler := handlers.NewHttpHandler(authService)
+	secretBox, err := NewSecretBox(cfg)
+	if err != nil {
+		fatal(logger, "can't load TOTP encryption key", err)
+	}
+	if secretBox == nil {
+		logger.Warn("TOTP_ENCRYPTION_KEY is not set, TOTP enrollment is disabled")
+	}
+
+	var tracerProvider *sdktrace.TracerProvider
+	if cfg.OTLPEndpoint != "" {
+		if tracerProvider, err = tracing.NewTracerProvider(context.Background(), cfg.OTLPEndpoint); err != nil {
+			fatal(logger, "can't create tracer provider", err)
+		}
+		logger.Info("tracing enabled", "otlp_endpoint", cfg.OTLPEndpoint)
+	} else {
+		logger.Info("OTEL_EXPORTER_OTLP_ENDPOINT is not set, spans are not exported")
+	}
+
+	serviceMetrics := metrics.NewMetrics()
+	repository := repo.NewRepo(db, logger, serviceMetrics, cfg.DBTimeout)
+	serviceMetrics.RegisterSessionCounter(repository)
+
+	// STATE_STORE=memory — всё, что иначе хранится в Redis, живёт в памяти процесса, и Redis не нужен вовсе
+	var redisClient *redis.Client
+	var revocationStore auth_service.TokenRevocationStore
+	var codeStore auth_service.AuthorizationCodeStore
+	var rateLimitStore handlers.RateLimitStore
+	var janitors []func(ctx context.Context, interval time.Duration)
+	if cfg.StateStore == stateStoreMemory {
+		memoryRevocationStore := memory_revocation_store.NewMemoryRevocationStore(cfg.RevocationStoreMaxKeys)
+		memoryCodeStore := memory_authorization_code_store.NewMemoryAuthorizationCodeStore()
+		memoryRateLimitStore := memory_rate_limit_store.NewMemoryRateLimitStore()
+		revocationStore, codeStore, rateLimitStore = memoryRevocationStore, memoryCodeStore, memoryRateLimitStore
+		janitors = append(janitors, memoryRevocationStore.RunJanitor, memoryCodeStore.RunJanitor, memoryRateLimitStore.RunJanitor)
+		logger.Warn("STATE_STORE=memory: revoked tokens and authorization codes are lost on restart, revocations, codes and rate limits are not shared between instances")
+	} else {
+		if redisClient, err = redisdb.NewRedisClient(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB); err != nil {
+			fatal(logger, "can't create redis client", err)
+		}
+		revocationStore = token_revocation_store.NewTokenRevocationStore(redisClient, "", cfg.RedisTimeout, serviceMetrics)
+		codeStore = authorization_code_store.NewAuthorizationCodeStore(redisClient, "authcode:", cfg.RedisTimeout)
+		rateLimitStore = rate_limit_store.NewRateLimitStore(redisClient, "ratelimit:", cfg.RedisTimeout)
+	}
+	authService := auth_service.NewAuthService(repository, revocationStore, codeStore, keyRing, secretBox, repository, logger, serviceMetrics, cfg.TTLAccessToken, webhook_events.EventTypes, cfg.TrustedUserIDLogin, cfg.RevocationLegacyKeysUntil)
+	webhookService := webhook_service.NewWebhookService(repository)
+	auditService := audit_service.NewAuditService(repository, keyRing, logger)
+	if cfg.WebhookURL != "" {
+		if cfg.WebhookSecret == "" {
+			logger.Warn("WEBHOOK_SECRET is not set, webhooks for WEBHOOK_URL are signed with an empty key")
+		}
+		adopted, err := webhookService.BootstrapSubscription(context.Background(), cfg.WebhookURL, cfg.WebhookSecret)
+		if err != nil {
+			fatal(logger, "can't create webhook subscription for WEBHOOK_URL", err)
+		}
+		if !adopted {
+			logger.Info("webhook subscription for WEBHOOK_URL is already configured, WEBHOOK_URL and WEBHOOK_SECRET are ignored")
+		}
+	}
+	dispatcher := webhook_dispatcher.NewWebhookDispatcher(repository, cfg.WebhookMaxAttempts, logger)
+
+	// фоновые задачи останавливаются после HTTP сервера: последние запросы ещё создают события webhook
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		auditService.RunCheckpoints(backgroundCtx, cfg.AuditCheckpointInterval)
+	}()
+	go func() {
+		defer background.Done()
+		dispatcher.Run(backgroundCtx)
+	}()
+	for _, runJanitor := range janitors {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			runJanitor(backgroundCtx, memoryStoreJanitorInterval)
+		}()
+	}
+
+	handler := handlers.NewHttpHandler(authService, webhookService, auditService, rateLimitStore, cfg.RateLimits, cfg.TrustedProxies, logger, serviceMetrics)
+
+	readinessChecks := []health.Check{{Name: "postgres", Check: db.PingContext}}
+	if redisClient != nil {
+		readinessChecks = append(readinessChecks, health.Check{Name: "redis", Check: func(ctx context.Context) error { return redisClient.Ping(ctx).Err() }})
+	}
+	probes := health.NewHealth(logger, readinessCheckTimeout, readinessChecks...)
+
+	// /metrics и пробы мимо HttpHandler: они не должны попадать в лог запросов, лимиты и гистограмму
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", serviceMetrics.Handler())
+	mux.Handle("/healthz", probes.LivenessHandler())
+	mux.Handle("/readyz", probes.ReadinessHandler())
+	mux.Handle("/", handler)
 
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: handler,
+		Addr:              ":8080",
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	// gRPC API для внутренних сервисов на отдельном порту; стандартный health отдаёт NOT_SERVING с началом остановки
+	grpcHealth := grpchealth.NewServer()
+	grpcServer := grpc_handlers.NewGrpcHandler(authService, rateLimitStore, cfg.RateLimits, logger).NewServer(grpcHealth, cfg.GRPCReflection)
+	grpcListener, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		fatal(logger, "can't listen grpc", err)
 	}
 
-	log.Println("Сервер запущен на http://localhost:8080")
-	log.Fatal(server.ListenAndServe())
+	// SIGTERM от Kubernetes или docker stop запускает остановку; повторный сигнал завершает процесс сразу
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 2)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	go func() {
+		serverErr <- grpcServer.Serve(grpcListener)
+	}()
+	logger.Info("server started", "addr", server.Addr, "grpc_addr", cfg.GRPCAddr)
+
+	select {
+	case err = <-serverErr:
+		fatal(logger, "server stopped", err)
+	case <-ctx.Done():
+	}
+	stop()
+	logger.Info("shutting down", "timeout", cfg.ShutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// /readyz отвечает 503, gRPC health — NOT_SERVING, новые соединения не принимаются, начатые запросы и RPC дообслуживаются
+	probes.StartDraining()
+	grpcHealth.Shutdown()
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("http server shutdown", "error", err)
+		_ = server.Close()
+	}
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		logger.Error("grpc server shutdown", "error", shutdownCtx.Err())
+		grpcServer.Stop()
+	}
+
+	// начатая пачка webhooks дописывается, затем отправляется всё, что накопилось к остановке
+	stopBackground()
+	if err = wait(shutdownCtx, &background); err != nil {
+		logger.Error("background jobs did not stop", "error", err)
+	}
+	if err = dispatcher.Flush(shutdownCtx); err != nil {
+		logger.Error("webhook flush", "error", err)
+	}
+
+	if tracerProvider != nil {
+		if err = tracerProvider.Shutdown(shutdownCtx); err != nil {
+			logger.Error("tracer provider shutdown", "error", err)
+		}
+	}
+
+	if redisClient != nil {
+		if err = redisClient.Close(); err != nil {
+			logger.Error("redis client close", "error", err)
+		}
+	}
+	if err = db.Close(); err != nil {
+		logger.Error("database close", "error", err)
+	}
+	logger.Info("server stopped")
 }
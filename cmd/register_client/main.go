@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/database"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
+	"github.com/Turalchik/authentication-service/internal/repo"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"strings"
+)
+
+// register_client регистрирует OAuth клиента и один раз печатает его секрет.
+// В базе хранится только bcrypt-хеш, восстановить секрет потом нельзя.
+//
+//	DB_USER=... DB_PASSWORD=... DB_HOST=... DB_PORT=... DB_NAME=... go run ./cmd/register_client -name api-gateway
+//	... go run ./cmd/register_client -name billing-job -grant-types client_credentials -scopes "invoices:read invoices:write"
+//	... go run ./cmd/register_client -name spa -public -grant-types authorization_code -redirect-uris https://app.example.com/callback
+func main() {
+	clientID := flag.String("client-id", "", "ID клиента (по умолчанию случайный UUID)")
+	name := flag.String("name", "", "человекочитаемое имя клиента")
+	grantTypes := flag.String("grant-types", "", "разрешённые grant_type через пробел, например client_credentials")
+	scopes := flag.String("scopes", "", "разрешённые scope через пробел")
+	redirectURIs := flag.String("redirect-uris", "", "разрешённые redirect_uri через пробел")
+	public := flag.Bool("public", false, "публичный клиент без секрета (SPA, мобильное приложение), защищается только PKCE")
+	flag.Parse()
+
+	if *clientID == "" {
+		*clientID = uuid.NewString()
+	}
+
+	var clientSecret string
+	var clientSecretHash []byte
+	if !*public {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			log.Fatalf("can't generate client secret: %v", err)
+		}
+		clientSecret = base64.RawURLEncoding.EncodeToString(raw)
+
+		var err error
+		clientSecretHash, err = bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.DefaultCost)
+		if err != nil {
+			log.Fatalf("can't hash client secret: %v", err)
+		}
+	}
+
+	db, err := database.NewDatabase(database.NewPostgresDSN(), "pgx")
+	if err != nil {
+		log.Fatalf("Can't create database: %v", err)
+	}
+	defer db.Close()
+
+	client := &clients.Clients{
+		ClientID:         *clientID,
+		ClientSecretHash: clientSecretHash,
+		Name:             *name,
+		Scopes:           strings.Join(strings.Fields(*scopes), " "),
+		GrantTypes:       strings.Join(strings.Fields(*grantTypes), " "),
+		RedirectURIs:     strings.Join(strings.Fields(*redirectURIs), " "),
+	}
+	if err = repo.NewRepo(db, nil, nil, 0).CreateClient(context.Background(), client); err != nil {
+		log.Fatalf("can't register client: %v", err)
+	}
+
+	if *public {
+		fmt.Printf("client_id=%s\n", client.ClientID)
+		return
+	}
+	fmt.Printf("client_id=%s\nclient_secret=%s\n", client.ClientID, clientSecret)
+}
@@ -184,3 +184,112 @@ func Test_AuthService_BlacklistScenarios(t *testing.T) {
 	require.Equal(t, 200, resp.StatusCode)
 	resp.Body.Close()
 }
+
+func Test_AuthService_MultipleSessions(t *testing.T) {
+	waitForAPI(t, "http://localhost:8080/api/v1/unknown", 30*time.Second)
+
+	client := &http.Client{}
+	userID := "0b9f2c1e-3d4a-4f5b-8c6d-7e8f9a0b1c2d"
+
+	login := func(userAgent string) tokensResp {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/api/v1/auth/tokens?user_id="+userID, nil)
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.StatusCode)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		var tokens tokensResp
+		require.NoError(t, json.Unmarshal(body, &tokens))
+		return tokens
+	}
+
+	// 1. Логинимся с двух устройств
+	laptop := login("laptop")
+	phone := login("phone")
+
+	// 2. Logout на ноутбуке
+	req, _ := http.NewRequest("POST", "http://localhost:8080/api/v1/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+laptop.AccessToken)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, 204, resp.StatusCode)
+	resp.Body.Close()
+
+	// 3. Сессия на телефоне продолжает работать
+	refreshBody, _ := json.Marshal(map[string]string{
+		"access_token":  phone.AccessToken,
+		"refresh_token": phone.RefreshToken,
+	})
+	req, _ = http.NewRequest("POST", "http://localhost:8080/api/v1/auth/refresh", bytes.NewReader(refreshBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "phone")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	resp.Body.Close()
+
+	// 4. А сессия на ноутбуке — нет
+	refreshBody, _ = json.Marshal(map[string]string{
+		"access_token":  laptop.AccessToken,
+		"refresh_token": laptop.RefreshToken,
+	})
+	req, _ = http.NewRequest("POST", "http://localhost:8080/api/v1/auth/refresh", bytes.NewReader(refreshBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "laptop")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.NotEqual(t, 200, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func Test_AuthService_RefreshTokenReuse(t *testing.T) {
+	waitForAPI(t, "http://localhost:8080/api/v1/unknown", 30*time.Second)
+
+	client := &http.Client{}
+	userID := "5d1e7a3c-2b4f-4c6e-9a8b-1c2d3e4f5a6b"
+
+	refresh := func(tokens tokensResp) *http.Response {
+		refreshBody, _ := json.Marshal(map[string]string{
+			"access_token":  tokens.AccessToken,
+			"refresh_token": tokens.RefreshToken,
+		})
+		resp, err := client.Post("http://localhost:8080/api/v1/auth/refresh", "application/json", bytes.NewReader(refreshBody))
+		require.NoError(t, err)
+		return resp
+	}
+
+	// 1. Получить токены
+	resp, err := client.Get("http://localhost:8080/api/v1/auth/tokens?user_id=" + userID)
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	var tokens tokensResp
+	require.NoError(t, json.Unmarshal(body, &tokens))
+
+	// 2. Легитимный refresh
+	resp = refresh(tokens)
+	require.Equal(t, 200, resp.StatusCode)
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	var tokens2 tokensResp
+	require.NoError(t, json.Unmarshal(body, &tokens2))
+
+	// 3. Повторное использование уже ротированного refresh токена
+	resp = refresh(tokens)
+	require.Equal(t, 401, resp.StatusCode)
+	resp.Body.Close()
+
+	// 4. Всё семейство отозвано: свежие токены тоже больше не работают
+	req, _ := http.NewRequest("GET", "http://localhost:8080/api/v1/auth/guid", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens2.AccessToken)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, 401, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = refresh(tokens2)
+	require.NotEqual(t, 200, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -2,23 +2,128 @@ package apperrors
 
 import (
 	"errors"
+	"time"
 )
 
 var (
-	ErrInvalidUserID            = errors.New("invalid user id")
-	ErrUserNotFound             = errors.New("user not found")
-	ErrCantCreateTokens         = errors.New("can't create tokens")
-	ErrCantCreateSession        = errors.New("can't create session")
-	ErrCantUpdateTokens         = errors.New("can't update tokens")
-	ErrUserAlreadyExists        = errors.New("user already exists")
-	ErrInvalidToken             = errors.New("invalid token")
-	ErrCantGetSession           = errors.New("can't get session")
-	ErrCantDeleteSession        = errors.New("can't delete session")
-	ErrTokensDontMatch          = errors.New("tokens don't match")
-	ErrCantBuildSQLQuery        = errors.New("cant build sql query")
-	ErrCantExecSQLQuery         = errors.New("can't exec sql query")
-	ErrCantOpenDatabase         = errors.New("can't open database")
-	ErrCantCheckRevocationToken = errors.New("can't verify the revocation of the token")
-	ErrCantRevokeToken          = errors.New("can't revoke token")
-	ErrRedisPingFailed          = errors.New("redis ping failed")
+	ErrInvalidUserID                = errors.New("invalid user id")
+	ErrUserNotFound                 = errors.New("user not found")
+	ErrUserAlreadyExists            = errors.New("user already exists")
+	ErrCantCreateUser               = errors.New("can't create user")
+	ErrCantGetUser                  = errors.New("can't get user")
+	ErrInvalidEmail                 = errors.New("invalid email")
+	ErrWeakPassword                 = errors.New("password is too weak")
+	ErrInvalidCredentials           = errors.New("invalid email or password")
+	ErrUserIDLoginDisabled          = errors.New("issuing tokens by user id is disabled")
+	ErrMismatchedPassword           = errors.New("password does not match hash")
+	ErrCantParsePasswordHash        = errors.New("can't parse password hash")
+	ErrCantCreateTokens             = errors.New("can't create tokens")
+	ErrCantCreateSession            = errors.New("can't create session")
+	ErrCantUpdateTokens             = errors.New("can't update tokens")
+	ErrInvalidToken                 = errors.New("invalid token")
+	ErrCantGetSession               = errors.New("can't get session")
+	ErrSessionNotFound              = errors.New("session not found")
+	ErrCantDeleteSession            = errors.New("can't delete session")
+	ErrTokensDontMatch              = errors.New("tokens don't match")
+	ErrRefreshTokenReused           = errors.New("refresh token reuse detected")
+	ErrCantBuildSQLQuery            = errors.New("cant build sql query")
+	ErrCantExecSQLQuery             = errors.New("can't exec sql query")
+	ErrCantOpenDatabase             = errors.New("can't open database")
+	ErrCantCheckRevocationToken     = errors.New("can't verify the revocation of the token")
+	ErrCantRevokeToken              = errors.New("can't revoke token")
+	ErrRedisPingFailed              = errors.New("redis ping failed")
+	ErrClientNotFound               = errors.New("client not found")
+	ErrInvalidClient                = errors.New("invalid client credentials")
+	ErrCantGetClient                = errors.New("can't get client")
+	ErrUnauthorizedClient           = errors.New("client is not allowed to use this grant type")
+	ErrInvalidScope                 = errors.New("invalid scope")
+	ErrInvalidRedirectURI           = errors.New("invalid redirect uri")
+	ErrUnsupportedResponseType      = errors.New("unsupported response type")
+	ErrInvalidRequest               = errors.New("invalid request")
+	ErrInvalidGrant                 = errors.New("invalid grant")
+	ErrAuthorizationCodeNotFound    = errors.New("authorization code not found")
+	ErrCantSaveAuthorizationCode    = errors.New("can't save authorization code")
+	ErrCantConsumeAuthorizationCode = errors.New("can't consume authorization code")
+	ErrMFARequired                  = errors.New("mfa required")
+	ErrTOTPNotFound                 = errors.New("totp is not enrolled")
+	ErrTOTPAlreadyEnrolled          = errors.New("totp is already enrolled")
+	ErrTOTPUnavailable              = errors.New("totp is not configured")
+	ErrInvalidTOTPCode              = errors.New("invalid totp code")
+	ErrCantGetTOTP                  = errors.New("can't get totp")
+	ErrCantSaveTOTP                 = errors.New("can't save totp")
+	ErrCantSaveWebhookEvents        = errors.New("can't save webhook events")
+	ErrWebhookSubscriptionNotFound  = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound      = errors.New("webhook delivery not found")
+	ErrWebhookDeliveryLeaseLost     = errors.New("webhook delivery lease lost")
+	ErrInvalidWebhookURL            = errors.New("invalid webhook url")
+	ErrInvalidWebhookEventType      = errors.New("invalid webhook event type")
+	ErrInvalidWebhookStatus         = errors.New("invalid webhook subscription status")
+	ErrCantGetWebhookSubscription   = errors.New("can't get webhook subscription")
+	ErrCantSaveWebhookSubscription  = errors.New("can't save webhook subscription")
+	ErrCantGetWebhookDeliveries     = errors.New("can't get webhook deliveries")
+	ErrCantReplayWebhookDelivery    = errors.New("can't replay webhook delivery")
+	ErrInsufficientScope            = errors.New("insufficient scope")
+	ErrInvalidAuditCursor           = errors.New("invalid audit cursor")
+	ErrInvalidAuditEventType        = errors.New("invalid audit event type")
+	ErrInvalidAuditTimeRange        = errors.New("invalid audit time range")
+	ErrCantGetAuditEvents           = errors.New("can't get audit events")
+	ErrAuditEventNotFound           = errors.New("audit event not found")
+	ErrAuditCheckpointNotFound      = errors.New("audit checkpoint not found")
+	ErrCantSaveAuditCheckpoint      = errors.New("can't save audit checkpoint")
+	ErrCantDecryptSecret            = errors.New("can't decrypt secret")
+	ErrCantParseSigningKey          = errors.New("can't parse signing key")
+	ErrUnsupportedSigningKey        = errors.New("unsupported signing key")
+	ErrRequestCanceled              = errors.New("request canceled")
+	ErrRevocationStoreFull          = errors.New("token revocation store is full")
 )
+
+// MFARequiredError — первый фактор пройден, но у пользователя включён TOTP: вместо пары токенов
+// выдаётся короткоживущий MFA токен, который обменивается на пару после проверки кода
+type MFARequiredError struct {
+	MFAToken  string
+	ExpiresIn time.Duration
+}
+
+func (err *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (err *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// causeError — ошибка приложения вместе с исходной причиной. Наружу уходит только текст err,
+// а причина остаётся для логов: errors.Is находит и err, и cause.
+type causeError struct {
+	err   error
+	cause error
+}
+
+// Wrap прикрепляет к ошибке приложения исходную ошибку зависимости (Postgres, Redis, bcrypt)
+func Wrap(err error, cause error) error {
+	if cause == nil {
+		return err
+	}
+	return &causeError{err: err, cause: cause}
+}
+
+func (err *causeError) Error() string {
+	return err.err.Error()
+}
+
+func (err *causeError) Unwrap() []error {
+	return []error{err.err, err.cause}
+}
+
+// Cause — исходная ошибка, прикреплённая через Wrap, или nil
+func Cause(err error) error {
+	var causeErr *causeError
+	if !errors.As(err, &causeErr) {
+		return nil
+	}
+	// самая глубокая причина: repo и сервис могут обернуть одну ошибку дважды
+	if deeper := Cause(causeErr.cause); deeper != nil {
+		return deeper
+	}
+	return causeErr.cause
+}
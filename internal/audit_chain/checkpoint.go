@@ -0,0 +1,28 @@
+package audit_chain
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+// TokenUseCheckpoint отличает подпись контрольной точки от access токена, подписанного тем же ключом
+const TokenUseCheckpoint = "audit_checkpoint"
+
+// CheckpointClaims — содержимое подписи контрольной точки: последняя закреплённая запись и её хеш
+type CheckpointClaims struct {
+	AuditID  int64  `json:"audit_id"`
+	Hash     string `json:"hash"`
+	TokenUse string `json:"token_use"`
+	jwt.RegisteredClaims
+}
+
+func NewCheckpointClaims(auditID int64, hash string, now time.Time) *CheckpointClaims {
+	return &CheckpointClaims{
+		AuditID:  auditID,
+		Hash:     hash,
+		TokenUse: TokenUseCheckpoint,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(now),
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package audit_chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"hash"
+	"strconv"
+)
+
+// Hash — sha256 записи журнала вместе с хешем предыдущей. Поля пишутся с префиксом длины,
+// поэтому перенос символов из одного поля в соседнее меняет хеш; время — в микросекундах, с точностью Postgres.
+func Hash(prevHash string, event *audit_events.AuditEvents) string {
+	h := sha256.New()
+	for _, field := range []string{
+		prevHash,
+		event.EventType,
+		event.Actor,
+		event.UserID,
+		event.SessionID,
+		event.IPAddr,
+		event.UserAgent,
+		event.Outcome,
+		event.ErrorCode,
+		strconv.FormatInt(event.CreatedAt.UnixMicro(), 10),
+	} {
+		writeField(h, field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeField(h hash.Hash, field string) {
+	fmt.Fprintf(h, "%d:%s", len(field), field)
+}
@@ -0,0 +1,123 @@
+package audit_chain
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+)
+
+// maxLineSize — строка выгрузки с длинным User-Agent не должна обрывать проверку
+const maxLineSize = 1 << 20
+
+// CheckpointVerifier проверяет подпись контрольной точки ключом сервиса
+type CheckpointVerifier interface {
+	Verify(tokenStr string, claims jwt.Claims) error
+}
+
+// BrokenLink — первое место, где цепочка не сходится
+type BrokenLink struct {
+	Line    int
+	AuditID int64
+	Reason  string
+}
+
+type Report struct {
+	// Unchained — записи, сделанные до появления цепочки; они ничем не защищены
+	Unchained   int
+	Chained     int
+	Checkpoints int
+	// LastCheckpointAuditID — последняя запись, закреплённая подписью; записи после неё можно незаметно отрезать
+	LastCheckpointAuditID int64
+	// Uncovered — цепочечные записи после последней контрольной точки
+	Uncovered int
+	Broken    *BrokenLink
+}
+
+// Verify проходит выгрузку журнала (JSON Lines из ExportRecord) по порядку: пересчитывает хеш каждой записи,
+// сверяет его со следующей записью и проверяет подписи контрольных точек. Останавливается на первом разрыве.
+// Ошибка возвращается только если выгрузку нельзя прочитать; разрыв цепочки — в Report.Broken.
+func Verify(r io.Reader, verifier CheckpointVerifier) (*Report, error) {
+	report := &Report{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var (
+		line        int
+		lastAuditID int64
+		lastHash    string
+	)
+	broken := func(auditID int64, format string, args ...interface{}) (*Report, error) {
+		report.Broken = &BrokenLink{Line: line, AuditID: auditID, Reason: fmt.Sprintf(format, args...)}
+		return report, nil
+	}
+
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record audit_events.ExportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return broken(0, "malformed record: %v", err)
+		}
+
+		switch {
+		case record.Event != nil:
+			event := record.Event
+			if event.AuditID <= lastAuditID {
+				return broken(event.AuditID, "record out of order after audit_id %d", lastAuditID)
+			}
+
+			if event.Hash == "" {
+				if report.Chained > 0 {
+					return broken(event.AuditID, "record without hash inside the chain")
+				}
+				report.Unchained++
+				lastAuditID = event.AuditID
+				continue
+			}
+
+			if event.PrevHash != lastHash {
+				return broken(event.AuditID, "prev_hash does not match the previous record: a record was deleted, inserted or reordered")
+			}
+			if Hash(event.PrevHash, event) != event.Hash {
+				return broken(event.AuditID, "hash does not match the record: the record was modified")
+			}
+
+			report.Chained++
+			report.Uncovered++
+			lastAuditID = event.AuditID
+			lastHash = event.Hash
+
+		case record.Checkpoint != nil:
+			checkpoint := record.Checkpoint
+			if checkpoint.AuditID != lastAuditID || checkpoint.Hash != lastHash || lastHash == "" {
+				return broken(checkpoint.AuditID, "checkpoint %d does not match the chain", checkpoint.CheckpointID)
+			}
+
+			var claims CheckpointClaims
+			if err := verifier.Verify(checkpoint.Signature, &claims); err != nil {
+				return broken(checkpoint.AuditID, "checkpoint %d signature is invalid", checkpoint.CheckpointID)
+			}
+			if claims.TokenUse != TokenUseCheckpoint || claims.AuditID != checkpoint.AuditID || claims.Hash != checkpoint.Hash {
+				return broken(checkpoint.AuditID, "checkpoint %d signature does not cover this record", checkpoint.CheckpointID)
+			}
+
+			report.Checkpoints++
+			report.LastCheckpointAuditID = checkpoint.AuditID
+			report.Uncovered = 0
+
+		default:
+			return broken(0, "record is neither an event nor a checkpoint")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,142 @@
+package audit_chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/token_signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportBuilder struct {
+	t        *testing.T
+	signer   *token_signer.TokenSigner
+	records  []*audit_events.ExportRecord
+	lastID   int64
+	lastHash string
+}
+
+func newExportBuilder(t *testing.T, signer *token_signer.TokenSigner) *exportBuilder {
+	return &exportBuilder{t: t, signer: signer}
+}
+
+func (b *exportBuilder) unchained() *exportBuilder {
+	b.lastID++
+	b.records = append(b.records, &audit_events.ExportRecord{Event: &audit_events.AuditEvents{AuditID: b.lastID, EventType: audit_events.EventLogin}})
+	return b
+}
+
+func (b *exportBuilder) event() *exportBuilder {
+	b.lastID++
+	event := &audit_events.AuditEvents{
+		AuditID:   b.lastID,
+		EventType: audit_events.EventLogin,
+		Actor:     "user",
+		UserID:    "u",
+		Outcome:   audit_events.OutcomeSuccess,
+		CreatedAt: time.Unix(b.lastID, 0).UTC(),
+		PrevHash:  b.lastHash,
+	}
+	event.Hash = Hash(event.PrevHash, event)
+	b.lastHash = event.Hash
+	b.records = append(b.records, &audit_events.ExportRecord{Event: event})
+	return b
+}
+
+func (b *exportBuilder) checkpoint() *exportBuilder {
+	signature, err := b.signer.Sign(NewCheckpointClaims(b.lastID, b.lastHash, time.Now()))
+	require.NoError(b.t, err)
+	b.records = append(b.records, &audit_events.ExportRecord{Checkpoint: &audit_events.AuditCheckpoints{
+		CheckpointID: int64(len(b.records)),
+		AuditID:      b.lastID,
+		Hash:         b.lastHash,
+		Signature:    signature,
+	}})
+	return b
+}
+
+func (b *exportBuilder) ndjson() *bytes.Buffer {
+	var buf bytes.Buffer
+	for _, record := range b.records {
+		require.NoError(b.t, json.NewEncoder(&buf).Encode(record))
+	}
+	return &buf
+}
+
+func TestVerify(t *testing.T) {
+	signer := token_signer.NewHMACTokenSigner([]byte("secret"))
+
+	t.Run("intact chain", func(t *testing.T) {
+		export := newExportBuilder(t, signer).unchained().unchained().event().event().checkpoint().event()
+		report, err := Verify(export.ndjson(), signer)
+		require.NoError(t, err)
+		assert.Nil(t, report.Broken)
+		assert.Equal(t, 2, report.Unchained)
+		assert.Equal(t, 3, report.Chained)
+		assert.Equal(t, 1, report.Checkpoints)
+		assert.Equal(t, int64(4), report.LastCheckpointAuditID)
+		assert.Equal(t, 1, report.Uncovered)
+	})
+
+	t.Run("modified record", func(t *testing.T) {
+		export := newExportBuilder(t, signer).event().event().event()
+		export.records[1].Event.UserID = "attacker"
+		report, err := Verify(export.ndjson(), signer)
+		require.NoError(t, err)
+		require.NotNil(t, report.Broken)
+		assert.Equal(t, 2, report.Broken.Line)
+		assert.Equal(t, int64(2), report.Broken.AuditID)
+		assert.Contains(t, report.Broken.Reason, "modified")
+	})
+
+	t.Run("deleted record", func(t *testing.T) {
+		export := newExportBuilder(t, signer).event().event().event()
+		export.records = append(export.records[:1], export.records[2:]...)
+		report, err := Verify(export.ndjson(), signer)
+		require.NoError(t, err)
+		require.NotNil(t, report.Broken)
+		assert.Equal(t, int64(3), report.Broken.AuditID)
+		assert.Contains(t, report.Broken.Reason, "prev_hash")
+	})
+
+	t.Run("unchained record inside the chain", func(t *testing.T) {
+		export := newExportBuilder(t, signer).event().unchained()
+		report, err := Verify(export.ndjson(), signer)
+		require.NoError(t, err)
+		require.NotNil(t, report.Broken)
+		assert.Equal(t, int64(2), report.Broken.AuditID)
+	})
+
+	t.Run("checkpoint signed by another key", func(t *testing.T) {
+		export := newExportBuilder(t, token_signer.NewHMACTokenSigner([]byte("other"))).event().checkpoint()
+		report, err := Verify(export.ndjson(), signer)
+		require.NoError(t, err)
+		require.NotNil(t, report.Broken)
+		assert.Equal(t, 2, report.Broken.Line)
+		assert.Contains(t, report.Broken.Reason, "signature is invalid")
+	})
+
+	t.Run("chain rewritten after the checkpoint was signed", func(t *testing.T) {
+		export := newExportBuilder(t, signer).event().checkpoint()
+		event := export.records[0].Event
+		event.UserID = "attacker"
+		event.Hash = Hash(event.PrevHash, event)
+		export.records[1].Checkpoint.Hash = event.Hash
+		report, err := Verify(export.ndjson(), signer)
+		require.NoError(t, err)
+		require.NotNil(t, report.Broken)
+		assert.Contains(t, report.Broken.Reason, "does not cover")
+	})
+
+	t.Run("malformed line", func(t *testing.T) {
+		report, err := Verify(strings.NewReader("{\"event\":\n"), signer)
+		require.NoError(t, err)
+		require.NotNil(t, report.Broken)
+		assert.Equal(t, 1, report.Broken.Line)
+	})
+}
@@ -0,0 +1,29 @@
+package audit_service
+
+import (
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"log/slog"
+	"time"
+)
+
+// AuditService отдаёт журнал аутентификации администраторам и закрепляет его hash chain
+// контрольными точками, подписанными ключом подписи сервиса
+type AuditService struct {
+	repo        Repo
+	tokenSigner TokenSigner
+	logger      *slog.Logger
+
+	exportBatchSize int
+	now             func() time.Time
+}
+
+func NewAuditService(repo Repo, tokenSigner TokenSigner, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		repo:        repo,
+		tokenSigner: tokenSigner,
+		logger:      logging.OrDiscard(logger).With("component", "audit_service"),
+
+		exportBatchSize: 500,
+		now:             time.Now,
+	}
+}
@@ -0,0 +1,268 @@
+package audit_service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/audit_chain"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/token_signer"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) ListAuditEvents(_ context.Context, filter *audit_events.AuditEventFilter) ([]*audit_events.AuditEvents, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]*audit_events.AuditEvents), args.Error(1)
+}
+
+func (m *mockRepo) ListAuditEventsAfter(_ context.Context, afterID int64, limit int) ([]*audit_events.AuditEvents, error) {
+	args := m.Called(afterID, limit)
+	return args.Get(0).([]*audit_events.AuditEvents), args.Error(1)
+}
+
+func (m *mockRepo) GetLastAuditEvent(_ context.Context) (*audit_events.AuditEvents, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit_events.AuditEvents), args.Error(1)
+}
+
+func (m *mockRepo) GetLastAuditCheckpoint(_ context.Context) (*audit_events.AuditCheckpoints, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit_events.AuditCheckpoints), args.Error(1)
+}
+
+func (m *mockRepo) InsertAuditCheckpoint(_ context.Context, checkpoint *audit_events.AuditCheckpoints) error {
+	return m.Called(checkpoint).Error(0)
+}
+
+func (m *mockRepo) ListAuditCheckpoints(_ context.Context) ([]*audit_events.AuditCheckpoints, error) {
+	args := m.Called()
+	return args.Get(0).([]*audit_events.AuditCheckpoints), args.Error(1)
+}
+
+type mockTokenSigner struct{ mock.Mock }
+
+func (m *mockTokenSigner) Sign(claims jwt.Claims) (string, error) {
+	args := m.Called(claims)
+	return args.String(0), args.Error(1)
+}
+
+func makeEvents(ids ...int64) []*audit_events.AuditEvents {
+	events := make([]*audit_events.AuditEvents, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, &audit_events.AuditEvents{AuditID: id, EventType: audit_events.EventLogin})
+	}
+	return events
+}
+
+func TestAuditService_ListEvents(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewAuditService(repo, nil, nil)
+
+	t.Run("invalid time range", func(t *testing.T) {
+		now := time.Now()
+		_, _, err := svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{From: now, To: now.Add(-time.Hour)}, "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidAuditTimeRange)
+	})
+
+	t.Run("unknown event type", func(t *testing.T) {
+		_, _, err := svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{EventTypes: []string{"session.created"}}, "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidAuditEventType)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		for _, cursor := range []string{"%%%", encodeCursor(0), "YWJj"} {
+			_, _, err := svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{}, cursor)
+			assert.ErrorIs(t, err, apperrors.ErrInvalidAuditCursor, cursor)
+		}
+	})
+
+	t.Run("pages through the log", func(t *testing.T) {
+		repo.On("ListAuditEvents", &audit_events.AuditEventFilter{UserID: "u", Limit: 3}).Return(makeEvents(10, 9, 8), nil).Once()
+		events, cursor, err := svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{UserID: "u", Limit: 2}, "")
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+		assert.NotEmpty(t, cursor)
+
+		repo.On("ListAuditEvents", &audit_events.AuditEventFilter{UserID: "u", BeforeID: 9, Limit: 3}).Return(makeEvents(8), nil).Once()
+		events, cursor, err = svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{UserID: "u", Limit: 2}, cursor)
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Empty(t, cursor)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("default and max limit", func(t *testing.T) {
+		repo.On("ListAuditEvents", &audit_events.AuditEventFilter{Limit: defaultEventsLimit + 1}).Return(makeEvents(), nil).Once()
+		_, _, err := svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{}, "")
+		assert.NoError(t, err)
+
+		repo.On("ListAuditEvents", &audit_events.AuditEventFilter{Limit: maxEventsLimit + 1}).Return(makeEvents(), nil).Once()
+		_, _, err = svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{Limit: 1_000_000}, "")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		dbErr := errors.New("fail")
+		repo.On("ListAuditEvents", mock.Anything).Return(makeEvents(), dbErr).Once()
+		_, _, err := svc.ListEvents(t.Context(), &audit_events.AuditEventFilter{}, "")
+		assert.ErrorIs(t, err, apperrors.ErrCantGetAuditEvents)
+		// исходная ошибка сохраняется для поля cause в логе
+		assert.ErrorIs(t, err, dbErr)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuditService_CreateCheckpoint(t *testing.T) {
+	last := &audit_events.AuditEvents{AuditID: 7, Hash: "h7"}
+
+	t.Run("empty log", func(t *testing.T) {
+		repo := new(mockRepo)
+		repo.On("GetLastAuditEvent").Return(nil, apperrors.ErrAuditEventNotFound).Once()
+		checkpoint, err := NewAuditService(repo, nil, nil).CreateCheckpoint(t.Context())
+		assert.NoError(t, err)
+		assert.Nil(t, checkpoint)
+	})
+
+	t.Run("only records written before the chain", func(t *testing.T) {
+		repo := new(mockRepo)
+		repo.On("GetLastAuditEvent").Return(&audit_events.AuditEvents{AuditID: 3}, nil).Once()
+		checkpoint, err := NewAuditService(repo, nil, nil).CreateCheckpoint(t.Context())
+		assert.NoError(t, err)
+		assert.Nil(t, checkpoint)
+	})
+
+	t.Run("nothing new since the last checkpoint", func(t *testing.T) {
+		repo := new(mockRepo)
+		repo.On("GetLastAuditEvent").Return(last, nil).Once()
+		repo.On("GetLastAuditCheckpoint").Return(&audit_events.AuditCheckpoints{AuditID: 7, Hash: "h7"}, nil).Once()
+		checkpoint, err := NewAuditService(repo, nil, nil).CreateCheckpoint(t.Context())
+		assert.NoError(t, err)
+		assert.Nil(t, checkpoint)
+		repo.AssertNotCalled(t, "InsertAuditCheckpoint", mock.Anything)
+	})
+
+	t.Run("signs the last record", func(t *testing.T) {
+		repo := new(mockRepo)
+		tokenSigner := new(mockTokenSigner)
+		repo.On("GetLastAuditEvent").Return(last, nil).Once()
+		repo.On("GetLastAuditCheckpoint").Return(&audit_events.AuditCheckpoints{AuditID: 5, Hash: "h5"}, nil).Once()
+		tokenSigner.On("Sign", mock.MatchedBy(func(claims *audit_chain.CheckpointClaims) bool {
+			return claims.AuditID == 7 && claims.Hash == "h7" && claims.TokenUse == audit_chain.TokenUseCheckpoint
+		})).Return("signature", nil).Once()
+		repo.On("InsertAuditCheckpoint", &audit_events.AuditCheckpoints{AuditID: 7, Hash: "h7", Signature: "signature"}).Return(nil).Once()
+
+		checkpoint, err := NewAuditService(repo, tokenSigner, nil).CreateCheckpoint(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), checkpoint.AuditID)
+		repo.AssertExpectations(t)
+		tokenSigner.AssertExpectations(t)
+	})
+
+	t.Run("first checkpoint", func(t *testing.T) {
+		repo := new(mockRepo)
+		tokenSigner := new(mockTokenSigner)
+		repo.On("GetLastAuditEvent").Return(last, nil).Once()
+		repo.On("GetLastAuditCheckpoint").Return(nil, apperrors.ErrAuditCheckpointNotFound).Once()
+		tokenSigner.On("Sign", mock.Anything).Return("signature", nil).Once()
+		repo.On("InsertAuditCheckpoint", mock.Anything).Return(nil).Once()
+
+		checkpoint, err := NewAuditService(repo, tokenSigner, nil).CreateCheckpoint(t.Context())
+		assert.NoError(t, err)
+		assert.NotNil(t, checkpoint)
+	})
+
+	t.Run("sign error", func(t *testing.T) {
+		repo := new(mockRepo)
+		tokenSigner := new(mockTokenSigner)
+		repo.On("GetLastAuditEvent").Return(last, nil).Once()
+		repo.On("GetLastAuditCheckpoint").Return(nil, apperrors.ErrAuditCheckpointNotFound).Once()
+		tokenSigner.On("Sign", mock.Anything).Return("", errors.New("fail")).Once()
+
+		_, err := NewAuditService(repo, tokenSigner, nil).CreateCheckpoint(t.Context())
+		assert.ErrorIs(t, err, apperrors.ErrCantSaveAuditCheckpoint)
+	})
+
+	t.Run("repo error", func(t *testing.T) {
+		repo := new(mockRepo)
+		dbErr := errors.New("fail")
+		repo.On("GetLastAuditEvent").Return(nil, dbErr).Once()
+		_, err := NewAuditService(repo, nil, nil).CreateCheckpoint(t.Context())
+		assert.ErrorIs(t, err, apperrors.ErrCantGetAuditEvents)
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
+
+// chainedEvents строит цепочку так же, как репозиторий при вставке
+func chainedEvents(from, to int64) []*audit_events.AuditEvents {
+	var events []*audit_events.AuditEvents
+	var prevHash string
+	for id := from; id <= to; id++ {
+		event := &audit_events.AuditEvents{
+			AuditID:   id,
+			EventType: audit_events.EventLogin,
+			Actor:     "user",
+			Outcome:   audit_events.OutcomeSuccess,
+			CreatedAt: time.Unix(id, 0).UTC(),
+			PrevHash:  prevHash,
+		}
+		event.Hash = audit_chain.Hash(prevHash, event)
+		prevHash = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestAuditService_Export(t *testing.T) {
+	signer := token_signer.NewHMACTokenSigner([]byte("secret"))
+	events := chainedEvents(1, 5)
+	signature, err := signer.Sign(audit_chain.NewCheckpointClaims(2, events[1].Hash, time.Now()))
+	assert.NoError(t, err)
+
+	repo := new(mockRepo)
+	svc := NewAuditService(repo, signer, nil)
+	svc.exportBatchSize = 2
+	repo.On("ListAuditCheckpoints").Return([]*audit_events.AuditCheckpoints{{CheckpointID: 1, AuditID: 2, Hash: events[1].Hash, Signature: signature}}, nil).Once()
+	repo.On("ListAuditEventsAfter", int64(0), 2).Return(events[0:2], nil).Once()
+	repo.On("ListAuditEventsAfter", int64(2), 2).Return(events[2:4], nil).Once()
+	repo.On("ListAuditEventsAfter", int64(4), 2).Return(events[4:], nil).Once()
+
+	var buf bytes.Buffer
+	assert.NoError(t, svc.Export(t.Context(), &buf))
+	repo.AssertExpectations(t)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 6)
+	var record audit_events.ExportRecord
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &record))
+	assert.NotNil(t, record.Checkpoint)
+
+	// выгрузку принимает офлайн проверка
+	report, err := audit_chain.Verify(&buf, signer)
+	assert.NoError(t, err)
+	assert.Nil(t, report.Broken)
+	assert.Equal(t, 5, report.Chained)
+	assert.Equal(t, 3, report.Uncovered)
+
+	t.Run("repo error", func(t *testing.T) {
+		repo := new(mockRepo)
+		repo.On("ListAuditCheckpoints").Return([]*audit_events.AuditCheckpoints(nil), errors.New("fail")).Once()
+		assert.ErrorIs(t, NewAuditService(repo, nil, nil).Export(t.Context(), &bytes.Buffer{}), apperrors.ErrCantGetAuditEvents)
+	})
+}
@@ -0,0 +1,48 @@
+package audit_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/audit_chain"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+// CreateCheckpoint подписывает хеш последней записи журнала. Если с прошлой контрольной точки
+// новых записей нет, возвращает nil без ошибки.
+func (auditService *AuditService) CreateCheckpoint(ctx context.Context) (*audit_events.AuditCheckpoints, error) {
+	last, err := auditService.repo.GetLastAuditEvent(ctx)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrAuditEventNotFound) {
+			return nil, nil
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantGetAuditEvents, err)
+	}
+	// записи до появления цепочки закреплять нечем
+	if last.Hash == "" {
+		return nil, nil
+	}
+
+	previous, err := auditService.repo.GetLastAuditCheckpoint(ctx)
+	if err != nil && !errors.Is(err, apperrors.ErrAuditCheckpointNotFound) {
+		return nil, apperrors.Wrap(apperrors.ErrCantGetAuditEvents, err)
+	}
+	if err == nil && previous.AuditID == last.AuditID {
+		return nil, nil
+	}
+
+	signature, err := auditService.tokenSigner.Sign(audit_chain.NewCheckpointClaims(last.AuditID, last.Hash, auditService.now()))
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantSaveAuditCheckpoint, err)
+	}
+
+	checkpoint := &audit_events.AuditCheckpoints{
+		AuditID:   last.AuditID,
+		Hash:      last.Hash,
+		Signature: signature,
+	}
+	if err = auditService.repo.InsertAuditCheckpoint(ctx, checkpoint); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantSaveAuditCheckpoint, err)
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,44 @@
+package audit_service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"io"
+)
+
+// Export пишет весь журнал в порядке цепочки в формате JSON Lines: каждая строка — audit_events.ExportRecord,
+// контрольная точка идёт сразу после записи, которую закрепляет. Выгрузку проверяет cmd/audit_verify.
+func (auditService *AuditService) Export(ctx context.Context, w io.Writer) error {
+	checkpoints, err := auditService.repo.ListAuditCheckpoints(ctx)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrCantGetAuditEvents, err)
+	}
+
+	encoder := json.NewEncoder(w)
+	var afterID int64
+	for {
+		events, err := auditService.repo.ListAuditEventsAfter(ctx, afterID, auditService.exportBatchSize)
+		if err != nil {
+			return apperrors.Wrap(apperrors.ErrCantGetAuditEvents, err)
+		}
+
+		for _, event := range events {
+			if err = encoder.Encode(&audit_events.ExportRecord{Event: event}); err != nil {
+				return err
+			}
+			for len(checkpoints) > 0 && checkpoints[0].AuditID == event.AuditID {
+				if err = encoder.Encode(&audit_events.ExportRecord{Checkpoint: checkpoints[0]}); err != nil {
+					return err
+				}
+				checkpoints = checkpoints[1:]
+			}
+			afterID = event.AuditID
+		}
+
+		if len(events) < auditService.exportBatchSize {
+			return nil
+		}
+	}
+}
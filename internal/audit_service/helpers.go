@@ -0,0 +1,40 @@
+package audit_service
+
+import (
+	"encoding/base64"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"strconv"
+)
+
+// лимиты страницы журнала
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 1000
+)
+
+// encodeCursor — курсор непрозрачен для клиента: это audit_id последней отданной записи
+func encodeCursor(auditID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(auditID, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, apperrors.ErrInvalidAuditCursor
+	}
+	auditID, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || auditID <= 0 {
+		return 0, apperrors.ErrInvalidAuditCursor
+	}
+	return auditID, nil
+}
+
+func isKnownEventType(eventType string) bool {
+	for _, known := range audit_events.EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
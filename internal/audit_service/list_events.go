@@ -0,0 +1,50 @@
+package audit_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+// ListEvents возвращает страницу журнала, новые записи первыми, и курсор следующей страницы.
+// Пустой курсор в ответе — записей больше нет.
+func (auditService *AuditService) ListEvents(ctx context.Context, filter *audit_events.AuditEventFilter, cursor string) ([]*audit_events.AuditEvents, string, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, "", apperrors.ErrInvalidAuditTimeRange
+	}
+	for _, eventType := range filter.EventTypes {
+		if !isKnownEventType(eventType) {
+			return nil, "", apperrors.ErrInvalidAuditEventType
+		}
+	}
+
+	page := *filter
+	if cursor != "" {
+		beforeID, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		page.BeforeID = beforeID
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultEventsLimit
+	}
+	if limit > maxEventsLimit {
+		limit = maxEventsLimit
+	}
+	// лишняя запись показывает, есть ли следующая страница
+	page.Limit = limit + 1
+
+	events, err := auditService.repo.ListAuditEvents(ctx, &page)
+	if err != nil {
+		return nil, "", apperrors.Wrap(apperrors.ErrCantGetAuditEvents, err)
+	}
+
+	if len(events) <= limit {
+		return events, "", nil
+	}
+	events = events[:limit]
+	return events, encodeCursor(events[limit-1].AuditID), nil
+}
@@ -0,0 +1,15 @@
+package audit_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+type Repo interface {
+	ListAuditEvents(ctx context.Context, filter *audit_events.AuditEventFilter) ([]*audit_events.AuditEvents, error)
+	ListAuditEventsAfter(ctx context.Context, afterID int64, limit int) ([]*audit_events.AuditEvents, error)
+	GetLastAuditEvent(ctx context.Context) (*audit_events.AuditEvents, error)
+	GetLastAuditCheckpoint(ctx context.Context) (*audit_events.AuditCheckpoints, error)
+	InsertAuditCheckpoint(ctx context.Context, checkpoint *audit_events.AuditCheckpoints) error
+	ListAuditCheckpoints(ctx context.Context) ([]*audit_events.AuditCheckpoints, error)
+}
@@ -0,0 +1,24 @@
+package audit_service
+
+import (
+	"context"
+	"time"
+)
+
+// RunCheckpoints создаёт контрольную точку раз в interval, пока не отменят ctx
+func (auditService *AuditService) RunCheckpoints(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := auditService.CreateCheckpoint(ctx); err != nil {
+			auditService.logger.ErrorContext(ctx, "audit checkpoint failed", "error", err)
+		}
+	}
+}
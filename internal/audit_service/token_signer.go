@@ -0,0 +1,7 @@
+package audit_service
+
+import "github.com/golang-jwt/jwt/v5"
+
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+}
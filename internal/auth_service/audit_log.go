@@ -0,0 +1,10 @@
+package auth_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+type AuditLog interface {
+	InsertAuditEvent(ctx context.Context, event *audit_events.AuditEvents) error
+}
@@ -1,32 +1,77 @@
 package auth_service
 
-import "time"
+import (
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"log/slog"
+	"time"
+)
 
 type AuthService struct {
-	repo                 Repo
-	tokenRevocationStore TokenRevocationStore
+	repo                   Repo
+	tokenRevocationStore   TokenRevocationStore
+	authorizationCodeStore AuthorizationCodeStore
+	tokenSigner            TokenSigner
+
+	// secretBox шифрует TOTP секреты; без него второй фактор недоступен
+	secretBox SecretBox
+
+	// auditLog — журнал выдачи, обновления и завершения сессий; nil — журнал не ведётся
+	auditLog AuditLog
+
+	// logger — причины сбоев, которые наружу уходят общими apperrors, и подозрительные события сессий
+	logger *slog.Logger
+
+	// metrics — счётчики токенов и время хеширования; nil — метрики не собираются
+	metrics Metrics
 
 	ttlAccessToken time.Duration
 
-	jwtSecretKey []byte
-	webhookURL   string
+	// webhookEventTypes — какие события писать в webhook outbox; доставляет их webhook_dispatcher
+	webhookEventTypes map[string]bool
+
+	// trustedUserIDLogin разрешает CreateTokens по голому user_id — режим для доверенных внутренних вызовов
+	trustedUserIDLogin bool
+
+	// legacyRevocationKeysUntil — до этого момента black-list проверяется ещё и по ключу старого формата
+	// (сам токен), который пишут экземпляры до перехода на jti; нулевое время — старые ключи не проверяются
+	legacyRevocationKeysUntil time.Time
 }
 
 func NewAuthService(
 
 	repo Repo,
 	tokenRevocationStore TokenRevocationStore,
+	authorizationCodeStore AuthorizationCodeStore,
+	tokenSigner TokenSigner,
+	secretBox SecretBox,
+	auditLog AuditLog,
+	logger *slog.Logger,
+	metrics Metrics,
 	ttlAccessToken time.Duration,
-	jwtSecretKey []byte,
-	webhookURL string,
+	webhookEventTypes []string,
+	trustedUserIDLogin bool,
+	legacyRevocationKeysUntil time.Time,
 
 ) *AuthService {
 
+	enabledEventTypes := make(map[string]bool, len(webhookEventTypes))
+	for _, eventType := range webhookEventTypes {
+		enabledEventTypes[eventType] = true
+	}
+
 	return &AuthService{
-		repo:                 repo,
-		tokenRevocationStore: tokenRevocationStore,
-		ttlAccessToken:       ttlAccessToken,
-		jwtSecretKey:         jwtSecretKey,
-		webhookURL:           webhookURL,
+		repo:                   repo,
+		tokenRevocationStore:   tokenRevocationStore,
+		authorizationCodeStore: authorizationCodeStore,
+		tokenSigner:            tokenSigner,
+		secretBox:              secretBox,
+		auditLog:               auditLog,
+		logger:                 logging.OrDiscard(logger).With("component", "auth_service"),
+		metrics:                metrics,
+		ttlAccessToken:         ttlAccessToken,
+		webhookEventTypes:      enabledEventTypes,
+		trustedUserIDLogin:     trustedUserIDLogin,
+
+		legacyRevocationKeysUntil: legacyRevocationKeysUntil,
 	}
 }
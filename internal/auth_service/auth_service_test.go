@@ -1,72 +1,216 @@
 package auth_service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
 	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/user_totp"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/Turalchik/authentication-service/internal/password_hasher"
+	"github.com/Turalchik/authentication-service/internal/secret_box"
+	"github.com/Turalchik/authentication-service/internal/token_signer"
+	"github.com/Turalchik/authentication-service/internal/totp"
 )
 
+var signer = token_signer.NewHMACTokenSigner([]byte("secret"))
+
 type mockRepo struct{ mock.Mock }
 
-func (m *mockRepo) GetSessionByUserID(userID string) (*sessions.Sessions, error) {
-	args := m.Called(userID)
+func (m *mockRepo) GetSessionByID(_ context.Context, sessionID string) (*sessions.Sessions, error) {
+	args := m.Called(sessionID)
 	return args.Get(0).(*sessions.Sessions), args.Error(1)
 }
-func (m *mockRepo) CreateSession(session *sessions.Sessions) error {
-	return m.Called(session).Error(0)
+func (m *mockRepo) ListSessionsByUserID(_ context.Context, userID string) ([]*sessions.Sessions, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]*sessions.Sessions), args.Error(1)
+}
+func (m *mockRepo) CreateSession(_ context.Context, session *sessions.Sessions, events ...*webhook_events.WebhookEvents) error {
+	args := []interface{}{session}
+	if len(events) > 0 {
+		args = append(args, events)
+	}
+	return m.Called(args...).Error(0)
+}
+func (m *mockRepo) DeleteSessionsByIDs(_ context.Context, userID string, sessionIDs []string, events ...*webhook_events.WebhookEvents) error {
+	args := []interface{}{userID, sessionIDs}
+	if len(events) > 0 {
+		args = append(args, events)
+	}
+	return m.Called(args...).Error(0)
+}
+
+// события webhook передаются в мок последним аргументом, только если они есть
+func (m *mockRepo) DeleteSessionByID(_ context.Context, sessionID string, events ...*webhook_events.WebhookEvents) error {
+	args := []interface{}{sessionID}
+	if len(events) > 0 {
+		args = append(args, events)
+	}
+	return m.Called(args...).Error(0)
+}
+func (m *mockRepo) RotateRefreshToken(_ context.Context, sessionID string, usedRefreshTokenDigest string, oldRefreshTokenHash string, newRefreshTokenHash string, events ...*webhook_events.WebhookEvents) error {
+	args := []interface{}{sessionID, usedRefreshTokenDigest, oldRefreshTokenHash, newRefreshTokenHash}
+	if len(events) > 0 {
+		args = append(args, events)
+	}
+	return m.Called(args...).Error(0)
+}
+func (m *mockRepo) IsRefreshTokenRotated(_ context.Context, sessionID string, refreshTokenDigest string) (bool, error) {
+	args := m.Called(sessionID, refreshTokenDigest)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockRepo) GetClientByID(_ context.Context, clientID string) (*clients.Clients, error) {
+	args := m.Called(clientID)
+	return args.Get(0).(*clients.Clients), args.Error(1)
+}
+func (m *mockRepo) CreateUser(_ context.Context, user *users.Users) error {
+	return m.Called(user).Error(0)
+}
+func (m *mockRepo) GetUserByEmail(_ context.Context, email string) (*users.Users, error) {
+	args := m.Called(email)
+	return args.Get(0).(*users.Users), args.Error(1)
+}
+func (m *mockRepo) GetUserByID(_ context.Context, userID string) (*users.Users, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*users.Users), args.Error(1)
+}
+func (m *mockRepo) GetUserTOTP(_ context.Context, userID string) (*user_totp.UserTOTP, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*user_totp.UserTOTP), args.Error(1)
+}
+func (m *mockRepo) SaveUserTOTP(_ context.Context, userTOTP *user_totp.UserTOTP) error {
+	return m.Called(userTOTP).Error(0)
+}
+func (m *mockRepo) ConfirmUserTOTP(_ context.Context, userID string, step int64) error {
+	return m.Called(userID, step).Error(0)
+}
+func (m *mockRepo) UseTOTPStep(_ context.Context, userID string, step int64) error {
+	return m.Called(userID, step).Error(0)
+}
+
+type mockAuditLog struct{ mock.Mock }
+
+func (m *mockAuditLog) InsertAuditEvent(_ context.Context, event *audit_events.AuditEvents) error {
+	return m.Called(event).Error(0)
 }
-func (m *mockRepo) DeleteSessionByUserID(userID string) error {
-	return m.Called(userID).Error(0)
+
+type mockMetrics struct{ mock.Mock }
+
+func (m *mockMetrics) ObserveTokens(operation string, outcome string, errorCode string) {
+	m.Called(operation, outcome, errorCode)
 }
-func (m *mockRepo) UpdateRefreshTokenByUserID(userID string, newRefreshTokenHash string) error {
-	return m.Called(userID, newRefreshTokenHash).Error(0)
+func (m *mockMetrics) ObserveHash(algorithm string, operation string, duration time.Duration) {
+	m.Called(algorithm, operation, duration)
 }
 
 type mockTokenRevocationStore struct{ mock.Mock }
 
-func (m *mockTokenRevocationStore) Revoke(tokenID string, ttl time.Duration) error {
+func (m *mockTokenRevocationStore) Revoke(_ context.Context, tokenID string, ttl time.Duration) error {
 	return m.Called(tokenID, ttl).Error(0)
 }
-func (m *mockTokenRevocationStore) IsRevoked(tokenID string) (bool, error) {
+func (m *mockTokenRevocationStore) IsRevoked(_ context.Context, tokenID string) (bool, error) {
 	args := m.Called(tokenID)
 	return args.Bool(0), args.Error(1)
 }
+func (m *mockTokenRevocationStore) Increment(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	args := m.Called(key, ttl)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *mockTokenRevocationStore) Delete(_ context.Context, key string) error {
+	return m.Called(key).Error(0)
+}
+
+// jtiKey — ключ black-list, под которым отзывается токен
+func jtiKey(token string) string {
+	claims, _ := claimsFromAccessToken(token, signer)
+	return tokenRevocationKey(claims.ID)
+}
+
+// remainingTTL — отзыв хранится не дольше, чем осталось жить токену
+func remainingTTL(ttl time.Duration) interface{} {
+	return mock.MatchedBy(func(d time.Duration) bool { return d > 0 && d <= ttl })
+}
+
+type mockAuthorizationCodeStore struct{ mock.Mock }
+
+func (m *mockAuthorizationCodeStore) Save(_ context.Context, code string, authorizationCode *authorization_codes.AuthorizationCodes, ttl time.Duration) error {
+	return m.Called(code, authorizationCode, ttl).Error(0)
+}
+func (m *mockAuthorizationCodeStore) Consume(_ context.Context, code string) (*authorization_codes.AuthorizationCodes, error) {
+	args := m.Called(code)
+	return args.Get(0).(*authorization_codes.AuthorizationCodes), args.Error(1)
+}
 
 func TestAuthService_CreateTokens(t *testing.T) {
 	repo := new(mockRepo)
 	tokenStore := new(mockTokenRevocationStore)
-	svc := NewAuthService(repo, time.Minute, []byte("secret"), "", tokenStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	repo.On("GetUserTOTP", "u").Return((*user_totp.UserTOTP)(nil), apperrors.ErrTOTPNotFound).Maybe()
+
+	t.Run("user id login disabled", func(t *testing.T) {
+		strictSvc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, false, time.Time{})
+		access, refresh, err := strictSvc.CreateTokens(t.Context(), "u", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrUserIDLoginDisabled)
+		assert.Empty(t, access)
+		assert.Empty(t, refresh)
+	})
 
 	t.Run("invalid user id", func(t *testing.T) {
-		access, refresh, err := svc.CreateTokens("", "ua", "ip")
+		access, refresh, err := svc.CreateTokens(t.Context(), "", "ua", "ip")
 		assert.ErrorIs(t, err, apperrors.ErrInvalidUserID)
 		assert.Empty(t, access)
 		assert.Empty(t, refresh)
 	})
 
-	t.Run("user already exists", func(t *testing.T) {
-		repo.On("GetSessionByUserID", "u").Return(&sessions.Sessions{}, nil).Once()
-		access, refresh, err := svc.CreateTokens("u", "ua", "ip")
-		assert.ErrorIs(t, err, apperrors.ErrUserAlreadyExists)
+	t.Run("cant create session", func(t *testing.T) {
+		repo.On("CreateSession", mock.AnythingOfType("*sessions.Sessions")).Return(errors.New("fail")).Once()
+		access, refresh, err := svc.CreateTokens(t.Context(), "u", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrCantCreateSession)
 		assert.Empty(t, access)
 		assert.Empty(t, refresh)
 		repo.AssertExpectations(t)
 	})
 
 	t.Run("success", func(t *testing.T) {
-		repo.On("GetSessionByUserID", "u").Return((*sessions.Sessions)(nil), apperrors.ErrUserNotFound).Once()
 		repo.On("CreateSession", mock.AnythingOfType("*sessions.Sessions")).Return(nil).Once()
-		access, refresh, err := svc.CreateTokens("u", "ua", "ip")
+		access, refresh, err := svc.CreateTokens(t.Context(), "u", "ua", "ip")
 		assert.NoError(t, err)
 		assert.NotEmpty(t, access)
 		assert.NotEmpty(t, refresh)
+
+		claims, err := claimsFromAccessToken(access, signer)
+		assert.NoError(t, err)
+		assert.Equal(t, "u", claims.UserID)
+		assert.NotEmpty(t, claims.SessionID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("every call opens a new session", func(t *testing.T) {
+		var sessionIDs []string
+		repo.On("CreateSession", mock.AnythingOfType("*sessions.Sessions")).Run(func(args mock.Arguments) {
+			sessionIDs = append(sessionIDs, args.Get(0).(*sessions.Sessions).SessionID)
+		}).Return(nil).Twice()
+		_, _, err := svc.CreateTokens(t.Context(), "u", "laptop", "ip")
+		assert.NoError(t, err)
+		_, _, err = svc.CreateTokens(t.Context(), "u", "phone", "ip")
+		assert.NoError(t, err)
+		assert.Len(t, sessionIDs, 2)
+		assert.NotEqual(t, sessionIDs[0], sessionIDs[1])
 		repo.AssertExpectations(t)
 	})
 }
@@ -74,69 +218,136 @@ func TestAuthService_CreateTokens(t *testing.T) {
 func TestAuthService_Logout(t *testing.T) {
 	repo := new(mockRepo)
 	tokenStore := new(mockTokenRevocationStore)
-	svc := NewAuthService(repo, time.Minute, []byte("secret"), "", tokenStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
 
 	t.Run("cant revoke token", func(t *testing.T) {
-		tokenStore.On("Revoke", "access", time.Minute).Return(errors.New("fail")).Once()
-		err := svc.Logout("access", "u")
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(errors.New("fail")).Once()
+		err := svc.Logout(t.Context(), access, "s", "ua", "ip")
 		assert.ErrorIs(t, err, apperrors.ErrCantRevokeToken)
 		tokenStore.AssertExpectations(t)
 	})
 
 	t.Run("cant delete session", func(t *testing.T) {
-		tokenStore.On("Revoke", "access", time.Minute).Return(nil).Once()
-		repo.On("DeleteSessionByUserID", "u").Return(errors.New("fail")).Once()
-		err := svc.Logout("access", "u")
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		repo.On("DeleteSessionByID", "s").Return(errors.New("fail")).Once()
+		err := svc.Logout(t.Context(), access, "s", "ua", "ip")
 		assert.ErrorIs(t, err, apperrors.ErrCantDeleteSession)
 		tokenStore.AssertExpectations(t)
 		repo.AssertExpectations(t)
 	})
 
 	t.Run("success", func(t *testing.T) {
-		tokenStore.On("Revoke", "access", time.Minute).Return(nil).Once()
-		repo.On("DeleteSessionByUserID", "u").Return(nil).Once()
-		err := svc.Logout("access", "u")
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		repo.On("DeleteSessionByID", "s").Return(nil).Once()
+		err := svc.Logout(t.Context(), access, "s", "ua", "ip")
 		assert.NoError(t, err)
 		tokenStore.AssertExpectations(t)
 		repo.AssertExpectations(t)
 	})
+
+	t.Run("expired token is not stored", func(t *testing.T) {
+		tokenStore := new(mockTokenRevocationStore)
+		svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+		expired, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, -time.Minute, signer)
+		repo.On("DeleteSessionByID", "s").Return(nil).Once()
+		err := svc.Logout(t.Context(), expired, "s", "ua", "ip")
+		assert.NoError(t, err)
+		tokenStore.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
 }
 
 func TestAuthService_CheckAccessTokenValidity(t *testing.T) {
 	repo := new(mockRepo)
 	tokenStore := new(mockTokenRevocationStore)
-	svc := NewAuthService(repo, time.Minute, []byte("secret"), "", tokenStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
 
 	t.Run("token revoked", func(t *testing.T) {
-		tokenStore.On("IsRevoked", "token").Return(true, nil).Once()
-		userID, err := svc.CheckAccessTokenValidity("token")
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(true, nil).Once()
+		userID, _, err := svc.CheckAccessTokenValidity(t.Context(), access)
 		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
 		assert.Empty(t, userID)
 		tokenStore.AssertExpectations(t)
 	})
 
 	t.Run("cant check revocation", func(t *testing.T) {
-		tokenStore.On("IsRevoked", "token").Return(false, errors.New("fail")).Once()
-		userID, err := svc.CheckAccessTokenValidity("token")
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, errors.New("fail")).Once()
+		userID, _, err := svc.CheckAccessTokenValidity(t.Context(), access)
 		assert.ErrorIs(t, err, apperrors.ErrCantCheckRevocationToken)
 		assert.Empty(t, userID)
 		tokenStore.AssertExpectations(t)
 	})
 
 	t.Run("invalid token", func(t *testing.T) {
-		tokenStore.On("IsRevoked", "bad").Return(false, nil).Once()
-		userID, err := svc.CheckAccessTokenValidity("bad")
+		// подделку в Redis не проверяем
+		userID, _, err := svc.CheckAccessTokenValidity(t.Context(), "bad")
 		assert.Error(t, err)
 		assert.Empty(t, userID)
+		tokenStore.AssertNotCalled(t, "IsRevoked", "bad")
+	})
+
+	t.Run("token without jti", func(t *testing.T) {
+		access, _ := signer.Sign(&Claims{UserID: "u", SessionID: "s", RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		}})
+		userID, _, err := svc.CheckAccessTokenValidity(t.Context(), access)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		assert.Empty(t, userID)
+	})
+
+	t.Run("legacy key is honoured until cutoff", func(t *testing.T) {
+		legacySvc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Now().Add(time.Minute))
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", access).Return(true, nil).Once()
+		userID, _, err := legacySvc.CheckAccessTokenValidity(t.Context(), access)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		assert.Empty(t, userID)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("legacy key is ignored after cutoff", func(t *testing.T) {
+		legacySvc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Now().Add(-time.Second))
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:s").Return(false, nil).Once()
+		userID, _, err := legacySvc.CheckAccessTokenValidity(t.Context(), access)
+		assert.NoError(t, err)
+		assert.Equal(t, "u", userID)
+		tokenStore.AssertExpectations(t)
+		tokenStore.AssertNotCalled(t, "IsRevoked", access)
+	})
+
+	t.Run("session revoked", func(t *testing.T) {
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:s").Return(true, nil).Once()
+		userID, _, err := svc.CheckAccessTokenValidity(t.Context(), access)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		assert.Empty(t, userID)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("client token is not a user token", func(t *testing.T) {
+		access, _ := makeClientJWT("worker", "jobs:read", time.Minute, signer)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		userID, _, err := svc.CheckAccessTokenValidity(t.Context(), access)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		assert.Empty(t, userID)
 		tokenStore.AssertExpectations(t)
 	})
 
 	t.Run("success", func(t *testing.T) {
-		access, _ := makeJWT("u", time.Minute, []byte("secret"))
-		tokenStore.On("IsRevoked", access).Return(false, nil).Once()
-		userID, err := svc.CheckAccessTokenValidity(access)
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:s").Return(false, nil).Once()
+		userID, sessionID, err := svc.CheckAccessTokenValidity(t.Context(), access)
 		assert.NoError(t, err)
 		assert.Equal(t, "u", userID)
+		assert.Equal(t, "s", sessionID)
 		tokenStore.AssertExpectations(t)
 	})
 }
@@ -144,61 +355,1563 @@ func TestAuthService_CheckAccessTokenValidity(t *testing.T) {
 func TestAuthService_RefreshTokens(t *testing.T) {
 	repo := new(mockRepo)
 	tokenStore := new(mockTokenRevocationStore)
-	svc := NewAuthService(repo, time.Minute, []byte("secret"), "", tokenStore)
-	access, _ := makeJWT("u", time.Minute, []byte("secret"))
+	sid, otherSID := "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a", "c9f0f895-fb98-4ab2-9f3e-9d5c3b8a6e21"
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: sid}, time.Minute, signer)
 	hash, _ := bcrypt.GenerateFromPassword([]byte("refresh"), bcrypt.DefaultCost)
-	sess := &sessions.Sessions{UserID: "u", RefreshTokenHash: hash, UserAgent: "ua", IPAddr: "ip"}
+	sess := &sessions.Sessions{SessionID: sid, UserID: "u", RefreshTokenHash: hash, UserAgent: "ua", IPAddr: "ip"}
 
 	t.Run("token revoked", func(t *testing.T) {
-		tokenStore.On("IsRevoked", "revoked").Return(true, nil).Once()
-		_, _, err := svc.RefreshTokens("revoked", "refresh", "ua", "ip")
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(true, nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
 		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
 		tokenStore.AssertExpectations(t)
 	})
 
 	t.Run("invalid access token", func(t *testing.T) {
-		tokenStore.On("IsRevoked", "bad").Return(false, nil).Once()
-		_, _, err := svc.RefreshTokens("bad", "refresh", "ua", "ip")
+		_, _, err := svc.RefreshTokens(t.Context(), "bad", "refresh", "ua", "ip")
 		assert.Error(t, err)
-		tokenStore.AssertExpectations(t)
+		tokenStore.AssertNotCalled(t, "IsRevoked", "bad")
 	})
 
-	t.Run("user not found", func(t *testing.T) {
-		tokenStore.On("IsRevoked", access).Return(false, nil).Once()
-		repo.On("GetSessionByUserID", "u").Return((*sessions.Sessions)(nil), apperrors.ErrUserNotFound).Once()
-		_, _, err := svc.RefreshTokens(access, "refresh", "ua", "ip")
-		assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+	t.Run("session not found", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return((*sessions.Sessions)(nil), apperrors.ErrSessionNotFound).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrSessionNotFound)
 		tokenStore.AssertExpectations(t)
 		repo.AssertExpectations(t)
 	})
 
 	t.Run("cant get session", func(t *testing.T) {
-		tokenStore.On("IsRevoked", access).Return(false, nil).Once()
-		repo.On("GetSessionByUserID", "u").Return((*sessions.Sessions)(nil), errors.New("fail")).Once()
-		_, _, err := svc.RefreshTokens(access, "refresh", "ua", "ip")
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return((*sessions.Sessions)(nil), errors.New("fail")).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
 		assert.ErrorIs(t, err, apperrors.ErrCantGetSession)
 		tokenStore.AssertExpectations(t)
 		repo.AssertExpectations(t)
 	})
 
 	t.Run("tokens dont match", func(t *testing.T) {
-		tokenStore.On("IsRevoked", access).Return(false, nil).Once()
-		repo.On("GetSessionByUserID", "u").Return(sess, nil).Once()
-		_, _, err := svc.RefreshTokens(access, "wrong", "ua", "ip")
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("IsRefreshTokenRotated", sid, refreshTokenDigest("wrong")).Return(false, nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "wrong", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrTokensDontMatch)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token of another session", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("IsRefreshTokenRotated", otherSID, refreshTokenDigest(otherSID+".refresh")).Return(false, nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, otherSID+".refresh", "ua", "ip")
 		assert.ErrorIs(t, err, apperrors.ErrTokensDontMatch)
 		tokenStore.AssertExpectations(t)
 		repo.AssertExpectations(t)
 	})
 
+	t.Run("rotated refresh token reused", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("IsRefreshTokenRotated", sid, refreshTokenDigest("stolen")).Return(true, nil).Once()
+		tokenStore.On("Revoke", "session:"+sid, time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionByID", sid).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "stolen", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrRefreshTokenReused)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token rotated concurrently", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("RotateRefreshToken", sid, refreshTokenDigest("refresh"), string(hash), mock.Anything).Return(apperrors.ErrRefreshTokenReused).Once()
+		tokenStore.On("Revoke", "session:"+sid, time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionByID", sid).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrRefreshTokenReused)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("session of another user", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(&sessions.Sessions{SessionID: sid, UserID: otherSID}, nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("user agent changed", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		repo.On("DeleteSessionByID", sid).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "other-ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
 	t.Run("success", func(t *testing.T) {
-		tokenStore.On("IsRevoked", access).Return(false, nil).Once()
-		repo.On("GetSessionByUserID", "u").Return(sess, nil).Once()
-		repo.On("UpdateRefreshTokenByUserID", "u", mock.Anything).Return(nil).Once()
-		newAccess, newRefresh, err := svc.RefreshTokens(access, "refresh", "ua", "ip")
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("RotateRefreshToken", sid, refreshTokenDigest("refresh"), string(hash), mock.Anything).Return(nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		newAccess, newRefresh, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, newAccess)
+		assert.True(t, strings.HasPrefix(newRefresh, sid+"."))
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh and ip change are written to outbox with rotation", func(t *testing.T) {
+		svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, webhook_events.EventTypes, true, time.Time{})
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("RotateRefreshToken", sid, refreshTokenDigest("refresh"), string(hash), mock.Anything, mock.MatchedBy(func(events []*webhook_events.WebhookEvents) bool {
+			return hasEventTypes(events, webhook_events.EventSessionRefreshed, webhook_events.EventSessionIPChanged)
+		})).Return(nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "other-ip")
+		assert.NoError(t, err)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("user agent mismatch is written to outbox with logout", func(t *testing.T) {
+		svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, webhook_events.EventTypes, true, time.Time{})
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		repo.On("DeleteSessionByID", sid, mock.MatchedBy(func(events []*webhook_events.WebhookEvents) bool {
+			return hasEventTypes(events, webhook_events.EventSessionUAMismatch, webhook_events.EventSessionRevoked)
+		})).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "other-ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("only subscribed event types are written", func(t *testing.T) {
+		svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, []string{webhook_events.EventSessionIPChanged}, true, time.Time{})
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("RotateRefreshToken", sid, refreshTokenDigest("refresh"), string(hash), mock.Anything).Return(nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
+		assert.NoError(t, err)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success with session prefixed refresh token", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("IsRefreshTokenRotated", sid, refreshTokenDigest(sid+".refresh")).Return(false, nil).Once()
+		repo.On("RotateRefreshToken", sid, refreshTokenDigest(sid+".refresh"), string(hash), mock.Anything).Return(nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, sid+".refresh", "ua", "ip")
+		assert.NoError(t, err)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("old access token revocation failure does not fail rotation", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		repo.On("RotateRefreshToken", sid, refreshTokenDigest("refresh"), string(hash), mock.Anything).Return(nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(errors.New("fail")).Once()
+		newAccess, newRefresh, err := svc.RefreshTokens(t.Context(), access, "refresh", "ua", "ip")
 		assert.NoError(t, err)
 		assert.NotEmpty(t, newAccess)
 		assert.NotEmpty(t, newRefresh)
 		tokenStore.AssertExpectations(t)
 		repo.AssertExpectations(t)
 	})
+
+	t.Run("rotated refresh token reused after access token expired", func(t *testing.T) {
+		expired, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: sid}, -time.Minute, signer)
+		repo.On("IsRefreshTokenRotated", sid, refreshTokenDigest(sid+".stolen")).Return(true, nil).Once()
+		repo.On("GetSessionByID", sid).Return(sess, nil).Once()
+		tokenStore.On("Revoke", "session:"+sid, time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionByID", sid).Return(nil).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), expired, sid+".stolen", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrRefreshTokenReused)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token with garbage session id", func(t *testing.T) {
+		_, _, err := svc.RefreshTokens(t.Context(), access, "not-a-uuid.refresh", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		repo.AssertNotCalled(t, "IsRefreshTokenRotated", "not-a-uuid", mock.Anything)
+		repo.AssertNotCalled(t, "GetSessionByID", "not-a-uuid")
+	})
+
+	t.Run("rotated refresh token of already revoked session", func(t *testing.T) {
+		goneSID := "45c48cce-2e2d-4fbd-b6a4-7b1f0a8e3d52"
+		repo.On("IsRefreshTokenRotated", goneSID, refreshTokenDigest(goneSID+".stolen")).Return(true, nil).Once()
+		repo.On("GetSessionByID", goneSID).Return((*sessions.Sessions)(nil), apperrors.ErrSessionNotFound).Once()
+		_, _, err := svc.RefreshTokens(t.Context(), access, goneSID+".stolen", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrRefreshTokenReused)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_ListSessions(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+
+	t.Run("cant list sessions", func(t *testing.T) {
+		repo.On("ListSessionsByUserID", "u").Return(([]*sessions.Sessions)(nil), errors.New("fail")).Once()
+		userSessions, err := svc.ListSessions(t.Context(), "u")
+		assert.ErrorIs(t, err, apperrors.ErrCantGetSession)
+		assert.Nil(t, userSessions)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		expected := []*sessions.Sessions{{SessionID: "s1", UserID: "u"}, {SessionID: "s2", UserID: "u"}}
+		repo.On("ListSessionsByUserID", "u").Return(expected, nil).Once()
+		userSessions, err := svc.ListSessions(t.Context(), "u")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, userSessions)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	sessionID := "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a"
+
+	t.Run("invalid session id", func(t *testing.T) {
+		err := svc.RevokeSession(t.Context(), "u", "not-a-uuid")
+		assert.ErrorIs(t, err, apperrors.ErrSessionNotFound)
+		repo.AssertNotCalled(t, "GetSessionByID", "not-a-uuid")
+	})
+
+	t.Run("session not found", func(t *testing.T) {
+		repo.On("GetSessionByID", sessionID).Return((*sessions.Sessions)(nil), apperrors.ErrSessionNotFound).Once()
+		err := svc.RevokeSession(t.Context(), "u", sessionID)
+		assert.ErrorIs(t, err, apperrors.ErrSessionNotFound)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("session of another user", func(t *testing.T) {
+		repo.On("GetSessionByID", sessionID).Return(&sessions.Sessions{SessionID: sessionID, UserID: "other"}, nil).Once()
+		err := svc.RevokeSession(t.Context(), "u", sessionID)
+		assert.ErrorIs(t, err, apperrors.ErrSessionNotFound)
+		repo.AssertExpectations(t)
+		tokenStore.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+	})
+
+	t.Run("cant revoke token", func(t *testing.T) {
+		repo.On("GetSessionByID", sessionID).Return(&sessions.Sessions{SessionID: sessionID, UserID: "u"}, nil).Once()
+		tokenStore.On("Revoke", "session:"+sessionID, time.Minute).Return(errors.New("fail")).Once()
+		err := svc.RevokeSession(t.Context(), "u", sessionID)
+		assert.ErrorIs(t, err, apperrors.ErrCantRevokeToken)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo.On("GetSessionByID", sessionID).Return(&sessions.Sessions{SessionID: sessionID, UserID: "u"}, nil).Once()
+		tokenStore.On("Revoke", "session:"+sessionID, time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionByID", sessionID).Return(nil).Once()
+		err := svc.RevokeSession(t.Context(), "u", sessionID)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_RevokeOtherSessions(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	userSessions := []*sessions.Sessions{{SessionID: "s1"}, {SessionID: "current"}, {SessionID: "s2"}}
+
+	t.Run("cant list sessions", func(t *testing.T) {
+		repo.On("ListSessionsByUserID", "u").Return(([]*sessions.Sessions)(nil), errors.New("fail")).Once()
+		err := svc.RevokeOtherSessions(t.Context(), "u", "current")
+		assert.ErrorIs(t, err, apperrors.ErrCantGetSession)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant revoke keeps sessions", func(t *testing.T) {
+		repo.On("ListSessionsByUserID", "u").Return(userSessions, nil).Once()
+		tokenStore.On("Revoke", "session:s1", time.Minute).Return(errors.New("redis down")).Once()
+		err := svc.RevokeOtherSessions(t.Context(), "u", "current")
+		assert.ErrorIs(t, err, apperrors.ErrCantRevokeToken)
+		repo.AssertNotCalled(t, "DeleteSessionsByIDs", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("cant delete sessions", func(t *testing.T) {
+		repo.On("ListSessionsByUserID", "u").Return(userSessions, nil).Once()
+		tokenStore.On("Revoke", "session:s1", time.Minute).Return(nil).Once()
+		tokenStore.On("Revoke", "session:s2", time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionsByIDs", "u", []string{"s1", "s2"}).Return(errors.New("fail")).Once()
+		err := svc.RevokeOtherSessions(t.Context(), "u", "current")
+		assert.ErrorIs(t, err, apperrors.ErrCantDeleteSession)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo.On("ListSessionsByUserID", "u").Return(userSessions, nil).Once()
+		tokenStore.On("Revoke", "session:s1", time.Minute).Return(nil).Once()
+		tokenStore.On("Revoke", "session:s2", time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionsByIDs", "u", []string{"s1", "s2"}).Return(nil).Once()
+		err := svc.RevokeOtherSessions(t.Context(), "u", "current")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("revoked sessions are written to outbox with the delete", func(t *testing.T) {
+		svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, webhook_events.EventTypes, true, time.Time{})
+		repo.On("ListSessionsByUserID", "u").Return(userSessions, nil).Once()
+		tokenStore.On("Revoke", "session:s1", time.Minute).Return(nil).Once()
+		tokenStore.On("Revoke", "session:s2", time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionsByIDs", "u", []string{"s1", "s2"}, mock.MatchedBy(func(events []*webhook_events.WebhookEvents) bool {
+			return hasEventTypes(events, webhook_events.EventSessionRevoked, webhook_events.EventSessionRevoked)
+		})).Return(nil).Once()
+		err := svc.RevokeOtherSessions(t.Context(), "u", "current")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_WebhookEvents(t *testing.T) {
+	svc := NewAuthService(new(mockRepo), new(mockTokenRevocationStore), nil, signer, nil, nil, nil, nil, time.Minute, []string{webhook_events.EventSessionRevoked}, true, time.Time{})
+
+	assert.Nil(t, svc.webhookEvents(webhook_events.EventSessionCreated, "u", &webhook_events.SessionCreated{}))
+
+	events := svc.sessionRevokedEvents("u", "s", webhook_events.RevokeReasonLogout)
+	if assert.Len(t, events, 1) {
+		var envelope map[string]interface{}
+		assert.NoError(t, json.Unmarshal(events[0].Payload, &envelope))
+		assert.Equal(t, "1.0", envelope["specversion"])
+		assert.Equal(t, events[0].EventID, envelope["id"])
+		assert.Equal(t, webhook_events.EventSessionRevoked, envelope["type"])
+		assert.Equal(t, "u", envelope["subject"])
+		assert.Equal(t, webhook_events.DataVersion, envelope["dataversion"])
+		assert.Equal(t, map[string]interface{}{"user_id": "u", "session_id": "s", "reason": "logout"}, envelope["data"])
+	}
+}
+
+func TestAuthService_IntrospectToken(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	secretHash, _ := bcrypt.GenerateFromPassword([]byte("client-secret"), bcrypt.MinCost)
+	client := &clients.Clients{ClientID: "gateway", ClientSecretHash: secretHash}
+	access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+
+	t.Run("unknown client", func(t *testing.T) {
+		repo.On("GetClientByID", "unknown").Return((*clients.Clients)(nil), apperrors.ErrClientNotFound).Once()
+		_, err := svc.IntrospectToken(t.Context(), "unknown", "client-secret", access)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidClient)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("wrong client secret", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		_, err := svc.IntrospectToken(t.Context(), "gateway", "wrong", access)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidClient)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		_, err := svc.IntrospectToken(t.Context(), "", "", access)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidClient)
+	})
+
+	t.Run("revoked token is inactive", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(true, nil).Once()
+		introspection, err := svc.IntrospectToken(t.Context(), "gateway", "client-secret", access)
+		assert.NoError(t, err)
+		assert.False(t, introspection.Active)
+		assert.Empty(t, introspection.Subject)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("garbage token is inactive", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		introspection, err := svc.IntrospectToken(t.Context(), "gateway", "client-secret", "garbage")
+		assert.NoError(t, err)
+		assert.False(t, introspection.Active)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("revocation store unavailable", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, errors.New("fail")).Once()
+		_, err := svc.IntrospectToken(t.Context(), "gateway", "client-secret", access)
+		assert.ErrorIs(t, err, apperrors.ErrCantCheckRevocationToken)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("active token", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:s").Return(false, nil).Once()
+		introspection, err := svc.IntrospectToken(t.Context(), "gateway", "client-secret", access)
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, "u", introspection.Subject)
+		assert.Equal(t, "s", introspection.SessionID)
+		assert.NotEmpty(t, introspection.TokenID)
+		assert.NotZero(t, introspection.ExpiresAt)
+		assert.NotZero(t, introspection.IssuedAt)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("active client token", func(t *testing.T) {
+		clientAccess, _ := makeClientJWT("worker", "jobs:read", time.Minute, signer)
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		tokenStore.On("IsRevoked", jtiKey(clientAccess)).Return(false, nil).Once()
+		introspection, err := svc.IntrospectToken(t.Context(), "gateway", "client-secret", clientAccess)
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, "worker", introspection.Subject)
+		assert.Equal(t, "worker", introspection.ClientID)
+		assert.Equal(t, "jobs:read", introspection.Scope)
+		assert.Empty(t, introspection.SessionID)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_RevokeToken(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	secretHash, _ := bcrypt.GenerateFromPassword([]byte("client-secret"), bcrypt.MinCost)
+	client := &clients.Clients{ClientID: "gateway", ClientSecretHash: secretHash}
+	access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s", ClientID: "gateway"}, time.Minute, signer)
+	refreshHash, _ := bcrypt.GenerateFromPassword([]byte("refresh"), bcrypt.MinCost)
+	sessionID := "4f1c2b8e-6d3a-4e5f-9a7b-0c1d2e3f4a5b"
+	sess := &sessions.Sessions{SessionID: sessionID, UserID: "u", ClientID: "gateway", RefreshTokenHash: refreshHash}
+
+	t.Run("invalid client", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "wrong", access, "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidClient)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("access token", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", access, "access_token")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("access token with wrong hint", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", access, "refresh_token")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("cant revoke access token", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(errors.New("fail")).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", access, "")
+		assert.ErrorIs(t, err, apperrors.ErrCantRevokeToken)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("access token of another client", func(t *testing.T) {
+		claims := &Claims{
+			UserID:    "u",
+			SessionID: "s",
+			ClientID:  "other",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+		foreign, _ := signer.Sign(claims)
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", foreign, "access_token")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertNotCalled(t, "Revoke", jtiKey(foreign), mock.Anything)
+	})
+
+	t.Run("user access token without client", func(t *testing.T) {
+		direct, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", direct, "access_token")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertNotCalled(t, "Revoke", jtiKey(direct), mock.Anything)
+	})
+
+	t.Run("refresh token of session opened by another client", func(t *testing.T) {
+		for _, owner := range []string{"other", ""} {
+			repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+			repo.On("GetSessionByID", sessionID).Return(&sessions.Sessions{SessionID: sessionID, UserID: "u", ClientID: owner, RefreshTokenHash: refreshHash}, nil).Once()
+			err := svc.RevokeToken(t.Context(), "gateway", "client-secret", sessionID+".refresh", "refresh_token")
+			assert.NoError(t, err)
+			repo.AssertExpectations(t)
+		}
+		tokenStore.AssertNotCalled(t, "Revoke", "session:"+sessionID, mock.Anything)
+		repo.AssertNotCalled(t, "DeleteSessionByID", sessionID)
+	})
+
+	t.Run("refresh token", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		repo.On("GetSessionByID", sessionID).Return(sess, nil).Once()
+		tokenStore.On("Revoke", "session:"+sessionID, time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionByID", sessionID).Return(nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", sessionID+".refresh", "refresh_token")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("refresh token without hint", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		repo.On("GetSessionByID", sessionID).Return(sess, nil).Once()
+		tokenStore.On("Revoke", "session:"+sessionID, time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionByID", sessionID).Return(nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", sessionID+".refresh", "")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("refresh token with wrong secret", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		repo.On("GetSessionByID", sessionID).Return(sess, nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", sessionID+".wrong", "refresh_token")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("already revoked session", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		repo.On("GetSessionByID", sessionID).Return((*sessions.Sessions)(nil), apperrors.ErrSessionNotFound).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", sessionID+".refresh", "refresh_token")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant get session", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		repo.On("GetSessionByID", sessionID).Return((*sessions.Sessions)(nil), errors.New("fail")).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", sessionID+".refresh", "refresh_token")
+		assert.ErrorIs(t, err, apperrors.ErrCantGetSession)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		repo.On("GetClientByID", "gateway").Return(client, nil).Once()
+		err := svc.RevokeToken(t.Context(), "gateway", "client-secret", "garbage", "")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_CheckClientScope(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	admin, _ := makeClientJWT("ops", "jobs:read webhooks:admin", time.Minute, signer)
+	worker, _ := makeClientJWT("worker", "jobs:read", time.Minute, signer)
+	user, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s", ClientID: "spa", Scope: "webhooks:admin"}, time.Minute, signer)
+
+	t.Run("scope granted", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(admin)).Return(false, nil).Once()
+		clientID, err := svc.CheckClientScope(t.Context(), admin, "webhooks:admin")
+		assert.NoError(t, err)
+		assert.Equal(t, "ops", clientID)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("scope missing", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(worker)).Return(false, nil).Once()
+		_, err := svc.CheckClientScope(t.Context(), worker, "webhooks:admin")
+		assert.ErrorIs(t, err, apperrors.ErrInsufficientScope)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("user token", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(user)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:s").Return(false, nil).Once()
+		_, err := svc.CheckClientScope(t.Context(), user, "webhooks:admin")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		tokenStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_ClientCredentialsToken(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	secretHash, _ := bcrypt.GenerateFromPassword([]byte("client-secret"), bcrypt.MinCost)
+	client := &clients.Clients{ClientID: "worker", ClientSecretHash: secretHash, Scopes: "jobs:read jobs:write", GrantTypes: "client_credentials"}
+
+	t.Run("invalid client", func(t *testing.T) {
+		repo.On("GetClientByID", "worker").Return(client, nil).Once()
+		_, err := svc.ClientCredentialsToken(t.Context(), "worker", "wrong", "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidClient)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("grant type not allowed", func(t *testing.T) {
+		introspectOnly := &clients.Clients{ClientID: "gateway", ClientSecretHash: secretHash}
+		repo.On("GetClientByID", "gateway").Return(introspectOnly, nil).Once()
+		_, err := svc.ClientCredentialsToken(t.Context(), "gateway", "client-secret", "")
+		assert.ErrorIs(t, err, apperrors.ErrUnauthorizedClient)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("scope not allowed", func(t *testing.T) {
+		repo.On("GetClientByID", "worker").Return(client, nil).Once()
+		_, err := svc.ClientCredentialsToken(t.Context(), "worker", "client-secret", "jobs:read admin")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidScope)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("all allowed scopes by default", func(t *testing.T) {
+		repo.On("GetClientByID", "worker").Return(client, nil).Once()
+		resp, err := svc.ClientCredentialsToken(t.Context(), "worker", "client-secret", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer", resp.TokenType)
+		assert.Equal(t, int64(60), resp.ExpiresIn)
+		assert.Equal(t, "jobs:read jobs:write", resp.Scope)
+		assert.Empty(t, resp.RefreshToken)
+
+		claims, err := claimsFromAccessToken(resp.AccessToken, signer)
+		assert.NoError(t, err)
+		assert.Equal(t, "worker", claims.ClientID)
+		assert.Equal(t, "worker", claims.Subject)
+		assert.Equal(t, "jobs:read jobs:write", claims.Scope)
+		assert.Empty(t, claims.UserID)
+		assert.Empty(t, claims.SessionID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("requested scope", func(t *testing.T) {
+		repo.On("GetClientByID", "worker").Return(client, nil).Once()
+		resp, err := svc.ClientCredentialsToken(t.Context(), "worker", "client-secret", "jobs:read")
+		assert.NoError(t, err)
+		assert.Equal(t, "jobs:read", resp.Scope)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_Authorize(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	codeStore := new(mockAuthorizationCodeStore)
+	svc := NewAuthService(repo, tokenStore, codeStore, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	client := &clients.Clients{
+		ClientID:     "spa",
+		Scopes:       "profile email",
+		GrantTypes:   "authorization_code",
+		RedirectURIs: "https://app.example.com/callback https://app.example.com/silent",
+	}
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	request := func() *authorization_codes.AuthorizationRequest {
+		return &authorization_codes.AuthorizationRequest{
+			ResponseType:        "code",
+			ClientID:            "spa",
+			RedirectURI:         "https://app.example.com/callback",
+			CodeChallenge:       challenge,
+			CodeChallengeMethod: "S256",
+		}
+	}
+	// код выдаётся по access токену сессии s, в которую вошли паролем и TOTP
+	repo.On("GetSessionByID", "s").Return(&sessions.Sessions{SessionID: "s", UserID: "u", AMR: "pwd otp"}, nil)
+
+	t.Run("session not found", func(t *testing.T) {
+		repo.On("GetSessionByID", "gone").Return((*sessions.Sessions)(nil), apperrors.ErrSessionNotFound).Once()
+		redirectURI, _, err := svc.Authorize(t.Context(), "u", "gone", request())
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		assert.Empty(t, redirectURI)
+		codeStore.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("session of another user", func(t *testing.T) {
+		redirectURI, _, err := svc.Authorize(t.Context(), "other", "s", request())
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		assert.Empty(t, redirectURI)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return((*clients.Clients)(nil), apperrors.ErrClientNotFound).Once()
+		redirectURI, _, err := svc.Authorize(t.Context(), "u", "s", request())
+		assert.ErrorIs(t, err, apperrors.ErrInvalidClient)
+		assert.Empty(t, redirectURI)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("unregistered redirect uri", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		req := request()
+		req.RedirectURI = "https://evil.example.com/callback"
+		redirectURI, _, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRedirectURI)
+		assert.Empty(t, redirectURI)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("redirect uri required when several are registered", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		req := request()
+		req.RedirectURI = ""
+		_, _, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRedirectURI)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("unsupported response type", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		req := request()
+		req.ResponseType = "token"
+		redirectURI, _, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.ErrorIs(t, err, apperrors.ErrUnsupportedResponseType)
+		assert.Equal(t, "https://app.example.com/callback", redirectURI)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("grant type not allowed", func(t *testing.T) {
+		worker := &clients.Clients{ClientID: "worker", GrantTypes: "client_credentials", RedirectURIs: "https://worker.example.com"}
+		repo.On("GetClientByID", "worker").Return(worker, nil).Once()
+		req := request()
+		req.ClientID = "worker"
+		req.RedirectURI = ""
+		redirectURI, _, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.ErrorIs(t, err, apperrors.ErrUnauthorizedClient)
+		assert.Equal(t, "https://worker.example.com", redirectURI)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("pkce required", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		req := request()
+		req.CodeChallenge = ""
+		req.CodeChallengeMethod = ""
+		_, _, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("plain pkce rejected", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		req := request()
+		req.CodeChallengeMethod = "plain"
+		_, _, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("scope not allowed", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		req := request()
+		req.Scope = "admin"
+		_, _, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidScope)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant save code", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		codeStore.On("Save", mock.Anything, mock.Anything, ttlAuthorizationCode).Return(errors.New("fail")).Once()
+		_, _, err := svc.Authorize(t.Context(), "u", "s", request())
+		assert.ErrorIs(t, err, apperrors.ErrCantSaveAuthorizationCode)
+		repo.AssertExpectations(t)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		expectCode := &authorization_codes.AuthorizationCodes{
+			ClientID:      "spa",
+			UserID:        "u",
+			RedirectURI:   "https://app.example.com/callback",
+			Scope:         "profile",
+			CodeChallenge: challenge,
+			AMR:           "pwd otp",
+		}
+		codeStore.On("Save", mock.Anything, expectCode, ttlAuthorizationCode).Return(nil).Once()
+		req := request()
+		req.Scope = "profile"
+		redirectURI, code, err := svc.Authorize(t.Context(), "u", "s", req)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, code)
+		assert.Equal(t, "https://app.example.com/callback", redirectURI)
+		repo.AssertExpectations(t)
+		codeStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_AuthorizeWithPassword(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	codeStore := new(mockAuthorizationCodeStore)
+	box := newTestSecretBox(t)
+	svc := NewAuthService(repo, tokenStore, codeStore, signer, box, nil, nil, nil, time.Minute, nil, false, time.Time{})
+	passwordHash, _ := password_hasher.GenerateFromPassword([]byte("long enough password"))
+	user := &users.Users{UserID: "u", Email: "alice@example.com", PasswordHash: passwordHash}
+	client := &clients.Clients{ClientID: "spa", GrantTypes: "authorization_code", RedirectURIs: "https://app.example.com/callback"}
+	request := &authorization_codes.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "spa",
+		CodeChallenge:       "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		CodeChallengeMethod: "S256",
+	}
+
+	secret, _ := totp.GenerateSecret()
+	ciphertext, _ := box.Seal(secret, []byte("u"))
+	confirmedAt := time.Now()
+	enrolled := &user_totp.UserTOTP{UserID: "u", SecretCiphertext: ciphertext, ConfirmedAt: &confirmedAt}
+	failuresKey := totpFailuresKey("u")
+
+	t.Run("wrong password", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		_, _, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "wrong password", "", request, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidCredentials)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success without totp", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return((*user_totp.UserTOTP)(nil), apperrors.ErrTOTPNotFound).Once()
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		codeStore.On("Save", mock.Anything, mock.MatchedBy(func(code *authorization_codes.AuthorizationCodes) bool {
+			return code.UserID == "u" && code.ClientID == "spa" && code.AMR == amrPassword
+		}), ttlAuthorizationCode).Return(nil).Once()
+
+		redirectURI, code, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "long enough password", "", request, "ua", "ip")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, code)
+		assert.Equal(t, "https://app.example.com/callback", redirectURI)
+		repo.AssertExpectations(t)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("totp code required", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		_, code, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "long enough password", "", request, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrMFARequired)
+		assert.Empty(t, code)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("wrong totp code", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		tokenStore.On("Increment", failuresKey, totpLockoutPeriod).Return(int64(1), nil).Once()
+		_, _, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "long enough password", "abcdef", request, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTOTPCode)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("sixth totp attempt is locked out", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		tokenStore.On("Increment", failuresKey, totpLockoutPeriod).Return(int64(maxMFAAttempts+1), nil).Once()
+
+		// даже верный код не принимается, пока действует блокировка
+		_, code, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "long enough password", totp.Code(secret, totp.Step(time.Now())), request, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTOTPCode)
+		assert.Empty(t, code)
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "UseTOTPStep", "u", mock.Anything)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("can't count totp attempt", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		tokenStore.On("Increment", failuresKey, totpLockoutPeriod).Return(int64(0), errors.New("redis down")).Once()
+		_, _, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "long enough password", "123456", request, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrCantCheckRevocationToken)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("totp code replayed", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		repo.On("UseTOTPStep", "u", mock.Anything).Return(apperrors.ErrInvalidTOTPCode).Once()
+		tokenStore.On("Increment", failuresKey, totpLockoutPeriod).Return(int64(2), nil).Once()
+		_, _, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "long enough password", totp.Code(secret, totp.Step(time.Now())), request, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTOTPCode)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success with totp resets failures counter", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		tokenStore.On("Increment", failuresKey, totpLockoutPeriod).Return(int64(maxMFAAttempts), nil).Once()
+		repo.On("UseTOTPStep", "u", mock.Anything).Return(nil).Once()
+		tokenStore.On("Delete", failuresKey).Return(nil).Once()
+		repo.On("GetClientByID", "spa").Return(client, nil).Once()
+		codeStore.On("Save", mock.Anything, mock.MatchedBy(func(code *authorization_codes.AuthorizationCodes) bool {
+			return code.AMR == "pwd otp"
+		}), ttlAuthorizationCode).Return(nil).Once()
+
+		_, code, err := svc.AuthorizeWithPassword(t.Context(), "alice@example.com", "long enough password", totp.Code(secret, totp.Step(time.Now())), request, "ua", "ip")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, code)
+		repo.AssertExpectations(t)
+		codeStore.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_ExchangeAuthorizationCode(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	codeStore := new(mockAuthorizationCodeStore)
+	svc := NewAuthService(repo, tokenStore, codeStore, signer, nil, nil, nil, nil, time.Minute, nil, true, time.Time{})
+	publicClient := &clients.Clients{ClientID: "spa", GrantTypes: "authorization_code"}
+	secretHash, _ := bcrypt.GenerateFromPassword([]byte("client-secret"), bcrypt.MinCost)
+	confidentialClient := &clients.Clients{ClientID: "web", ClientSecretHash: secretHash, GrantTypes: "authorization_code"}
+
+	// пример из RFC 7636, приложение B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	authorizationCode := func(clientID string) *authorization_codes.AuthorizationCodes {
+		return &authorization_codes.AuthorizationCodes{
+			ClientID:      clientID,
+			UserID:        "u",
+			RedirectURI:   "https://app.example.com/callback",
+			Scope:         "profile",
+			CodeChallenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+			AMR:           "pwd otp",
+		}
+	}
+
+	t.Run("missing code verifier", func(t *testing.T) {
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "spa", "", "code", "https://app.example.com/callback", "", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidRequest)
+	})
+
+	t.Run("confidential client without secret", func(t *testing.T) {
+		repo.On("GetClientByID", "web").Return(confidentialClient, nil).Once()
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "web", "", "code", "https://app.example.com/callback", verifier, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidClient)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("unknown code", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(publicClient, nil).Once()
+		codeStore.On("Consume", "code").Return((*authorization_codes.AuthorizationCodes)(nil), apperrors.ErrAuthorizationCodeNotFound).Once()
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "spa", "", "code", "https://app.example.com/callback", verifier, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidGrant)
+		repo.AssertExpectations(t)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("code store unavailable", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(publicClient, nil).Once()
+		codeStore.On("Consume", "code").Return((*authorization_codes.AuthorizationCodes)(nil), errors.New("fail")).Once()
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "spa", "", "code", "https://app.example.com/callback", verifier, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrCantConsumeAuthorizationCode)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("code issued to another client", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(publicClient, nil).Once()
+		codeStore.On("Consume", "code").Return(authorizationCode("other"), nil).Once()
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "spa", "", "code", "https://app.example.com/callback", verifier, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidGrant)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("redirect uri mismatch", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(publicClient, nil).Once()
+		codeStore.On("Consume", "code").Return(authorizationCode("spa"), nil).Once()
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "spa", "", "code", "https://app.example.com/other", verifier, "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidGrant)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(publicClient, nil).Once()
+		codeStore.On("Consume", "code").Return(authorizationCode("spa"), nil).Once()
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "spa", "", "code", "https://app.example.com/callback", "wrong-verifier", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidGrant)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("public client", func(t *testing.T) {
+		repo.On("GetClientByID", "spa").Return(publicClient, nil).Once()
+		codeStore.On("Consume", "code").Return(authorizationCode("spa"), nil).Once()
+		repo.On("CreateSession", mock.MatchedBy(func(session *sessions.Sessions) bool {
+			return session.UserID == "u" && session.ClientID == "spa" && session.Scope == "profile" && session.UserAgent == "ua" && session.AMR == "pwd otp"
+		})).Return(nil).Once()
+		resp, err := svc.ExchangeAuthorizationCode(t.Context(), "spa", "", "code", "https://app.example.com/callback", verifier, "ua", "ip")
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer", resp.TokenType)
+		assert.Equal(t, "profile", resp.Scope)
+		assert.NotEmpty(t, resp.RefreshToken)
+
+		claims, err := claimsFromAccessToken(resp.AccessToken, signer)
+		assert.NoError(t, err)
+		assert.Equal(t, "u", claims.UserID)
+		assert.Equal(t, "spa", claims.ClientID)
+		assert.Equal(t, "profile", claims.Scope)
+		assert.Equal(t, []string{"pwd", "otp"}, claims.AMR)
+		assert.NotEmpty(t, claims.SessionID)
+		repo.AssertExpectations(t)
+		codeStore.AssertExpectations(t)
+	})
+
+	t.Run("confidential client", func(t *testing.T) {
+		repo.On("GetClientByID", "web").Return(confidentialClient, nil).Once()
+		codeStore.On("Consume", "code").Return(authorizationCode("web"), nil).Once()
+		repo.On("CreateSession", mock.AnythingOfType("*sessions.Sessions")).Return(nil).Once()
+		_, err := svc.ExchangeAuthorizationCode(t.Context(), "web", "client-secret", "code", "https://app.example.com/callback", verifier, "ua", "ip")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+		codeStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_Register(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, false, time.Time{})
+
+	t.Run("invalid email", func(t *testing.T) {
+		for _, email := range []string{"", "not-an-email", "Alice <alice@example.com>"} {
+			_, err := svc.Register(t.Context(), email, "long enough password")
+			assert.ErrorIs(t, err, apperrors.ErrInvalidEmail, email)
+		}
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		_, err := svc.Register(t.Context(), "alice@example.com", "short")
+		assert.ErrorIs(t, err, apperrors.ErrWeakPassword)
+	})
+
+	t.Run("email already taken", func(t *testing.T) {
+		repo.On("CreateUser", mock.AnythingOfType("*users.Users")).Return(apperrors.ErrUserAlreadyExists).Once()
+		_, err := svc.Register(t.Context(), "alice@example.com", "long enough password")
+		assert.ErrorIs(t, err, apperrors.ErrUserAlreadyExists)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant create user", func(t *testing.T) {
+		repo.On("CreateUser", mock.AnythingOfType("*users.Users")).Return(errors.New("fail")).Once()
+		_, err := svc.Register(t.Context(), "alice@example.com", "long enough password")
+		assert.ErrorIs(t, err, apperrors.ErrCantCreateUser)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		var created *users.Users
+		repo.On("CreateUser", mock.AnythingOfType("*users.Users")).Run(func(args mock.Arguments) {
+			created = args.Get(0).(*users.Users)
+		}).Return(nil).Once()
+		userID, err := svc.Register(t.Context(), "  Alice@Example.com ", "long enough password")
+		assert.NoError(t, err)
+		assert.Equal(t, created.UserID, userID)
+		assert.Equal(t, "alice@example.com", created.Email)
+		assert.NoError(t, password_hasher.CompareHashAndPassword(created.PasswordHash, []byte("long enough password")))
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_Login(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, false, time.Time{})
+	passwordHash, _ := password_hasher.GenerateFromPassword([]byte("long enough password"))
+	user := &users.Users{UserID: "u", Email: "alice@example.com", PasswordHash: passwordHash}
+
+	t.Run("unknown email", func(t *testing.T) {
+		repo.On("GetUserByEmail", "bob@example.com").Return((*users.Users)(nil), apperrors.ErrUserNotFound).Once()
+		_, _, err := svc.Login(t.Context(), "bob@example.com", "long enough password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidCredentials)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		_, _, err := svc.Login(t.Context(), "alice@example.com", "wrong password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidCredentials)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("malformed email", func(t *testing.T) {
+		_, _, err := svc.Login(t.Context(), "alice", "long enough password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidCredentials)
+	})
+
+	t.Run("cant get user", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return((*users.Users)(nil), errors.New("fail")).Once()
+		_, _, err := svc.Login(t.Context(), "alice@example.com", "long enough password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrCantGetUser)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return((*user_totp.UserTOTP)(nil), apperrors.ErrTOTPNotFound).Once()
+		repo.On("CreateSession", mock.MatchedBy(func(session *sessions.Sessions) bool {
+			return session.UserID == "u" && session.UserAgent == "ua" && session.IPAddr == "ip" && session.AMR == "pwd"
+		})).Return(nil).Once()
+		access, refresh, err := svc.Login(t.Context(), "Alice@example.com", "long enough password", "ua", "ip")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, refresh)
+
+		claims, err := claimsFromAccessToken(access, signer)
+		assert.NoError(t, err)
+		assert.Equal(t, "u", claims.UserID)
+		assert.Equal(t, []string{"pwd"}, claims.AMR)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("totp enrolled", func(t *testing.T) {
+		confirmedAt := time.Now()
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(&user_totp.UserTOTP{UserID: "u", ConfirmedAt: &confirmedAt}, nil).Once()
+		access, refresh, err := svc.Login(t.Context(), "alice@example.com", "long enough password", "ua", "ip")
+		assert.Empty(t, access)
+		assert.Empty(t, refresh)
+
+		var mfaErr *apperrors.MFARequiredError
+		assert.ErrorAs(t, err, &mfaErr)
+		assert.ErrorIs(t, err, apperrors.ErrMFARequired)
+		assert.Equal(t, ttlMFAToken, mfaErr.ExpiresIn)
+
+		claims, err := claimsFromAccessToken(mfaErr.MFAToken, signer)
+		assert.NoError(t, err)
+		assert.Equal(t, "u", claims.UserID)
+		assert.Equal(t, tokenUseMFA, claims.TokenUse)
+		assert.Equal(t, []string{"pwd"}, claims.AMR)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant get totp", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return((*user_totp.UserTOTP)(nil), errors.New("fail")).Once()
+		_, _, err := svc.Login(t.Context(), "alice@example.com", "long enough password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrCantGetTOTP)
+		repo.AssertExpectations(t)
+	})
+}
+
+func newTestSecretBox(t *testing.T) *secret_box.SecretBox {
+	box, err := secret_box.NewSecretBox([]byte(strings.Repeat("k", secret_box.KeySize)))
+	assert.NoError(t, err)
+	return box
+}
+
+func TestAuthService_EnrollTOTP(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	box := newTestSecretBox(t)
+	svc := NewAuthService(repo, tokenStore, nil, signer, box, nil, nil, nil, time.Minute, nil, false, time.Time{})
+
+	t.Run("not configured", func(t *testing.T) {
+		noBoxSvc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, false, time.Time{})
+		_, _, err := noBoxSvc.EnrollTOTP(t.Context(), "u")
+		assert.ErrorIs(t, err, apperrors.ErrTOTPUnavailable)
+	})
+
+	t.Run("already enrolled", func(t *testing.T) {
+		repo.On("GetUserByID", "u").Return((*users.Users)(nil), apperrors.ErrUserNotFound).Once()
+		repo.On("SaveUserTOTP", mock.Anything).Return(apperrors.ErrTOTPAlreadyEnrolled).Once()
+		_, _, err := svc.EnrollTOTP(t.Context(), "u")
+		assert.ErrorIs(t, err, apperrors.ErrTOTPAlreadyEnrolled)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		var saved *user_totp.UserTOTP
+		repo.On("GetUserByID", "u").Return(&users.Users{UserID: "u", Email: "alice@example.com"}, nil).Once()
+		repo.On("SaveUserTOTP", mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(*user_totp.UserTOTP)
+		}).Return(nil).Once()
+
+		uri, secret, err := svc.EnrollTOTP(t.Context(), "u")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(uri, "otpauth://totp/authentication-service:alice@example.com?"))
+		assert.Contains(t, uri, "secret="+secret)
+
+		// в базу попадает только шифротекст, привязанный к user_id
+		assert.Equal(t, "u", saved.UserID)
+		assert.NotContains(t, string(saved.SecretCiphertext), secret)
+		plaintext, err := box.Open(saved.SecretCiphertext, []byte("u"))
+		assert.NoError(t, err)
+		assert.Equal(t, secret, totp.EncodeSecret(plaintext))
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_ConfirmTOTP(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	box := newTestSecretBox(t)
+	svc := NewAuthService(repo, tokenStore, nil, signer, box, nil, nil, nil, time.Minute, nil, false, time.Time{})
+
+	secret, _ := totp.GenerateSecret()
+	ciphertext, _ := box.Seal(secret, []byte("u"))
+	pending := &user_totp.UserTOTP{UserID: "u", SecretCiphertext: ciphertext}
+
+	t.Run("not enrolled", func(t *testing.T) {
+		repo.On("GetUserTOTP", "u").Return((*user_totp.UserTOTP)(nil), apperrors.ErrTOTPNotFound).Once()
+		err := svc.ConfirmTOTP(t.Context(), "u", "123456")
+		assert.ErrorIs(t, err, apperrors.ErrTOTPNotFound)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("already confirmed", func(t *testing.T) {
+		confirmedAt := time.Now()
+		repo.On("GetUserTOTP", "u").Return(&user_totp.UserTOTP{UserID: "u", ConfirmedAt: &confirmedAt}, nil).Once()
+		err := svc.ConfirmTOTP(t.Context(), "u", "123456")
+		assert.ErrorIs(t, err, apperrors.ErrTOTPAlreadyEnrolled)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		repo.On("GetUserTOTP", "u").Return(pending, nil).Once()
+		err := svc.ConfirmTOTP(t.Context(), "u", "abcdef")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTOTPCode)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		step := totp.Step(time.Now())
+		repo.On("GetUserTOTP", "u").Return(pending, nil).Once()
+		repo.On("ConfirmUserTOTP", "u", mock.MatchedBy(func(s int64) bool { return s >= step-1 && s <= step+1 })).Return(nil).Once()
+		err := svc.ConfirmTOTP(t.Context(), "u", totp.Code(secret, step))
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_VerifyMFA(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	box := newTestSecretBox(t)
+	svc := NewAuthService(repo, tokenStore, nil, signer, box, nil, nil, nil, time.Minute, nil, false, time.Time{})
+
+	secret, _ := totp.GenerateSecret()
+	ciphertext, _ := box.Seal(secret, []byte("u"))
+	confirmedAt := time.Now()
+	enrolled := &user_totp.UserTOTP{UserID: "u", SecretCiphertext: ciphertext, ConfirmedAt: &confirmedAt}
+	mfaToken, _ := makeMFAToken("u", []string{"pwd"}, time.Minute, signer)
+	mfaClaims, _ := claimsFromAccessToken(mfaToken, signer)
+	attemptsKey := mfaAttemptsKey(mfaClaims.ID)
+
+	t.Run("access token instead of mfa token", func(t *testing.T) {
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		_, _, err := svc.VerifyMFA(t.Context(), access, "123456", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+	})
+
+	t.Run("mfa token already used", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(mfaToken)).Return(true, nil).Once()
+		_, _, err := svc.VerifyMFA(t.Context(), mfaToken, "123456", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(mfaToken)).Return(false, nil).Once()
+		tokenStore.On("Increment", attemptsKey, remainingTTL(time.Minute)).Return(int64(1), nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		_, _, err := svc.VerifyMFA(t.Context(), mfaToken, "abcdef", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTOTPCode)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("code replayed", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(mfaToken)).Return(false, nil).Once()
+		tokenStore.On("Increment", attemptsKey, remainingTTL(time.Minute)).Return(int64(2), nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		repo.On("UseTOTPStep", "u", mock.Anything).Return(apperrors.ErrInvalidTOTPCode).Once()
+		_, _, err := svc.VerifyMFA(t.Context(), mfaToken, totp.Code(secret, totp.Step(time.Now())), "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidTOTPCode)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("too many attempts revoke mfa token", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(mfaToken)).Return(false, nil).Once()
+		tokenStore.On("Increment", attemptsKey, remainingTTL(time.Minute)).Return(int64(maxMFAAttempts+1), nil).Once()
+		tokenStore.On("Revoke", jtiKey(mfaToken), remainingTTL(time.Minute)).Return(nil).Once()
+		// правильный код уже не принимается: до проверки кода дело не доходит
+		_, _, err := svc.VerifyMFA(t.Context(), mfaToken, totp.Code(secret, totp.Step(time.Now())), "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		tokenStore.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant count attempt", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(mfaToken)).Return(false, nil).Once()
+		tokenStore.On("Increment", attemptsKey, mock.Anything).Return(int64(0), errors.New("fail")).Once()
+		_, _, err := svc.VerifyMFA(t.Context(), mfaToken, "123456", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrCantCheckRevocationToken)
+		tokenStore.AssertExpectations(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		tokenStore.On("IsRevoked", jtiKey(mfaToken)).Return(false, nil).Once()
+		tokenStore.On("Increment", attemptsKey, remainingTTL(time.Minute)).Return(int64(maxMFAAttempts), nil).Once()
+		tokenStore.On("Revoke", jtiKey(mfaToken), remainingTTL(ttlMFAToken)).Return(nil).Once()
+		repo.On("GetUserTOTP", "u").Return(enrolled, nil).Once()
+		repo.On("UseTOTPStep", "u", mock.Anything).Return(nil).Once()
+		repo.On("CreateSession", mock.MatchedBy(func(session *sessions.Sessions) bool {
+			return session.UserID == "u" && session.AMR == "pwd otp"
+		})).Return(nil).Once()
+
+		access, refresh, err := svc.VerifyMFA(t.Context(), mfaToken, totp.Code(secret, totp.Step(time.Now())), "ua", "ip")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, refresh)
+
+		claims, err := claimsFromAccessToken(access, signer)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"pwd", "otp"}, claims.AMR)
+		assert.Empty(t, claims.TokenUse)
+		repo.AssertExpectations(t)
+		tokenStore.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_CheckAccessTokenValidity_RejectsMFAToken(t *testing.T) {
+	tokenStore := new(mockTokenRevocationStore)
+	svc := NewAuthService(new(mockRepo), tokenStore, nil, signer, nil, nil, nil, nil, time.Minute, nil, false, time.Time{})
+
+	mfaToken, _ := makeMFAToken("u", []string{"pwd"}, time.Minute, signer)
+	tokenStore.On("IsRevoked", jtiKey(mfaToken)).Return(false, nil).Once()
+
+	_, _, err := svc.CheckAccessTokenValidity(t.Context(), mfaToken)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+}
+
+// hasEventTypes проверяет типы событий, переданных в репозиторий, с учётом порядка
+func hasEventTypes(events []*webhook_events.WebhookEvents, eventTypes ...string) bool {
+	if len(events) != len(eventTypes) {
+		return false
+	}
+	for i, event := range events {
+		if event.EventType != eventTypes[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAuthService_AuditLog(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	auditLog := new(mockAuditLog)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, auditLog, nil, nil, time.Minute, nil, true, time.Time{})
+	passwordHash, _ := password_hasher.GenerateFromPassword([]byte("long enough password"))
+	user := &users.Users{UserID: "u", Email: "alice@example.com", PasswordHash: passwordHash}
+
+	// lastEvent возвращает событие, переданное в журнал последним
+	var lastEvent *audit_events.AuditEvents
+	recordAudit := func(args mock.Arguments) {
+		lastEvent = args.Get(0).(*audit_events.AuditEvents)
+	}
+
+	t.Run("tokens issued", func(t *testing.T) {
+		repo.On("GetUserTOTP", "u").Return((*user_totp.UserTOTP)(nil), apperrors.ErrTOTPNotFound).Once()
+		repo.On("CreateSession", mock.AnythingOfType("*sessions.Sessions")).Return(nil).Once()
+		auditLog.On("InsertAuditEvent", mock.Anything).Run(recordAudit).Return(nil).Once()
+
+		access, _, err := svc.CreateTokens(t.Context(), "u", "ua", "ip")
+		assert.NoError(t, err)
+		claims, _ := claimsFromAccessToken(access, signer)
+		assert.Equal(t, &audit_events.AuditEvents{
+			EventType: audit_events.EventTokensIssued,
+			Actor:     audit_events.ActorTrustedCaller,
+			UserID:    "u",
+			SessionID: claims.SessionID,
+			IPAddr:    "ip",
+			UserAgent: "ua",
+			Outcome:   audit_events.OutcomeSuccess,
+		}, lastEvent)
+		auditLog.AssertExpectations(t)
+	})
+
+	t.Run("failed login", func(t *testing.T) {
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		auditLog.On("InsertAuditEvent", mock.Anything).Run(recordAudit).Return(nil).Once()
+
+		_, _, err := svc.Login(t.Context(), "alice@example.com", "wrong password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidCredentials)
+		assert.Equal(t, audit_events.EventLogin, lastEvent.EventType)
+		assert.Equal(t, audit_events.OutcomeFailure, lastEvent.Outcome)
+		assert.Equal(t, "invalid_credentials", lastEvent.ErrorCode)
+		assert.Empty(t, lastEvent.UserID)
+		assert.Equal(t, "ip", lastEvent.IPAddr)
+		auditLog.AssertExpectations(t)
+	})
+
+	t.Run("login requires mfa", func(t *testing.T) {
+		confirmedAt := time.Now()
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		repo.On("GetUserTOTP", "u").Return(&user_totp.UserTOTP{UserID: "u", ConfirmedAt: &confirmedAt}, nil).Once()
+		auditLog.On("InsertAuditEvent", mock.Anything).Run(recordAudit).Return(nil).Once()
+
+		_, _, err := svc.Login(t.Context(), "alice@example.com", "long enough password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrMFARequired)
+		assert.Equal(t, "u", lastEvent.UserID)
+		assert.Equal(t, audit_events.OutcomeMFARequired, lastEvent.Outcome)
+		assert.Empty(t, lastEvent.ErrorCode)
+		assert.Empty(t, lastEvent.SessionID)
+		auditLog.AssertExpectations(t)
+	})
+
+	t.Run("refresh with foreign token", func(t *testing.T) {
+		sid := "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a"
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: sid}, time.Minute, signer)
+		hash, _ := bcrypt.GenerateFromPassword([]byte("refresh"), bcrypt.DefaultCost)
+		tokenStore.On("IsRevoked", jtiKey(access)).Return(false, nil).Once()
+		tokenStore.On("IsRevoked", "session:"+sid).Return(false, nil).Once()
+		repo.On("GetSessionByID", sid).Return(&sessions.Sessions{SessionID: sid, UserID: "u", RefreshTokenHash: hash}, nil).Once()
+		repo.On("IsRefreshTokenRotated", sid, refreshTokenDigest(sid+".other")).Return(false, nil).Once()
+		auditLog.On("InsertAuditEvent", mock.Anything).Run(recordAudit).Return(nil).Once()
+
+		_, _, err := svc.RefreshTokens(t.Context(), access, sid+".other", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrTokensDontMatch)
+		assert.Equal(t, audit_events.EventTokensRefreshed, lastEvent.EventType)
+		assert.Equal(t, "u", lastEvent.UserID)
+		assert.Equal(t, sid, lastEvent.SessionID)
+		assert.Equal(t, "tokens_dont_match", lastEvent.ErrorCode)
+		auditLog.AssertExpectations(t)
+	})
+
+	t.Run("audit failure does not fail logout", func(t *testing.T) {
+		access, _ := makeJWT(&sessions.Sessions{UserID: "u", SessionID: "s"}, time.Minute, signer)
+		tokenStore.On("Revoke", jtiKey(access), remainingTTL(time.Minute)).Return(nil).Once()
+		repo.On("DeleteSessionByID", "s").Return(nil).Once()
+		auditLog.On("InsertAuditEvent", mock.Anything).Run(recordAudit).Return(errors.New("fail")).Once()
+
+		assert.NoError(t, svc.Logout(t.Context(), access, "s", "ua", "ip"))
+		assert.Equal(t, audit_events.EventLogout, lastEvent.EventType)
+		assert.Equal(t, "u", lastEvent.UserID)
+		assert.Equal(t, audit_events.OutcomeSuccess, lastEvent.Outcome)
+		auditLog.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_Metrics(t *testing.T) {
+	repo := new(mockRepo)
+	tokenStore := new(mockTokenRevocationStore)
+	metrics := new(mockMetrics)
+	svc := NewAuthService(repo, tokenStore, nil, signer, nil, nil, nil, metrics, time.Minute, nil, true, time.Time{})
+
+	t.Run("tokens issued", func(t *testing.T) {
+		repo.On("GetUserTOTP", "u").Return((*user_totp.UserTOTP)(nil), apperrors.ErrTOTPNotFound).Once()
+		repo.On("CreateSession", mock.AnythingOfType("*sessions.Sessions")).Return(nil).Once()
+		metrics.On("ObserveHash", hashAlgorithmBcrypt, hashOperationHash, mock.AnythingOfType("time.Duration")).Once()
+		metrics.On("ObserveTokens", tokenOperationIssued, audit_events.OutcomeSuccess, "").Once()
+
+		_, _, err := svc.CreateTokens(t.Context(), "u", "ua", "ip")
+		assert.NoError(t, err)
+		metrics.AssertExpectations(t)
+	})
+
+	t.Run("refresh with foreign token", func(t *testing.T) {
+		metrics.On("ObserveTokens", tokenOperationRefreshed, audit_events.OutcomeFailure, "invalid_token").Once()
+
+		_, _, err := svc.RefreshTokens(t.Context(), "not a jwt", "refresh", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidToken)
+		metrics.AssertExpectations(t)
+	})
+
+	t.Run("session revoked", func(t *testing.T) {
+		repo.On("GetSessionByID", "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a").Return(&sessions.Sessions{SessionID: "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a", UserID: "u"}, nil).Once()
+		tokenStore.On("Revoke", "session:8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a", time.Minute).Return(nil).Once()
+		repo.On("DeleteSessionByID", "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a").Return(nil).Once()
+		metrics.On("ObserveTokens", tokenOperationRevoked, audit_events.OutcomeSuccess, "").Once()
+
+		assert.NoError(t, svc.RevokeSession(t.Context(), "u", "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a"))
+		metrics.AssertExpectations(t)
+	})
+
+	t.Run("infrastructure failure", func(t *testing.T) {
+		repo.On("GetSessionByID", "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a").Return((*sessions.Sessions)(nil), errors.New("connection refused")).Once()
+		metrics.On("ObserveTokens", tokenOperationRevoked, audit_events.OutcomeFailure, "internal_error").Once()
+
+		assert.ErrorIs(t, svc.RevokeSession(t.Context(), "u", "8f14e45f-ceea-467f-a0e6-8f2b1c5d6e7a"), apperrors.ErrCantGetSession)
+		metrics.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_Cancellation(t *testing.T) {
+	repo := new(mockRepo)
+	auditLog := new(mockAuditLog)
+	metrics := new(mockMetrics)
+	svc := NewAuthService(repo, nil, nil, signer, nil, auditLog, nil, metrics, time.Minute, nil, false, time.Time{})
+	passwordHash, _ := password_hasher.GenerateFromPassword([]byte("long enough password"))
+	user := &users.Users{UserID: "u", Email: "alice@example.com", PasswordHash: passwordHash}
+
+	var lastEvent *audit_events.AuditEvents
+	recordAudit := func(args mock.Arguments) {
+		lastEvent = args.Get(0).(*audit_events.AuditEvents)
+	}
+
+	// ObserveHash не ожидается: на хеширование ушедшего запроса CPU не тратится
+	t.Run("client went away", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		repo.On("GetUserByEmail", "alice@example.com").Return(user, nil).Once()
+		auditLog.On("InsertAuditEvent", mock.Anything).Run(recordAudit).Return(nil).Once()
+		metrics.On("ObserveTokens", tokenOperationIssued, audit_events.OutcomeFailure, "request_canceled").Once()
+
+		_, _, err := svc.Login(ctx, "alice@example.com", "long enough password", "ua", "ip")
+		assert.ErrorIs(t, err, apperrors.ErrRequestCanceled)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, "request_canceled", lastEvent.ErrorCode)
+		repo.AssertExpectations(t)
+		auditLog.AssertExpectations(t)
+		metrics.AssertExpectations(t)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(t.Context(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := svc.Register(ctx, "bob@example.com", "long enough password")
+		assert.ErrorIs(t, err, apperrors.ErrRequestCanceled)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		metrics.AssertExpectations(t)
+	})
 }
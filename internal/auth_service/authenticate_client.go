@@ -0,0 +1,64 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+)
+
+// authenticateClient проверяет client_id и client_secret зарегистрированного OAuth клиента
+func (authService *AuthService) authenticateClient(ctx context.Context, clientID string, clientSecret string) (*clients.Clients, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, apperrors.ErrInvalidClient
+	}
+
+	client, err := authService.repo.GetClientByID(ctx, clientID)
+	if err != nil {
+		// не раскрываем, существует ли клиент
+		if errors.Is(err, apperrors.ErrClientNotFound) {
+			return nil, apperrors.ErrInvalidClient
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantGetClient, err)
+	}
+
+	if err = checkCanceled(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	err = bcrypt.CompareHashAndPassword(client.ClientSecretHash, []byte(clientSecret))
+	authService.observeHash(hashAlgorithmBcrypt, hashOperationCompare, start)
+	if err != nil {
+		return nil, apperrors.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// authenticateTokenClient — как authenticateClient, но пускает и публичного клиента без секрета:
+// такой клиент не может хранить секрет, и его защищает PKCE
+func (authService *AuthService) authenticateTokenClient(ctx context.Context, clientID string, clientSecret string) (*clients.Clients, error) {
+	if clientSecret != "" {
+		return authService.authenticateClient(ctx, clientID, clientSecret)
+	}
+	if clientID == "" {
+		return nil, apperrors.ErrInvalidClient
+	}
+
+	client, err := authService.repo.GetClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrClientNotFound) {
+			return nil, apperrors.ErrInvalidClient
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantGetClient, err)
+	}
+
+	// конфиденциальный клиент обязан предъявить секрет
+	if len(client.ClientSecretHash) != 0 {
+		return nil, apperrors.ErrInvalidClient
+	}
+
+	return client, nil
+}
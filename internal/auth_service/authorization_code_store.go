@@ -0,0 +1,12 @@
+package auth_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"time"
+)
+
+type AuthorizationCodeStore interface {
+	Save(ctx context.Context, code string, authorizationCode *authorization_codes.AuthorizationCodes, ttl time.Duration) error
+	Consume(ctx context.Context, code string) (*authorization_codes.AuthorizationCodes, error)
+}
@@ -0,0 +1,91 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"time"
+)
+
+const (
+	grantTypeAuthorizationCode = "authorization_code"
+
+	// RFC 6749, раздел 4.1.2: код должен жить недолго, рекомендуется не больше 10 минут
+	ttlAuthorizationCode = time.Minute
+)
+
+// Authorize — RFC 6749, раздел 4.1.1: выдаёт клиенту authorization code от имени вошедшего пользователя.
+// PKCE (RFC 7636) обязателен и только с методом S256.
+// Возвращает redirect_uri, куда вернуть пользователя с кодом или с ошибкой. Если redirect_uri пустой,
+// ему нельзя доверять (неизвестный клиент или незарегистрированный адрес), и ошибку нужно показать самому пользователю.
+// Пользователь вошёл по access токену сессии sessionID, поэтому amr кода берётся из этой сессии.
+func (authService *AuthService) Authorize(ctx context.Context, userID string, sessionID string, request *authorization_codes.AuthorizationRequest) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Authorize")
+	defer endSpan(span, &err)
+
+	session, err := authService.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return "", "", apperrors.ErrInvalidToken
+		}
+		return "", "", apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+	if session.UserID != userID {
+		return "", "", apperrors.ErrInvalidToken
+	}
+
+	return authService.authorize(ctx, userID, session.AMR, request)
+}
+
+// authorize проверяет запрос и сохраняет код; amr — как пользователь подтвердил вход перед выдачей кода
+func (authService *AuthService) authorize(ctx context.Context, userID string, amr string, request *authorization_codes.AuthorizationRequest) (string, string, error) {
+	client, err := authService.repo.GetClientByID(ctx, request.ClientID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrClientNotFound) {
+			return "", "", apperrors.ErrInvalidClient
+		}
+		return "", "", apperrors.Wrap(apperrors.ErrCantGetClient, err)
+	}
+
+	if !clientHasRedirectURI(client, request.RedirectURI) {
+		return "", "", apperrors.ErrInvalidRedirectURI
+	}
+	redirectURI := clientRedirectURI(client, request.RedirectURI)
+
+	if request.ResponseType != "code" {
+		return redirectURI, "", apperrors.ErrUnsupportedResponseType
+	}
+
+	if !clientHasGrantType(client, grantTypeAuthorizationCode) {
+		return redirectURI, "", apperrors.ErrUnauthorizedClient
+	}
+
+	if request.CodeChallengeMethod != "S256" || !isCodeChallenge(request.CodeChallenge) {
+		return redirectURI, "", apperrors.ErrInvalidRequest
+	}
+
+	grantedScope, err := grantScope(client.Scopes, request.Scope)
+	if err != nil {
+		return redirectURI, "", err
+	}
+
+	code, err := makeTokenInBase64()
+	if err != nil {
+		return redirectURI, "", apperrors.Wrap(apperrors.ErrCantSaveAuthorizationCode, err)
+	}
+
+	authorizationCode := &authorization_codes.AuthorizationCodes{
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   request.RedirectURI,
+		Scope:         grantedScope,
+		CodeChallenge: request.CodeChallenge,
+		AMR:           amr,
+	}
+	if err = authService.authorizationCodeStore.Save(ctx, code, authorizationCode, ttlAuthorizationCode); err != nil {
+		return redirectURI, "", apperrors.Wrap(apperrors.ErrCantSaveAuthorizationCode, err)
+	}
+
+	return redirectURI, code, nil
+}
@@ -0,0 +1,93 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+)
+
+// AuthorizeWithPassword — вход на самом authorization endpoint: браузер, пришедший по редиректу от клиента,
+// не может передать Bearer заголовок, поэтому пользователь вводит email, пароль и, если включён TOTP, код.
+// Сессия не открывается — после проверки выдаётся authorization code, как в Authorize.
+// Если у пользователя включён TOTP, а код не передан, возвращает apperrors.ErrMFARequired.
+func (authService *AuthService) AuthorizeWithPassword(ctx context.Context, email string, password string, totpCode string, request *authorization_codes.AuthorizationRequest, userAgent string, ipAddr string) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.AuthorizeWithPassword")
+	defer endSpan(span, &err)
+
+	event := &audit_events.AuditEvents{
+		EventType: audit_events.EventLogin,
+		Actor:     audit_events.ActorUser,
+		IPAddr:    ipAddr,
+		UserAgent: userAgent,
+	}
+
+	var usedTOTP bool
+	user, err := authService.verifyCredentials(ctx, email, password)
+	if err == nil {
+		event.UserID = user.UserID
+		usedTOTP, err = authService.verifySecondFactor(ctx, user.UserID, totpCode)
+	}
+	authService.audit(ctx, event, err)
+	if err != nil {
+		return "", "", err
+	}
+
+	amr := amrPassword
+	if usedTOTP {
+		amr += " " + amrOTP
+	}
+	return authService.authorize(ctx, user.UserID, amr, request)
+}
+
+// verifySecondFactor проверяет TOTP код, если у пользователя подтверждён TOTP; иначе второй фактор не нужен.
+// Возвращает true, если код был проверен — тогда в amr попадает otp.
+// MFA токена здесь нет, поэтому попытки считаются по пользователю: после maxMFAAttempts ошибок ввод кода
+// блокируется на totpLockoutPeriod, иначе шестизначный код можно было бы подбирать без ограничений.
+func (authService *AuthService) verifySecondFactor(ctx context.Context, userID string, code string) (bool, error) {
+	userTOTP, err := authService.repo.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTOTPNotFound) {
+			return false, nil
+		}
+		return false, apperrors.Wrap(apperrors.ErrCantGetTOTP, err)
+	}
+	if userTOTP.ConfirmedAt == nil {
+		return false, nil
+	}
+	if code == "" {
+		return false, apperrors.ErrMFARequired
+	}
+
+	// попытка считается до проверки кода, иначе параллельные запросы обошли бы лимит
+	attempts, err := authService.tokenRevocationStore.Increment(ctx, totpFailuresKey(userID), totpLockoutPeriod)
+	if err != nil {
+		return false, apperrors.Wrap(apperrors.ErrCantCheckRevocationToken, err)
+	}
+	if attempts > maxMFAAttempts {
+		return false, apperrors.ErrInvalidTOTPCode
+	}
+
+	step, err := authService.checkTOTPCode(userTOTP, code)
+	if err != nil {
+		return false, err
+	}
+
+	// код из уже принятого окна — повтор перехваченного кода
+	if err = authService.repo.UseTOTPStep(ctx, userID, step); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidTOTPCode) {
+			return false, apperrors.ErrInvalidTOTPCode
+		}
+		return false, apperrors.Wrap(apperrors.ErrCantSaveTOTP, err)
+	}
+
+	// код верный — счётчик сбрасывается; если не вышло, он истечёт сам, вход от этого не ломается
+	if err = authService.tokenRevocationStore.Delete(ctx, totpFailuresKey(userID)); err != nil {
+		authService.logger.ErrorContext(ctx, "can't reset totp failures counter",
+			"user_id", userID,
+			"error", err,
+		)
+	}
+	return true, nil
+}
@@ -1,19 +1,60 @@
 package auth_service
 
-import "github.com/Turalchik/authentication-service/internal/apperrors"
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
 
-func (authService *AuthService) CheckAccessTokenValidity(accessToken string) (string, error) {
-	isRevoked, err := authService.tokenRevocationStore.IsRevoked(accessToken)
+// CheckAccessTokenValidity возвращает user_id и session_id из валидного access токена
+func (authService *AuthService) CheckAccessTokenValidity(ctx context.Context, accessToken string) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CheckAccessTokenValidity")
+	defer endSpan(span, &err)
+
+	claims, err := authService.validateAccessToken(ctx, accessToken)
 	if err != nil {
-		return "", apperrors.ErrCantCheckRevocationToken
+		return "", "", err
+	}
+
+	// токен сервиса (client_credentials) не даёт доступа к ручкам пользователя
+	if claims.UserID == "" {
+		return "", "", apperrors.ErrInvalidToken
+	}
+	return claims.UserID, claims.SessionID, nil
+}
+
+// validateAccessToken проверяет подпись, срок жизни и black-list, возвращая claims токена
+func (authService *AuthService) validateAccessToken(ctx context.Context, accessToken string) (*Claims, error) {
+	claims, err := claimsFromAccessToken(accessToken, authService.tokenSigner)
+	if err != nil {
+		return nil, err
+	}
+
+	isRevoked, err := authService.isTokenRevoked(ctx, accessToken, claims)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantCheckRevocationToken, err)
 	}
 	if isRevoked {
-		return "", apperrors.ErrInvalidToken
+		return nil, apperrors.ErrInvalidToken
+	}
+
+	// MFA токен подписан тем же ключом, но доступа не даёт
+	if claims.TokenUse != "" {
+		return nil, apperrors.ErrInvalidToken
+	}
+
+	// у токена сервиса сессии нет
+	if claims.SessionID == "" {
+		return claims, nil
 	}
 
-	claims, err := claimsFromAccessToken(accessToken, authService.jwtSecretKey)
+	// сессия могла быть завершена с другого устройства
+	isRevoked, err = authService.tokenRevocationStore.IsRevoked(ctx, sessionRevocationKey(claims.SessionID))
 	if err != nil {
-		return "", err
+		return nil, apperrors.Wrap(apperrors.ErrCantCheckRevocationToken, err)
+	}
+	if isRevoked {
+		return nil, apperrors.ErrInvalidToken
 	}
-	return claims.UserID, nil
+
+	return claims, nil
 }
@@ -0,0 +1,29 @@
+package auth_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"strings"
+)
+
+// CheckClientScope проверяет токен сервиса (client_credentials) и наличие в нём scope, возвращая client_id.
+// Токен пользователя не подходит, даже если получен OAuth клиентом с тем же scope.
+func (authService *AuthService) CheckClientScope(ctx context.Context, accessToken string, scope string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CheckClientScope")
+	defer endSpan(span, &err)
+
+	claims, err := authService.validateAccessToken(ctx, accessToken)
+	if err != nil {
+		return "", err
+	}
+	if claims.UserID != "" || claims.ClientID == "" {
+		return "", apperrors.ErrInvalidToken
+	}
+
+	for _, granted := range strings.Fields(claims.Scope) {
+		if granted == scope {
+			return claims.ClientID, nil
+		}
+	}
+	return "", apperrors.ErrInsufficientScope
+}
@@ -0,0 +1,43 @@
+package auth_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/token_response"
+)
+
+const grantTypeClientCredentials = "client_credentials"
+
+// ClientCredentialsToken — RFC 6749, раздел 4.4: выдаёт access токен самому клиенту (сервис-сервис).
+// Refresh токен не выдаётся: клиент в любой момент может получить новый access токен по своим credentials.
+func (authService *AuthService) ClientCredentialsToken(ctx context.Context, clientID string, clientSecret string, scope string) (_ *token_response.TokenResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ClientCredentialsToken")
+	defer endSpan(span, &err)
+	defer func() { authService.observeTokens(tokenOperationIssued, err) }()
+
+	client, err := authService.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !clientHasGrantType(client, grantTypeClientCredentials) {
+		return nil, apperrors.ErrUnauthorizedClient
+	}
+
+	grantedScope, err := grantScope(client.Scopes, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := makeClientJWT(client.ClientID, grantedScope, authService.ttlAccessToken, authService.tokenSigner)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantCreateTokens, err)
+	}
+
+	return &token_response.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(authService.ttlAccessToken.Seconds()),
+		Scope:       grantedScope,
+	}, nil
+}
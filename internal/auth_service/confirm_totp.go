@@ -0,0 +1,37 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// ConfirmTOTP включает второй фактор, если пользователь ввёл верный код из приложения
+func (authService *AuthService) ConfirmTOTP(ctx context.Context, userID string, code string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ConfirmTOTP")
+	defer endSpan(span, &err)
+
+	userTOTP, err := authService.repo.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTOTPNotFound) {
+			return apperrors.ErrTOTPNotFound
+		}
+		return apperrors.Wrap(apperrors.ErrCantGetTOTP, err)
+	}
+	if userTOTP.ConfirmedAt != nil {
+		return apperrors.ErrTOTPAlreadyEnrolled
+	}
+
+	step, err := authService.checkTOTPCode(userTOTP, code)
+	if err != nil {
+		return err
+	}
+
+	if err = authService.repo.ConfirmUserTOTP(ctx, userID, step); err != nil {
+		if errors.Is(err, apperrors.ErrTOTPAlreadyEnrolled) {
+			return apperrors.ErrTOTPAlreadyEnrolled
+		}
+		return apperrors.Wrap(apperrors.ErrCantSaveTOTP, err)
+	}
+	return nil
+}
@@ -1,57 +1,107 @@
 package auth_service
 
 import (
+	"context"
 	"errors"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
 	"github.com/Turalchik/authentication-service/internal/entities/sessions"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/google/uuid"
+	"strings"
+	"time"
 )
 
-func (authService *AuthService) CreateTokens(userID string, userAgent string, ipAddr string) (string, string, error) {
+// CreateTokens выдаёт токены по user_id без проверки учётных данных.
+// Работает только в доверенном режиме (TRUSTED_USER_ID_LOGIN), в остальных случаях пользователь входит через Login.
+func (authService *AuthService) CreateTokens(ctx context.Context, userID string, userAgent string, ipAddr string) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.CreateTokens")
+	defer endSpan(span, &err)
+
+	accessToken, refreshToken, err := authService.createTokens(ctx, userID, userAgent, ipAddr)
+
+	sessionID, _ := splitRefreshToken(refreshToken)
+	authService.audit(ctx, &audit_events.AuditEvents{
+		EventType: audit_events.EventTokensIssued,
+		Actor:     audit_events.ActorTrustedCaller,
+		UserID:    userID,
+		SessionID: sessionID,
+		IPAddr:    ipAddr,
+		UserAgent: userAgent,
+	}, err)
+
+	return accessToken, refreshToken, err
+}
+
+func (authService *AuthService) createTokens(ctx context.Context, userID string, userAgent string, ipAddr string) (string, string, error) {
+	if !authService.trustedUserIDLogin {
+		return "", "", apperrors.ErrUserIDLoginDisabled
+	}
 	if userID == "" {
 		return "", "", apperrors.ErrInvalidUserID
 	}
 
-	_, err := authService.repo.GetSessionByUserID(userID)
-	if err == nil {
-		return "", "", apperrors.ErrUserAlreadyExists
-	}
-
-	// case when the user does not have a token yet
-	if errors.Is(err, apperrors.ErrUserNotFound) {
-		// создаем токены (access и refresh)
-		accessToken, err := makeJWT(userID, authService.ttlAccessToken, authService.jwtSecretKey)
-		if err != nil {
-			return "", "", apperrors.ErrCantCreateTokens
-		}
-
-		// создаём refresh токен
-		refreshToken, err := makeTokenInBase64()
-		if err != nil {
-			return "", "", apperrors.ErrCantCreateTokens
-		}
-
-		// хэшируем refresh токен
-		refreshTokenHash, err := bcrypt.GenerateFromPassword([]byte(refreshToken), bcrypt.DefaultCost)
-		if err != nil {
-			return "", "", apperrors.ErrCantCreateTokens
-		}
-
-		// создаём сессию и сохраняем её в базу
-		newSession := &sessions.Sessions{
-			UserID:           userID,
-			RefreshTokenHash: refreshTokenHash,
-			UserAgent:        userAgent,
-			IPAddr:           ipAddr,
-		}
-		err = authService.repo.CreateSession(newSession)
-		if err != nil {
-			return "", "", apperrors.ErrCantCreateSession
-		}
-
-		// возвращаем токены
-		return accessToken, refreshToken, nil
-	}
-
-	return "", "", err
+	return authService.issueTokens(ctx, &sessions.Sessions{
+		UserID:    userID,
+		UserAgent: userAgent,
+		IPAddr:    ipAddr,
+	})
+}
+
+// issueTokens открывает сессию после первого фактора. Если у пользователя подтверждён TOTP,
+// сессия не открывается: вместо пары токенов возвращается *apperrors.MFARequiredError с MFA токеном.
+func (authService *AuthService) issueTokens(ctx context.Context, newSession *sessions.Sessions) (string, string, error) {
+	userTOTP, err := authService.repo.GetUserTOTP(ctx, newSession.UserID)
+	if err != nil && !errors.Is(err, apperrors.ErrTOTPNotFound) {
+		return "", "", apperrors.Wrap(apperrors.ErrCantGetTOTP, err)
+	}
+	if err != nil || userTOTP.ConfirmedAt == nil {
+		return authService.openSession(ctx, newSession)
+	}
+
+	mfaToken, err := makeMFAToken(newSession.UserID, strings.Fields(newSession.AMR), ttlMFAToken, authService.tokenSigner)
+	if err != nil {
+		return "", "", apperrors.Wrap(apperrors.ErrCantCreateTokens, err)
+	}
+	return "", "", &apperrors.MFARequiredError{MFAToken: mfaToken, ExpiresIn: ttlMFAToken}
+}
+
+// openSession открывает новую сессию и выдаёт её первую пару токенов
+func (authService *AuthService) openSession(ctx context.Context, newSession *sessions.Sessions) (string, string, error) {
+	// каждая выдача токенов открывает новую сессию (отдельное устройство)
+	newSession.SessionID = uuid.NewString()
+
+	// создаем токены (access и refresh)
+	accessToken, err := makeJWT(newSession, authService.ttlAccessToken, authService.tokenSigner)
+	if err != nil {
+		return "", "", apperrors.Wrap(apperrors.ErrCantCreateTokens, err)
+	}
+
+	// создаём refresh токен и его хэш
+	if err = checkCanceled(ctx); err != nil {
+		return "", "", err
+	}
+	start := time.Now()
+	refreshToken, refreshTokenHash, err := makeRefreshToken(newSession.SessionID)
+	authService.observeHash(hashAlgorithmBcrypt, hashOperationHash, start)
+	if err != nil {
+		return "", "", apperrors.Wrap(apperrors.ErrCantCreateTokens, err)
+	}
+	newSession.RefreshTokenHash = refreshTokenHash
+
+	// сохраняем сессию в базу, событие session.created уходит в outbox вместе с ней
+	events := authService.webhookEvents(webhook_events.EventSessionCreated, newSession.UserID, &webhook_events.SessionCreated{
+		UserID:    newSession.UserID,
+		SessionID: newSession.SessionID,
+		UserAgent: newSession.UserAgent,
+		IPAddr:    newSession.IPAddr,
+		ClientID:  newSession.ClientID,
+		AMR:       strings.Fields(newSession.AMR),
+	})
+	if err = authService.repo.CreateSession(ctx, newSession, events...); err != nil {
+		return "", "", apperrors.Wrap(apperrors.ErrCantCreateSession, err)
+	}
+
+	// возвращаем токены
+	return accessToken, refreshToken, nil
 }
@@ -0,0 +1,90 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/user_totp"
+	"github.com/Turalchik/authentication-service/internal/totp"
+	"time"
+)
+
+const (
+	// totpIssuer — под этим именем аккаунт появится в приложении-аутентификаторе
+	totpIssuer = "authentication-service"
+	// totpSkew — сколько соседних 30-секундных окон принимается из-за расхождения часов
+	totpSkew = 1
+
+	ttlMFAToken = 5 * time.Minute
+	// maxMFAAttempts — сколько раз можно ввести TOTP код по одному MFA токену, потом токен отзывается
+	maxMFAAttempts = 5
+	// totpLockoutPeriod — на сколько блокируется ввод TOTP кода на /oauth2/authorize после maxMFAAttempts ошибок
+	totpLockoutPeriod = 15 * time.Minute
+
+	// значения amr по RFC 8176
+	amrPassword = "pwd"
+	amrOTP      = "otp"
+)
+
+// EnrollTOTP начинает привязку TOTP: генерирует секрет, сохраняет его зашифрованным и возвращает
+// otpauth:// URI для QR кода и сам секрет в base32. Второй фактор включится после ConfirmTOTP.
+func (authService *AuthService) EnrollTOTP(ctx context.Context, userID string) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.EnrollTOTP")
+	defer endSpan(span, &err)
+
+	if authService.secretBox == nil {
+		return "", "", apperrors.ErrTOTPUnavailable
+	}
+	if userID == "" {
+		return "", "", apperrors.ErrInvalidUserID
+	}
+
+	// у пользователя с паролем в приложении будет виден email, у остальных — user_id
+	accountName := userID
+	user, err := authService.repo.GetUserByID(ctx, userID)
+	if err == nil {
+		accountName = user.Email
+	} else if !errors.Is(err, apperrors.ErrUserNotFound) {
+		return "", "", apperrors.Wrap(apperrors.ErrCantGetUser, err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", apperrors.Wrap(apperrors.ErrCantSaveTOTP, err)
+	}
+	secretCiphertext, err := authService.secretBox.Seal(secret, []byte(userID))
+	if err != nil {
+		return "", "", apperrors.Wrap(apperrors.ErrCantSaveTOTP, err)
+	}
+
+	err = authService.repo.SaveUserTOTP(ctx, &user_totp.UserTOTP{
+		UserID:           userID,
+		SecretCiphertext: secretCiphertext,
+	})
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTOTPAlreadyEnrolled) {
+			return "", "", apperrors.ErrTOTPAlreadyEnrolled
+		}
+		return "", "", apperrors.Wrap(apperrors.ErrCantSaveTOTP, err)
+	}
+
+	return totp.KeyURI(totpIssuer, accountName, secret), totp.EncodeSecret(secret), nil
+}
+
+// checkTOTPCode расшифровывает секрет пользователя и возвращает окно, которому соответствует код
+func (authService *AuthService) checkTOTPCode(userTOTP *user_totp.UserTOTP, code string) (int64, error) {
+	if authService.secretBox == nil {
+		return 0, apperrors.ErrTOTPUnavailable
+	}
+
+	secret, err := authService.secretBox.Open(userTOTP.SecretCiphertext, []byte(userTOTP.UserID))
+	if err != nil {
+		return 0, apperrors.Wrap(apperrors.ErrCantDecryptSecret, err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return 0, apperrors.ErrInvalidTOTPCode
+	}
+	return step, nil
+}
@@ -0,0 +1,81 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/token_response"
+)
+
+// ExchangeAuthorizationCode — RFC 6749, раздел 4.1.3: меняет authorization code на пару токенов новой сессии.
+// Код одноразовый; redirect_uri должен совпасть с переданным в /oauth2/authorize, code_verifier — с code_challenge.
+func (authService *AuthService) ExchangeAuthorizationCode(ctx context.Context, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string, userAgent string, ipAddr string) (_ *token_response.TokenResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ExchangeAuthorizationCode")
+	defer endSpan(span, &err)
+
+	resp, err := authService.exchangeAuthorizationCode(ctx, clientID, clientSecret, code, redirectURI, codeVerifier, userAgent, ipAddr)
+
+	event := &audit_events.AuditEvents{
+		EventType: audit_events.EventTokensIssued,
+		Actor:     audit_events.ActorClientPrefix + clientID,
+		IPAddr:    ipAddr,
+		UserAgent: userAgent,
+	}
+	if resp != nil {
+		event.UserID, event.SessionID = authService.auditClaims(resp.AccessToken)
+	}
+	authService.audit(ctx, event, err)
+
+	return resp, err
+}
+
+func (authService *AuthService) exchangeAuthorizationCode(ctx context.Context, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string, userAgent string, ipAddr string) (*token_response.TokenResponse, error) {
+	if code == "" || codeVerifier == "" {
+		return nil, apperrors.ErrInvalidRequest
+	}
+
+	client, err := authService.authenticateTokenClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !clientHasGrantType(client, grantTypeAuthorizationCode) {
+		return nil, apperrors.ErrUnauthorizedClient
+	}
+
+	authorizationCode, err := authService.authorizationCodeStore.Consume(ctx, code)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrAuthorizationCodeNotFound) {
+			return nil, apperrors.ErrInvalidGrant
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantConsumeAuthorizationCode, err)
+	}
+
+	if authorizationCode.ClientID != client.ClientID ||
+		authorizationCode.RedirectURI != redirectURI ||
+		!verifyCodeChallenge(codeVerifier, authorizationCode.CodeChallenge) {
+		return nil, apperrors.ErrInvalidGrant
+	}
+
+	accessToken, refreshToken, err := authService.openSession(ctx, &sessions.Sessions{
+		UserID:    authorizationCode.UserID,
+		UserAgent: userAgent,
+		IPAddr:    ipAddr,
+		ClientID:  client.ClientID,
+		Scope:     authorizationCode.Scope,
+		AMR:       authorizationCode.AMR,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token_response.TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(authService.ttlAccessToken.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        authorizationCode.Scope,
+	}, nil
+}
@@ -1,35 +1,89 @@
 package auth_service
 
 import (
-	"bytes"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"io"
-	"net/http"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"time"
 )
 
+// Claims — токен пользователя несёт user_id, sid и amr (RFC 8176: как пользователь вошёл, например ["pwd","otp"]),
+// токен сервиса (client_credentials) — client_id, scope и sub = client_id.
+// TokenUse заполнен только у служебных токенов (MFA токен) — access токеном они не являются.
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID    string   `json:"user_id,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	AMR       []string `json:"amr,omitempty"`
+	TokenUse  string   `json:"token_use,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func makeJWT(userID string, ttl time.Duration, jwtSecretKey []byte) (string, error) {
+// tokenUseMFA — MFA токен: первый фактор пройден, ждём TOTP код
+const tokenUseMFA = "mfa"
+
+// makeJWT выпускает access токен сессии; у сессии, открытой OAuth клиентом, в нём ещё client_id и scope
+func makeJWT(session *sessions.Sessions, ttl time.Duration, tokenSigner TokenSigner) (string, error) {
+	claims := &Claims{
+		UserID:    session.UserID,
+		SessionID: session.SessionID,
+		ClientID:  session.ClientID,
+		Scope:     session.Scope,
+		AMR:       strings.Fields(session.AMR),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return tokenSigner.Sign(claims)
+}
+
+// makeClientJWT выпускает access токен, за которым стоит не пользователь, а сам OAuth клиент
+func makeClientJWT(clientID string, scope string, ttl time.Duration, tokenSigner TokenSigner) (string, error) {
+	claims := &Claims{
+		ClientID: clientID,
+		Scope:    scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   clientID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return tokenSigner.Sign(claims)
+}
+
+// makeMFAToken выпускает MFA токен: он подтверждает пройденный первый фактор и годится
+// только для обмена на пару токенов вместе с TOTP кодом
+func makeMFAToken(userID string, amr []string, ttl time.Duration, tokenSigner TokenSigner) (string, error) {
 	claims := &Claims{
-		UserID: userID,
+		UserID:   userID,
+		AMR:      amr,
+		TokenUse: tokenUseMFA,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.NewString(),
+			Subject:   userID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-	return tok.SignedString(jwtSecretKey)
+	return tokenSigner.Sign(claims)
 }
 
 func makeTokenInBase64() (string, error) {
@@ -42,26 +96,326 @@ func makeTokenInBase64() (string, error) {
 	return token, nil
 }
 
-func claimsFromAccessToken(tokenStr string, jwtSecretKey []byte) (*Claims, error) {
-	claims := &Claims{}
-	tok, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
-		if t.Method != jwt.SigningMethodHS512 {
-			return nil, fmt.Errorf("unexpected signing method")
+// makeRefreshToken возвращает refresh токен вида <session_id>.<secret> и bcrypt-хеш его секретной части.
+// По префиксу сессию можно найти без access токена (например, при отзыве по RFC 7009),
+// а хешируется только секрет, потому что bcrypt учитывает не больше 72 байт.
+func makeRefreshToken(sessionID string) (string, []byte, error) {
+	secret, err := makeTokenInBase64()
+	if err != nil {
+		return "", nil, err
+	}
+
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return sessionID + "." + secret, secretHash, nil
+}
+
+// splitRefreshToken разбирает refresh токен на session_id и секрет.
+// У токенов, выданных до появления префикса, session_id пустой.
+func splitRefreshToken(refreshToken string) (string, string) {
+	sessionID, secret, found := strings.Cut(refreshToken, ".")
+	if !found {
+		return "", refreshToken
+	}
+	return sessionID, secret
+}
+
+// refreshTokenMatchesSession проверяет, что refresh токен — текущий токен этой сессии
+func refreshTokenMatchesSession(refreshToken string, session *sessions.Sessions) bool {
+	sessionID, secret := splitRefreshToken(refreshToken)
+	if sessionID != "" && sessionID != session.SessionID {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(session.RefreshTokenHash, []byte(secret)) == nil
+}
+
+// clientHasGrantType проверяет, что клиенту разрешён grant_type
+func clientHasGrantType(client *clients.Clients, grantType string) bool {
+	for _, allowed := range strings.Fields(client.GrantTypes) {
+		if allowed == grantType {
+			return true
 		}
-		return jwtSecretKey, nil
-	})
-	if err != nil || !tok.Valid {
+	}
+	return false
+}
+
+// grantScope возвращает scope, который получит клиент: запрошенный, если он целиком входит в разрешённый,
+// или весь разрешённый, если клиент ничего не запросил
+func grantScope(allowedScope string, requestedScope string) (string, error) {
+	allowed := strings.Fields(allowedScope)
+	requested := strings.Fields(requestedScope)
+	if len(requested) == 0 {
+		return strings.Join(allowed, " "), nil
+	}
+
+	for _, scope := range requested {
+		found := false
+		for _, a := range allowed {
+			if a == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", apperrors.ErrInvalidScope
+		}
+	}
+	return strings.Join(requested, " "), nil
+}
+
+// clientHasRedirectURI проверяет redirect_uri точным сравнением с зарегистрированными.
+// Пустой redirect_uri допустим, только если у клиента он ровно один (RFC 6749, раздел 3.1.2.3).
+func clientHasRedirectURI(client *clients.Clients, redirectURI string) bool {
+	registered := strings.Fields(client.RedirectURIs)
+	if redirectURI == "" {
+		return len(registered) == 1
+	}
+	for _, uri := range registered {
+		if uri == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// clientRedirectURI — куда вернуть пользователя: переданный redirect_uri или единственный зарегистрированный
+func clientRedirectURI(client *clients.Clients, redirectURI string) string {
+	if redirectURI != "" {
+		return redirectURI
+	}
+	return strings.Fields(client.RedirectURIs)[0]
+}
+
+// isCodeChallenge проверяет формат S256 code_challenge: base64url без паддинга от sha256
+func isCodeChallenge(codeChallenge string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(codeChallenge)
+	return err == nil && len(raw) == sha256.Size
+}
+
+// verifyCodeChallenge — RFC 7636, раздел 4.6: BASE64URL(SHA256(code_verifier)) == code_challenge
+func verifyCodeChallenge(codeVerifier string, codeChallenge string) bool {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(codeChallenge)) == 1
+}
+
+func claimsFromAccessToken(tokenStr string, tokenSigner TokenSigner) (*Claims, error) {
+	claims := &Claims{}
+	if err := tokenSigner.Verify(tokenStr, claims); err != nil {
 		return nil, apperrors.ErrInvalidToken
 	}
 	return claims, nil
 }
 
-func notifyWebhook(userID string, oldIP string, newIP string, webhookURL string) (resp *http.Response, err error) {
-	payload := map[string]string{
-		"user_id":     userID,
-		"original_ip": oldIP,
-		"new_ip":      newIP,
+// webhookEventSource — атрибут source в CloudEvents
+const webhookEventSource = "/authentication-service"
+
+// webhookEvents готовит событие для outbox в конверте CloudEvents; репозиторий сохранит его в одной
+// транзакции с изменением сессии. Если тип события не включён, событий нет.
+func (authService *AuthService) webhookEvents(eventType string, userID string, data interface{}) []*webhook_events.WebhookEvents {
+	if !authService.webhookEventTypes[eventType] {
+		return nil
+	}
+
+	event := &webhook_events.CloudEvent{
+		SpecVersion:     webhook_events.CloudEventsSpecVersion,
+		ID:              uuid.NewString(),
+		Source:          webhookEventSource,
+		Type:            eventType,
+		Subject:         userID,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		DataVersion:     webhook_events.DataVersion,
+		Data:            data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil
+	}
+
+	return []*webhook_events.WebhookEvents{{
+		EventID:   event.ID,
+		EventType: eventType,
+		Payload:   payload,
+	}}
+}
+
+// sessionRevokedEvents — событие session.revoked для завершённой сессии
+func (authService *AuthService) sessionRevokedEvents(userID string, sessionID string, reason string) []*webhook_events.WebhookEvents {
+	return authService.webhookEvents(webhook_events.EventSessionRevoked, userID, &webhook_events.SessionRevoked{
+		UserID:    userID,
+		SessionID: sessionID,
+		Reason:    reason,
+	})
+}
+
+// refreshTokenDigest — sha256 от refresh токена. Токен — 32 случайных байта, поэтому
+// быстрого хэша достаточно, а по дайджесту можно искать в истории без перебора bcrypt.
+func refreshTokenDigest(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionRevocationKey — ключ в black-list, по которому отзываются все access токены сессии
+func sessionRevocationKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// tokenRevocationKey — ключ в black-list для одного токена: jti, а не сам токен,
+// чтобы в Redis не лежали действующие bearer токены
+func tokenRevocationKey(tokenID string) string {
+	return "jti:" + tokenID
+}
+
+// mfaAttemptsKey — счётчик попыток ввести TOTP код по MFA токену
+func mfaAttemptsKey(tokenID string) string {
+	return "mfa_attempts:" + tokenID
+}
+
+// totpFailuresKey — счётчик неудачных попыток ввести TOTP код пользователя на /oauth2/authorize
+func totpFailuresKey(userID string) string {
+	return "totp_failures:" + userID
+}
+
+// countMFAAttempt учитывает попытку ввести TOTP код по MFA токену. Попытка считается до проверки кода,
+// иначе параллельные запросы обошли бы лимит. После maxMFAAttempts попыток токен отзывается: без этого
+// шестизначный код можно было бы подбирать всё время жизни токена.
+func (authService *AuthService) countMFAAttempt(ctx context.Context, claims *Claims) error {
+	if claims.ExpiresAt == nil {
+		return apperrors.ErrInvalidToken
+	}
+
+	attempts, err := authService.tokenRevocationStore.Increment(ctx, mfaAttemptsKey(claims.ID), time.Until(claims.ExpiresAt.Time))
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrCantCheckRevocationToken, err)
+	}
+	if attempts <= maxMFAAttempts {
+		return nil
+	}
+
+	if err = authService.revokeToken(ctx, claims); err != nil {
+		return err
+	}
+	return apperrors.ErrInvalidToken
+}
+
+// revokeToken заносит токен в black-list по jti до его exp: дольше хранить отзыв незачем
+func (authService *AuthService) revokeToken(ctx context.Context, claims *Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return apperrors.ErrInvalidToken
+	}
+
+	// истёкший токен и так не пройдёт проверку, а TTL 0 в Redis означал бы ключ навсегда
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := authService.tokenRevocationStore.Revoke(ctx, tokenRevocationKey(claims.ID), ttl); err != nil {
+		return apperrors.Wrap(apperrors.ErrCantRevokeToken, err)
+	}
+	return nil
+}
+
+// isTokenRevoked проверяет black-list по jti, а до legacyRevocationKeysUntil — и по ключу старого формата
+func (authService *AuthService) isTokenRevoked(ctx context.Context, token string, claims *Claims) (bool, error) {
+	// токен без jti отозвать нельзя, поэтому и принимать его нельзя
+	if claims.ID == "" {
+		return true, nil
+	}
+
+	isRevoked, err := authService.tokenRevocationStore.IsRevoked(ctx, tokenRevocationKey(claims.ID))
+	if err != nil || isRevoked {
+		return isRevoked, err
+	}
+
+	if time.Now().Before(authService.legacyRevocationKeysUntil) {
+		return authService.tokenRevocationStore.IsRevoked(ctx, token)
+	}
+	return false, nil
+}
+
+// checkCanceled — bcrypt и argon2 не принимают контекст, поэтому перед ними проверяем, нужен ли ещё результат:
+// клиент ушёл или дедлайн истёк — CPU на хеширование не тратим
+func checkCanceled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.Wrap(apperrors.ErrRequestCanceled, err)
+	}
+	return nil
+}
+
+// auditErrorCodes — стабильные коды ошибок для журнала аутентификации; остальные ошибки пишутся как internal_error
+var auditErrorCodes = []struct {
+	err  error
+	code string
+}{
+	{apperrors.ErrUserIDLoginDisabled, "user_id_login_disabled"},
+	{apperrors.ErrInvalidUserID, "invalid_user_id"},
+	{apperrors.ErrInvalidCredentials, "invalid_credentials"},
+	{apperrors.ErrInvalidToken, "invalid_token"},
+	{apperrors.ErrSessionNotFound, "session_not_found"},
+	{apperrors.ErrTokensDontMatch, "tokens_dont_match"},
+	{apperrors.ErrRefreshTokenReused, "refresh_token_reused"},
+	{apperrors.ErrInvalidTOTPCode, "invalid_totp_code"},
+	{apperrors.ErrInvalidRequest, "invalid_request"},
+	{apperrors.ErrInvalidClient, "invalid_client"},
+	{apperrors.ErrUnauthorizedClient, "unauthorized_client"},
+	{apperrors.ErrInvalidGrant, "invalid_grant"},
+	{context.DeadlineExceeded, "timeout"},
+	{apperrors.ErrRequestCanceled, "request_canceled"},
+}
+
+func auditErrorCode(err error) string {
+	for _, known := range auditErrorCodes {
+		if errors.Is(err, known.err) {
+			return known.code
+		}
+	}
+	return "internal_error"
+}
+
+// outcome — исход действия для журнала и метрик: success, mfa_required или failure со стабильным кодом ошибки
+func outcome(err error) (string, string) {
+	var mfaErr *apperrors.MFARequiredError
+	switch {
+	case err == nil:
+		return audit_events.OutcomeSuccess, ""
+	case errors.As(err, &mfaErr):
+		return audit_events.OutcomeMFARequired, ""
+	default:
+		return audit_events.OutcomeFailure, auditErrorCode(err)
+	}
+}
+
+// audit записывает исход действия в журнал аутентификации и учитывает его в метриках.
+// Недоступный журнал не мешает входу — ошибка только логируется.
+func (authService *AuthService) audit(ctx context.Context, event *audit_events.AuditEvents, err error) {
+	event.Outcome, event.ErrorCode = outcome(err)
+	if operation, ok := tokenOperations[event.EventType]; ok && authService.metrics != nil {
+		authService.metrics.ObserveTokens(operation, event.Outcome, event.ErrorCode)
+	}
+
+	if authService.auditLog == nil {
+		return
+	}
+	// исход отменённого запроса тоже должен попасть в журнал; запись всё равно ограничена таймаутом repo
+	if err = authService.auditLog.InsertAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+		authService.logger.ErrorContext(ctx, "can't save audit event",
+			"event_type", event.EventType,
+			"user_id", event.UserID,
+			"session_id", event.SessionID,
+			"error", err)
+	}
+}
+
+// auditClaims — user_id и session_id из подписанного токена для журнала; у неверного токена пустые
+func (authService *AuthService) auditClaims(token string) (string, string) {
+	claims, err := claimsFromAccessToken(token, authService.tokenSigner)
+	if err != nil {
+		return "", ""
 	}
-	b, _ := json.Marshal(payload)
-	return http.Post(webhookURL, "application/json", io.NopCloser(bytes.NewReader(b)))
+	return claims.UserID, claims.SessionID
 }
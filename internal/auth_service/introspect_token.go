@@ -0,0 +1,48 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/token_introspection"
+)
+
+// IntrospectToken — RFC 7662: сообщает аутентифицированному клиенту, активен ли токен и кому он выдан.
+// Отозванный, просроченный, поддельный или вовсе не access токен считается неактивным.
+func (authService *AuthService) IntrospectToken(ctx context.Context, clientID string, clientSecret string, token string) (_ *token_introspection.TokenIntrospection, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.IntrospectToken")
+	defer endSpan(span, &err)
+
+	if _, err := authService.authenticateClient(ctx, clientID, clientSecret); err != nil {
+		return nil, err
+	}
+
+	claims, err := authService.validateAccessToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrInvalidToken) {
+			return &token_introspection.TokenIntrospection{Active: false}, nil
+		}
+		return nil, err
+	}
+
+	introspection := &token_introspection.TokenIntrospection{
+		Active:    true,
+		Subject:   claims.UserID,
+		TokenID:   claims.ID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+		TokenType: "Bearer",
+		SessionID: claims.SessionID,
+	}
+	if introspection.Subject == "" {
+		introspection.Subject = claims.Subject
+	}
+	if claims.ExpiresAt != nil {
+		introspection.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		introspection.IssuedAt = claims.IssuedAt.Unix()
+	}
+
+	return introspection, nil
+}
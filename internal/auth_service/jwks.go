@@ -0,0 +1,8 @@
+package auth_service
+
+import "github.com/Turalchik/authentication-service/internal/token_signer"
+
+// JWKS — публичные ключи, которыми можно проверить наши access токены без обращения к сервису
+func (authService *AuthService) JWKS() token_signer.JWKS {
+	return authService.tokenSigner.JWKS()
+}
@@ -0,0 +1,18 @@
+package auth_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+)
+
+func (authService *AuthService) ListSessions(ctx context.Context, userID string) (_ []*sessions.Sessions, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ListSessions")
+	defer endSpan(span, &err)
+
+	userSessions, err := authService.repo.ListSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+	return userSessions, nil
+}
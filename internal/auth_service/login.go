@@ -0,0 +1,98 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
+	"github.com/Turalchik/authentication-service/internal/password_hasher"
+	"sync"
+	"time"
+)
+
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// Login проверяет email и пароль и открывает новую сессию пользователя.
+// Если у пользователя включён TOTP, возвращает *apperrors.MFARequiredError — см. VerifyMFA.
+func (authService *AuthService) Login(ctx context.Context, email string, password string, userAgent string, ipAddr string) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer endSpan(span, &err)
+
+	event := &audit_events.AuditEvents{
+		EventType: audit_events.EventLogin,
+		Actor:     audit_events.ActorUser,
+		IPAddr:    ipAddr,
+		UserAgent: userAgent,
+	}
+
+	user, err := authService.verifyCredentials(ctx, email, password)
+	if err != nil {
+		authService.audit(ctx, event, err)
+		return "", "", err
+	}
+
+	accessToken, refreshToken, err := authService.issueTokens(ctx, &sessions.Sessions{
+		UserID:    user.UserID,
+		UserAgent: userAgent,
+		IPAddr:    ipAddr,
+		AMR:       amrPassword,
+	})
+
+	event.UserID = user.UserID
+	event.SessionID, _ = splitRefreshToken(refreshToken)
+	authService.audit(ctx, event, err)
+
+	return accessToken, refreshToken, err
+}
+
+// verifyCredentials возвращает пользователя, если пароль верный. Неизвестный email и неверный пароль
+// неотличимы снаружи ни по ошибке, ни по времени ответа.
+func (authService *AuthService) verifyCredentials(ctx context.Context, email string, password string) (*users.Users, error) {
+	normalizedEmail, err := normalizeEmail(email)
+	if err != nil || len(password) > maxPasswordLength {
+		return nil, apperrors.ErrInvalidCredentials
+	}
+
+	user, err := authService.repo.GetUserByEmail(ctx, normalizedEmail)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			// тратим на проверку столько же времени, сколько на настоящий хеш
+			if err = checkCanceled(ctx); err != nil {
+				return nil, err
+			}
+			dummyPasswordHash := getDummyPasswordHash()
+			start := time.Now()
+			_ = password_hasher.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+			authService.observeHash(hashAlgorithmArgon2id, hashOperationCompare, start)
+			return nil, apperrors.ErrInvalidCredentials
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantGetUser, err)
+	}
+
+	if err = checkCanceled(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	err = password_hasher.CompareHashAndPassword(user.PasswordHash, []byte(password))
+	authService.observeHash(hashAlgorithmArgon2id, hashOperationCompare, start)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrMismatchedPassword) {
+			return nil, apperrors.ErrInvalidCredentials
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantGetUser, err)
+	}
+
+	return user, nil
+}
+
+func getDummyPasswordHash() string {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = password_hasher.GenerateFromPassword([]byte("dummy password"))
+	})
+	return dummyPasswordHash
+}
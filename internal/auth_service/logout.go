@@ -1,18 +1,48 @@
 package auth_service
 
 import (
+	"context"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
 )
 
-func (authService *AuthService) Logout(accessToken string, userID string) error {
-	// заносим access токен в black-list
-	if err := authService.tokenRevocationStore.Revoke(accessToken, authService.ttlAccessToken); err != nil {
-		return apperrors.ErrCantRevokeToken
+// Logout завершает только ту сессию, к которой привязан access токен
+func (authService *AuthService) Logout(ctx context.Context, accessToken string, sessionID string, userAgent string, ipAddr string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Logout")
+	defer endSpan(span, &err)
+
+	// user_id нужен только для события: access токен уже проверен middleware
+	var userID string
+	if claims, err := claimsFromAccessToken(accessToken, authService.tokenSigner); err == nil {
+		userID = claims.UserID
+	}
+
+	err = authService.logout(ctx, accessToken, sessionID, authService.sessionRevokedEvents(userID, sessionID, webhook_events.RevokeReasonLogout)...)
+	authService.audit(ctx, &audit_events.AuditEvents{
+		EventType: audit_events.EventLogout,
+		Actor:     audit_events.ActorUser,
+		UserID:    userID,
+		SessionID: sessionID,
+		IPAddr:    ipAddr,
+		UserAgent: userAgent,
+	}, err)
+
+	return err
+}
+
+// logout отзывает access токен и удаляет сессию; события webhook пишутся в outbox вместе с удалением
+func (authService *AuthService) logout(ctx context.Context, accessToken string, sessionID string, events ...*webhook_events.WebhookEvents) error {
+	// заносим access токен в black-list; истёкший или чужой токен и так не пройдёт проверку
+	if claims, err := claimsFromAccessToken(accessToken, authService.tokenSigner); err == nil {
+		if err = authService.revokeToken(ctx, claims); err != nil {
+			return err
+		}
 	}
 
 	// удаляем refresh токен из базы
-	if err := authService.repo.DeleteSessionByUserID(userID); err != nil {
-		return apperrors.ErrCantDeleteSession
+	if err := authService.repo.DeleteSessionByID(ctx, sessionID, events...); err != nil {
+		return apperrors.Wrap(apperrors.ErrCantDeleteSession, err)
 	}
 
 	return nil
@@ -0,0 +1,52 @@
+package auth_service
+
+import (
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"time"
+)
+
+type Metrics interface {
+	ObserveTokens(operation string, outcome string, errorCode string)
+	ObserveHash(algorithm string, operation string, duration time.Duration)
+}
+
+// операции для метрики выдачи токенов
+const (
+	tokenOperationIssued    = "issued"
+	tokenOperationRefreshed = "refreshed"
+	tokenOperationRevoked   = "revoked"
+)
+
+// tokenOperations — какие события журнала считаются выдачей, обновлением или отзывом токенов
+var tokenOperations = map[string]string{
+	audit_events.EventTokensIssued:    tokenOperationIssued,
+	audit_events.EventLogin:           tokenOperationIssued,
+	audit_events.EventMFAVerify:       tokenOperationIssued,
+	audit_events.EventTokensRefreshed: tokenOperationRefreshed,
+	audit_events.EventLogout:          tokenOperationRevoked,
+}
+
+// алгоритмы и операции хеширования для метрики времени хеширования
+const (
+	hashAlgorithmArgon2id = "argon2id"
+	hashAlgorithmBcrypt   = "bcrypt"
+
+	hashOperationHash    = "hash"
+	hashOperationCompare = "compare"
+)
+
+// observeTokens учитывает операцию, которая не пишется в журнал аутентификации
+func (authService *AuthService) observeTokens(operation string, err error) {
+	if authService.metrics == nil {
+		return
+	}
+	result, errorCode := outcome(err)
+	authService.metrics.ObserveTokens(operation, result, errorCode)
+}
+
+// observeHash учитывает время хеширования пароля, секрета клиента или refresh токена, начатого в start
+func (authService *AuthService) observeHash(algorithm string, operation string, start time.Time) {
+	if authService.metrics != nil {
+		authService.metrics.ObserveHash(algorithm, operation, time.Since(start))
+	}
+}
@@ -1,68 +1,209 @@
 package auth_service
 
 import (
+	"context"
 	"errors"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
-	"golang.org/x/crypto/bcrypt"
-	"log"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/google/uuid"
+	"time"
 )
 
-func (authService *AuthService) RefreshTokens(accessToken string, refreshToken string, userAgent string, ipAddr string) (string, string, error) {
-	userID, err := authService.CheckAccessTokenValidity(accessToken)
+func (authService *AuthService) RefreshTokens(ctx context.Context, accessToken string, refreshToken string, userAgent string, ipAddr string) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RefreshTokens")
+	defer endSpan(span, &err)
+
+	newAccessToken, newRefreshToken, err := authService.refreshTokens(ctx, accessToken, refreshToken, userAgent, ipAddr)
+
+	// сессию берём из предъявленного access токена: при ошибке новой пары нет
+	userID, sessionID := authService.auditClaims(accessToken)
+	authService.audit(ctx, &audit_events.AuditEvents{
+		EventType: audit_events.EventTokensRefreshed,
+		Actor:     audit_events.ActorUser,
+		UserID:    userID,
+		SessionID: sessionID,
+		IPAddr:    ipAddr,
+		UserAgent: userAgent,
+	}, err)
+
+	return newAccessToken, newRefreshToken, err
+}
+
+func (authService *AuthService) refreshTokens(ctx context.Context, accessToken string, refreshToken string, userAgent string, ipAddr string) (string, string, error) {
+	// повторное предъявление ротированного refresh токена проверяем до access токена: украденный
+	// refresh токен обычно предъявляют, когда парный access токен уже истёк
+	refreshSessionID, _ := splitRefreshToken(refreshToken)
+	// префикс — session_id; мусор вместо UUID не должен доходить до Postgres и считаться сбоем базы
+	if refreshSessionID != "" {
+		if _, err := uuid.Parse(refreshSessionID); err != nil {
+			return "", "", apperrors.ErrInvalidToken
+		}
+		if err := authService.detectRefreshTokenReuse(ctx, refreshSessionID, refreshToken, userAgent, ipAddr); err != nil {
+			return "", "", err
+		}
+	}
+
+	userID, sessionID, err := authService.CheckAccessTokenValidity(ctx, accessToken)
 	if err != nil {
 		return "", "", err
 	}
 
-	// найти соответствующий refresh токен
-	session, err := authService.repo.GetSessionByUserID(userID)
+	// найти сессию, к которой привязан access токен
+	session, err := authService.repo.GetSessionByID(ctx, sessionID)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrUserNotFound) {
-			return "", "", apperrors.ErrUserNotFound
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return "", "", apperrors.ErrSessionNotFound
 		}
-		return "", "", apperrors.ErrCantGetSession
+		return "", "", apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+	if session.UserID != userID {
+		return "", "", apperrors.ErrInvalidToken
 	}
 
 	// проверяем на соответствие refresh токены
-	if bcrypt.CompareHashAndPassword(session.RefreshTokenHash, []byte(refreshToken)) != nil {
-		return "", "", apperrors.ErrTokensDontMatch
+	if err = checkCanceled(ctx); err != nil {
+		return "", "", err
+	}
+	start := time.Now()
+	matches := refreshTokenMatchesSession(refreshToken, session)
+	authService.observeHash(hashAlgorithmBcrypt, hashOperationCompare, start)
+	if !matches {
+		// токен с session_id уже проверен на повтор выше
+		if refreshSessionID != "" {
+			return "", "", apperrors.ErrTokensDontMatch
+		}
+		return "", "", authService.checkRefreshTokenReuse(ctx, session, refreshToken, userAgent, ipAddr)
 	}
 
-	// проверить userAgent
+	// проверить userAgent: при смене устройства завершаем сессию и сообщаем об этом
 	if userAgent != session.UserAgent {
-		if err = authService.Logout(accessToken, userID); err != nil {
+		events := authService.webhookEvents(webhook_events.EventSessionUAMismatch, userID, &webhook_events.SessionUAMismatch{
+			UserID:            userID,
+			SessionID:         sessionID,
+			ExpectedUserAgent: session.UserAgent,
+			UserAgent:         userAgent,
+			IPAddr:            ipAddr,
+		})
+		events = append(events, authService.sessionRevokedEvents(userID, sessionID, webhook_events.RevokeReasonUAMismatch)...)
+		authService.logger.WarnContext(ctx, "user agent changed on refresh, revoking session",
+			"user_id", userID,
+			"session_id", sessionID,
+			"ip_addr", ipAddr)
+		if err = authService.logout(ctx, accessToken, sessionID, events...); err != nil {
 			return "", "", err
 		}
+		return "", "", apperrors.ErrInvalidToken
 	}
 
-	// проверить userIP
+	// события уйдут в outbox вместе с ротацией токена
+	events := authService.webhookEvents(webhook_events.EventSessionRefreshed, userID, &webhook_events.SessionRefreshed{
+		UserID:    userID,
+		SessionID: sessionID,
+		UserAgent: userAgent,
+		IPAddr:    ipAddr,
+	})
 	if ipAddr != session.IPAddr {
-		go func() {
-			_, err := notifyWebhook(userID, session.IPAddr, ipAddr, authService.webhookURL)
-			if err != nil {
-				log.Printf("can't notify webhook with error: %s\n", err.Error())
-			}
-		}()
+		events = append(events, authService.webhookEvents(webhook_events.EventSessionIPChanged, userID, &webhook_events.SessionIPChanged{
+			UserID:     userID,
+			SessionID:  sessionID,
+			OriginalIP: session.IPAddr,
+			NewIP:      ipAddr,
+		})...)
 	}
 
-	// TODO
-	// тут тоже нужно старый access токен занести в black-list
-	newAccessToken, err := makeJWT(userID, authService.ttlAccessToken, authService.jwtSecretKey)
+	newAccessToken, err := makeJWT(session, authService.ttlAccessToken, authService.tokenSigner)
 	if err != nil {
-		return "", "", apperrors.ErrCantCreateTokens
+		return "", "", apperrors.Wrap(apperrors.ErrCantCreateTokens, err)
 	}
 
-	newRefreshToken, err := makeTokenInBase64()
+	if err = checkCanceled(ctx); err != nil {
+		return "", "", err
+	}
+	start = time.Now()
+	newRefreshToken, newRefreshTokenHash, err := makeRefreshToken(sessionID)
+	authService.observeHash(hashAlgorithmBcrypt, hashOperationHash, start)
 	if err != nil {
-		return "", "", apperrors.ErrCantCreateTokens
+		return "", "", apperrors.Wrap(apperrors.ErrCantCreateTokens, err)
 	}
-	newRefreshTokenHash, err := bcrypt.GenerateFromPassword([]byte(newRefreshToken), bcrypt.DefaultCost)
+
+	// ротируем refresh токен, старый уходит в историю семейства
+	err = authService.repo.RotateRefreshToken(ctx, sessionID, refreshTokenDigest(refreshToken), string(session.RefreshTokenHash), string(newRefreshTokenHash), events...)
 	if err != nil {
-		return "", "", apperrors.ErrCantCreateTokens
+		// этот же refresh токен только что ротировали параллельным запросом
+		if errors.Is(err, apperrors.ErrRefreshTokenReused) {
+			return "", "", authService.revokeTokenFamily(ctx, session, userAgent, ipAddr)
+		}
+		return "", "", apperrors.Wrap(apperrors.ErrCantUpdateTokens, err)
 	}
 
-	if err = authService.repo.UpdateRefreshTokenByUserID(userID, string(newRefreshTokenHash)); err != nil {
-		return "", "", apperrors.ErrCantUpdateTokens
+	// старый access токен больше не нужен; refresh токен уже ротирован, поэтому сбой black-list
+	// не отменяет выдачу новой пары — старый токен доживёт до exp
+	if claims, err := claimsFromAccessToken(accessToken, authService.tokenSigner); err == nil {
+		if err = authService.revokeToken(ctx, claims); err != nil {
+			authService.logger.ErrorContext(ctx, "can't revoke rotated access token",
+				"session_id", sessionID,
+				"error", err,
+				"cause", apperrors.Cause(err))
+		}
 	}
 
 	return newAccessToken, newRefreshToken, nil
 }
+
+// detectRefreshTokenReuse завершает сессию, если refresh токен уже был ротирован. Нужны только
+// session_id из самого токена и sha256 дайджест, поэтому проверка не требует access токена и bcrypt
+func (authService *AuthService) detectRefreshTokenReuse(ctx context.Context, sessionID string, refreshToken string, userAgent string, ipAddr string) error {
+	isRotated, err := authService.repo.IsRefreshTokenRotated(ctx, sessionID, refreshTokenDigest(refreshToken))
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+	if !isRotated {
+		return nil
+	}
+
+	session, err := authService.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		// сессию уже завершили, семейство отозвано
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return apperrors.ErrRefreshTokenReused
+		}
+		return apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+	return authService.revokeTokenFamily(ctx, session, userAgent, ipAddr)
+}
+
+// checkRefreshTokenReuse отличает просто неверный refresh токен от повторно предъявленного уже ротированного
+func (authService *AuthService) checkRefreshTokenReuse(ctx context.Context, session *sessions.Sessions, refreshToken string, userAgent string, ipAddr string) error {
+	isRotated, err := authService.repo.IsRefreshTokenRotated(ctx, session.SessionID, refreshTokenDigest(refreshToken))
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+	if !isRotated {
+		return apperrors.ErrTokensDontMatch
+	}
+	return authService.revokeTokenFamily(ctx, session, userAgent, ipAddr)
+}
+
+// revokeTokenFamily завершает сессию, refresh токен которой был использован повторно:
+// неизвестно, у кого из двоих настоящий клиент, поэтому отзываем всё семейство
+func (authService *AuthService) revokeTokenFamily(ctx context.Context, session *sessions.Sessions, userAgent string, ipAddr string) error {
+	authService.logger.WarnContext(ctx, "refresh token reuse detected, revoking session",
+		"user_id", session.UserID,
+		"session_id", session.SessionID,
+		"ip_addr", ipAddr)
+
+	events := authService.webhookEvents(webhook_events.EventRefreshReuseDetected, session.UserID, &webhook_events.RefreshReuseDetected{
+		UserID:    session.UserID,
+		SessionID: session.SessionID,
+		UserAgent: userAgent,
+		IPAddr:    ipAddr,
+	})
+	events = append(events, authService.sessionRevokedEvents(session.UserID, session.SessionID, webhook_events.RevokeReasonRefreshReuse)...)
+	if err := authService.revokeSession(ctx, session.SessionID, events...); err != nil {
+		return err
+	}
+
+	return apperrors.ErrRefreshTokenReused
+}
@@ -0,0 +1,69 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
+	"github.com/Turalchik/authentication-service/internal/password_hasher"
+	"github.com/google/uuid"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	minPasswordLength = 8
+	// argon2 принимает пароль любой длины, но хешировать мегабайты по запросу анонима не стоит
+	maxPasswordLength = 1024
+)
+
+// Register создаёт учётную запись пользователя и возвращает её user_id
+func (authService *AuthService) Register(ctx context.Context, email string, password string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer endSpan(span, &err)
+
+	email, err = normalizeEmail(email)
+	if err != nil {
+		return "", err
+	}
+
+	if utf8.RuneCountInString(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", apperrors.ErrWeakPassword
+	}
+
+	if err = checkCanceled(ctx); err != nil {
+		return "", err
+	}
+	start := time.Now()
+	passwordHash, err := password_hasher.GenerateFromPassword([]byte(password))
+	authService.observeHash(hashAlgorithmArgon2id, hashOperationHash, start)
+	if err != nil {
+		return "", apperrors.Wrap(apperrors.ErrCantCreateUser, err)
+	}
+
+	user := &users.Users{
+		UserID:       uuid.NewString(),
+		Email:        email,
+		PasswordHash: passwordHash,
+	}
+	if err = authService.repo.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, apperrors.ErrUserAlreadyExists) {
+			return "", apperrors.ErrUserAlreadyExists
+		}
+		return "", apperrors.Wrap(apperrors.ErrCantCreateUser, err)
+	}
+
+	return user.UserID, nil
+}
+
+// normalizeEmail принимает только голый адрес (без имени в кавычках) и приводит его к нижнему регистру
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", apperrors.ErrInvalidEmail
+	}
+	return strings.ToLower(email), nil
+}
@@ -1,10 +1,28 @@
 package auth_service
 
-import "github.com/Turalchik/authentication-service/internal/entities/sessions"
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/user_totp"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+)
 
 type Repo interface {
-	GetSessionByUserID(userID string) (*sessions.Sessions, error)
-	CreateSession(session *sessions.Sessions) error
-	DeleteSessionByUserID(userID string) error
-	UpdateRefreshTokenByUserID(userID string, newRefreshTokenHash string) error
+	GetSessionByID(ctx context.Context, sessionID string) (*sessions.Sessions, error)
+	ListSessionsByUserID(ctx context.Context, userID string) ([]*sessions.Sessions, error)
+	CreateSession(ctx context.Context, session *sessions.Sessions, events ...*webhook_events.WebhookEvents) error
+	DeleteSessionByID(ctx context.Context, sessionID string, events ...*webhook_events.WebhookEvents) error
+	DeleteSessionsByIDs(ctx context.Context, userID string, sessionIDs []string, events ...*webhook_events.WebhookEvents) error
+	RotateRefreshToken(ctx context.Context, sessionID string, usedRefreshTokenDigest string, oldRefreshTokenHash string, newRefreshTokenHash string, events ...*webhook_events.WebhookEvents) error
+	IsRefreshTokenRotated(ctx context.Context, sessionID string, refreshTokenDigest string) (bool, error)
+	GetClientByID(ctx context.Context, clientID string) (*clients.Clients, error)
+	CreateUser(ctx context.Context, user *users.Users) error
+	GetUserByEmail(ctx context.Context, email string) (*users.Users, error)
+	GetUserByID(ctx context.Context, userID string) (*users.Users, error)
+	GetUserTOTP(ctx context.Context, userID string) (*user_totp.UserTOTP, error)
+	SaveUserTOTP(ctx context.Context, userTOTP *user_totp.UserTOTP) error
+	ConfirmUserTOTP(ctx context.Context, userID string, step int64) error
+	UseTOTPStep(ctx context.Context, userID string, step int64) error
 }
@@ -0,0 +1,40 @@
+package auth_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+)
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме текущей. Как и в revokeSession, сначала
+// отзываются access токены: если отзыв не удался, сессии остаются на месте и повтор найдёт их снова.
+// Удаление и события webhook пишутся одной транзакцией.
+func (authService *AuthService) RevokeOtherSessions(ctx context.Context, userID string, currentSessionID string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeOtherSessions")
+	defer endSpan(span, &err)
+	defer func() { authService.observeTokens(tokenOperationRevoked, err) }()
+
+	userSessions, err := authService.repo.ListSessionsByUserID(ctx, userID)
+	if err != nil {
+		return apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+
+	var sessionIDs []string
+	var events []*webhook_events.WebhookEvents
+	for _, session := range userSessions {
+		if session.SessionID == currentSessionID {
+			continue
+		}
+		if err = authService.tokenRevocationStore.Revoke(ctx, sessionRevocationKey(session.SessionID), authService.ttlAccessToken); err != nil {
+			return apperrors.Wrap(apperrors.ErrCantRevokeToken, err)
+		}
+		sessionIDs = append(sessionIDs, session.SessionID)
+		events = append(events, authService.sessionRevokedEvents(userID, session.SessionID, webhook_events.RevokeReasonLogoutOthers)...)
+	}
+
+	if err = authService.repo.DeleteSessionsByIDs(ctx, userID, sessionIDs, events...); err != nil {
+		return apperrors.Wrap(apperrors.ErrCantDeleteSession, err)
+	}
+
+	return nil
+}
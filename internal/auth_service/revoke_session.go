@@ -0,0 +1,50 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/google/uuid"
+)
+
+// RevokeSession завершает одну из сессий пользователя вместе с её access токенами
+func (authService *AuthService) RevokeSession(ctx context.Context, userID string, sessionID string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeSession")
+	defer endSpan(span, &err)
+	defer func() { authService.observeTokens(tokenOperationRevoked, err) }()
+
+	// session_id приходит из URL: не-UUID Postgres не приведёт к uuid, а такой сессии всё равно нет
+	if _, err = uuid.Parse(sessionID); err != nil {
+		return apperrors.ErrSessionNotFound
+	}
+
+	session, err := authService.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return apperrors.ErrSessionNotFound
+		}
+		return apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+
+	// чужие сессии не показываем и не трогаем
+	if session.UserID != userID {
+		return apperrors.ErrSessionNotFound
+	}
+
+	return authService.revokeSession(ctx, sessionID, authService.sessionRevokedEvents(userID, sessionID, webhook_events.RevokeReasonRevokedByUser)...)
+}
+
+// revokeSession отзывает все access токены сессии и удаляет её refresh токен; события webhook
+// пишутся в outbox в одной транзакции с удалением
+func (authService *AuthService) revokeSession(ctx context.Context, sessionID string, events ...*webhook_events.WebhookEvents) error {
+	if err := authService.tokenRevocationStore.Revoke(ctx, sessionRevocationKey(sessionID), authService.ttlAccessToken); err != nil {
+		return apperrors.Wrap(apperrors.ErrCantRevokeToken, err)
+	}
+
+	if err := authService.repo.DeleteSessionByID(ctx, sessionID, events...); err != nil {
+		return apperrors.Wrap(apperrors.ErrCantDeleteSession, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	tokenTypeHintAccessToken  = "access_token"
+	tokenTypeHintRefreshToken = "refresh_token"
+)
+
+// RevokeToken — RFC 7009: отзывает access или refresh токен по запросу аутентифицированного клиента.
+// Отозвать можно только токен, выданный этому клиенту (RFC 7009 §2.1). token_type_hint лишь задаёт
+// порядок проверки. Неизвестный, чужой, просроченный или уже отозванный токен ошибкой не считается:
+// ответ тот же, что и при отзыве, чтобы по нему нельзя было проверять чужие токены.
+func (authService *AuthService) RevokeToken(ctx context.Context, clientID string, clientSecret string, token string, tokenTypeHint string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RevokeToken")
+	defer endSpan(span, &err)
+	defer func() { authService.observeTokens(tokenOperationRevoked, err) }()
+
+	if _, err = authService.authenticateClient(ctx, clientID, clientSecret); err != nil {
+		return err
+	}
+
+	revokers := []func(ctx context.Context, clientID string, token string) (bool, error){
+		authService.revokeAccessToken,
+		authService.revokeRefreshToken,
+	}
+	if tokenTypeHint == tokenTypeHintRefreshToken {
+		revokers[0], revokers[1] = revokers[1], revokers[0]
+	}
+
+	for _, revoke := range revokers {
+		revoked, err := revoke(ctx, clientID, token)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// revokeAccessToken заносит access токен в black-list. false — токен не является нашим валидным access токеном
+// или выдан не этому клиенту.
+func (authService *AuthService) revokeAccessToken(ctx context.Context, clientID string, accessToken string) (bool, error) {
+	claims, err := claimsFromAccessToken(accessToken, authService.tokenSigner)
+	if err != nil {
+		return false, nil
+	}
+
+	// токен, выданный другому клиенту или пользователю напрямую (без client_id), отзывать нельзя
+	if claims.ClientID != clientID {
+		return false, nil
+	}
+
+	if err = authService.revokeToken(ctx, claims); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// revokeRefreshToken завершает сессию, которой принадлежит refresh токен. false — сессия не найдена,
+// открыта не этим клиентом или токен не её.
+func (authService *AuthService) revokeRefreshToken(ctx context.Context, clientID string, refreshToken string) (bool, error) {
+	// access токен тоже содержит точки, поэтому префикс обязан быть session_id
+	sessionID, _ := splitRefreshToken(refreshToken)
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return false, nil
+	}
+
+	session, err := authService.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			return false, nil
+		}
+		return false, apperrors.Wrap(apperrors.ErrCantGetSession, err)
+	}
+
+	// сессию, открытую другим клиентом или пользователем напрямую, этот клиент завершить не может
+	if session.ClientID != clientID {
+		return false, nil
+	}
+
+	if err = checkCanceled(ctx); err != nil {
+		return false, err
+	}
+	start := time.Now()
+	matches := refreshTokenMatchesSession(refreshToken, session)
+	authService.observeHash(hashAlgorithmBcrypt, hashOperationCompare, start)
+	if !matches {
+		return false, nil
+	}
+
+	if err = authService.revokeSession(ctx, sessionID, authService.sessionRevokedEvents(session.UserID, sessionID, webhook_events.RevokeReasonTokenRevoked)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,6 @@
+package auth_service
+
+type SecretBox interface {
+	Seal(plaintext []byte, additionalData []byte) ([]byte, error)
+	Open(ciphertext []byte, additionalData []byte) ([]byte, error)
+}
@@ -1,8 +1,18 @@
 package auth_service
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
+// TokenRevocationStore — black-list: ключи jti:<jti> для отдельных токенов и session:<session_id> для сессий
 type TokenRevocationStore interface {
-	Revoke(token string, ttl time.Duration) error
-	IsRevoked(token string) (bool, error)
+	Revoke(ctx context.Context, token string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, token string) (bool, error)
+	// Increment увеличивает счётчик по ключу и продлевает его на ttl, возвращает новое значение.
+	// Им считаются попытки ввести TOTP код по одному MFA токену (ключи mfa_attempts:<jti>) и неудачные
+	// TOTP коды пользователя в форме входа /oauth2/authorize (ключи totp_failures:<user_id>)
+	Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Delete удаляет ключ; им сбрасывается счётчик неудачных TOTP кодов после верного кода
+	Delete(ctx context.Context, key string) error
 }
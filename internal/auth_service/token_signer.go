@@ -0,0 +1,12 @@
+package auth_service
+
+import (
+	"github.com/Turalchik/authentication-service/internal/token_signer"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+	Verify(tokenStr string, claims jwt.Claims) error
+	JWKS() token_signer.JWKS
+}
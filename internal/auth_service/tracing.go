@@ -0,0 +1,23 @@
+package auth_service
+
+import (
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Turalchik/authentication-service/internal/auth_service")
+
+// endSpan завершает span метода AuthService с исходом, как в журнале аутентификации.
+// Ошибкой span отмечается только при отказе: запрос второго фактора — обычный шаг входа.
+func endSpan(span trace.Span, err *error) {
+	result, errorCode := outcome(*err)
+	span.SetAttributes(attribute.String("auth.outcome", result))
+	if result == audit_events.OutcomeFailure {
+		span.SetAttributes(attribute.String("auth.error_code", errorCode))
+		tracing.RecordError(span, *err)
+	}
+	span.End()
+}
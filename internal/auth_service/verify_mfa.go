@@ -0,0 +1,87 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"strings"
+)
+
+// VerifyMFA обменивает MFA токен и TOTP код на пару токенов. MFA токен одноразовый и допускает
+// не больше maxMFAAttempts попыток ввести код, а amr новой сессии — методы первого фактора плюс "otp".
+func (authService *AuthService) VerifyMFA(ctx context.Context, mfaToken string, code string, userAgent string, ipAddr string) (_ string, _ string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthService.VerifyMFA")
+	defer endSpan(span, &err)
+
+	accessToken, refreshToken, err := authService.verifyMFA(ctx, mfaToken, code, userAgent, ipAddr)
+
+	// user_id берём из MFA токена — он известен и при неверном коде
+	userID, _ := authService.auditClaims(mfaToken)
+	sessionID, _ := splitRefreshToken(refreshToken)
+	authService.audit(ctx, &audit_events.AuditEvents{
+		EventType: audit_events.EventMFAVerify,
+		Actor:     audit_events.ActorUser,
+		UserID:    userID,
+		SessionID: sessionID,
+		IPAddr:    ipAddr,
+		UserAgent: userAgent,
+	}, err)
+
+	return accessToken, refreshToken, err
+}
+
+func (authService *AuthService) verifyMFA(ctx context.Context, mfaToken string, code string, userAgent string, ipAddr string) (string, string, error) {
+	claims, err := claimsFromAccessToken(mfaToken, authService.tokenSigner)
+	if err != nil || claims.TokenUse != tokenUseMFA || claims.UserID == "" {
+		return "", "", apperrors.ErrInvalidToken
+	}
+
+	isRevoked, err := authService.isTokenRevoked(ctx, mfaToken, claims)
+	if err != nil {
+		return "", "", apperrors.Wrap(apperrors.ErrCantCheckRevocationToken, err)
+	}
+	if isRevoked {
+		return "", "", apperrors.ErrInvalidToken
+	}
+
+	if err = authService.countMFAAttempt(ctx, claims); err != nil {
+		return "", "", err
+	}
+
+	userTOTP, err := authService.repo.GetUserTOTP(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrTOTPNotFound) {
+			return "", "", apperrors.ErrInvalidToken
+		}
+		return "", "", apperrors.Wrap(apperrors.ErrCantGetTOTP, err)
+	}
+	if userTOTP.ConfirmedAt == nil {
+		return "", "", apperrors.ErrInvalidToken
+	}
+
+	step, err := authService.checkTOTPCode(userTOTP, code)
+	if err != nil {
+		return "", "", err
+	}
+
+	// код из уже принятого окна — повтор перехваченного кода
+	if err = authService.repo.UseTOTPStep(ctx, claims.UserID, step); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidTOTPCode) {
+			return "", "", apperrors.ErrInvalidTOTPCode
+		}
+		return "", "", apperrors.Wrap(apperrors.ErrCantSaveTOTP, err)
+	}
+
+	if err = authService.revokeToken(ctx, claims); err != nil {
+		return "", "", err
+	}
+
+	return authService.openSession(ctx, &sessions.Sessions{
+		UserID:    claims.UserID,
+		UserAgent: userAgent,
+		IPAddr:    ipAddr,
+		AMR:       strings.Join(append(claims.AMR, amrOTP), " "),
+	})
+}
@@ -0,0 +1,55 @@
+package authorization_code_store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+type AuthorizationCodeStore struct {
+	client    *redis.Client
+	keyPrefix string
+
+	// timeout ограничивает каждую операцию Redis; 0 — только дедлайн вызывающего
+	timeout time.Duration
+}
+
+func NewAuthorizationCodeStore(client *redis.Client, keyPrefix string, timeout time.Duration) *AuthorizationCodeStore {
+	return &AuthorizationCodeStore{
+		client:    client,
+		keyPrefix: keyPrefix,
+		timeout:   timeout,
+	}
+}
+
+var tracer = otel.Tracer("github.com/Turalchik/authentication-service/internal/authorization_code_store")
+
+// start открывает span операции Redis и ограничивает её timeout; done вызывается через defer с ошибкой операции
+func (codeStore *AuthorizationCodeStore) start(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	ctx, span := tracer.Start(ctx, "redis.authorization_code."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(operation)))
+
+	cancel := context.CancelFunc(func() {})
+	if codeStore.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, codeStore.timeout)
+	}
+
+	return ctx, func(err *error) {
+		cancel()
+		tracing.End(span, err)
+	}
+}
+
+// key — ключ Redis для кода. Сам код — bearer-учётка, поэтому в ключ попадает только его sha256:
+// по дампу Redis или списку ключей код не восстановить и не обменять на токены.
+func (codeStore *AuthorizationCodeStore) key(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return codeStore.keyPrefix + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,56 @@
+package authorization_code_store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizationCodeStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	codeStore := NewAuthorizationCodeStore(client, "authcode:", 0)
+
+	authorizationCode := &authorization_codes.AuthorizationCodes{
+		ClientID:      "spa",
+		UserID:        "u",
+		RedirectURI:   "https://app.example.com/callback",
+		Scope:         "profile",
+		CodeChallenge: "challenge",
+	}
+
+	t.Run("code is stored under its hash", func(t *testing.T) {
+		require.NoError(t, codeStore.Save(t.Context(), "secret-code", authorizationCode, time.Minute))
+
+		keys := server.Keys()
+		require.Len(t, keys, 1)
+		assert.True(t, strings.HasPrefix(keys[0], "authcode:"))
+		assert.NotContains(t, keys[0], "secret-code")
+		assert.Equal(t, time.Minute, server.TTL(keys[0]))
+	})
+
+	t.Run("code is consumed once", func(t *testing.T) {
+		got, err := codeStore.Consume(t.Context(), "secret-code")
+		require.NoError(t, err)
+		assert.Equal(t, authorizationCode, got)
+
+		_, err = codeStore.Consume(t.Context(), "secret-code")
+		assert.ErrorIs(t, err, apperrors.ErrAuthorizationCodeNotFound)
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		require.NoError(t, codeStore.Save(t.Context(), "short-lived", authorizationCode, time.Minute))
+		server.FastForward(time.Minute)
+
+		_, err := codeStore.Consume(t.Context(), "short-lived")
+		assert.ErrorIs(t, err, apperrors.ErrAuthorizationCodeNotFound)
+	})
+}
@@ -0,0 +1,31 @@
+package authorization_code_store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"github.com/go-redis/redis/v8"
+)
+
+// Consume — атомарно читает и удаляет код (GETDEL), поэтому обменять его на токены можно только один раз
+func (codeStore *AuthorizationCodeStore) Consume(ctx context.Context, code string) (_ *authorization_codes.AuthorizationCodes, err error) {
+	ctx, done := codeStore.start(ctx, "consume")
+	defer done(&err)
+
+	key := codeStore.key(code)
+	value, err := codeStore.client.GetDel(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, apperrors.ErrAuthorizationCodeNotFound
+		}
+		return nil, err
+	}
+
+	authorizationCode := &authorization_codes.AuthorizationCodes{}
+	if err = json.Unmarshal(value, authorizationCode); err != nil {
+		return nil, err
+	}
+	return authorizationCode, nil
+}
@@ -0,0 +1,22 @@
+package authorization_code_store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"time"
+)
+
+// Save — кладёт в Redis ключ <prefix><sha256(code) в hex> с данными кода и TTL
+func (codeStore *AuthorizationCodeStore) Save(ctx context.Context, code string, authorizationCode *authorization_codes.AuthorizationCodes, ttl time.Duration) (err error) {
+	ctx, done := codeStore.start(ctx, "save")
+	defer done(&err)
+
+	value, err := json.Marshal(authorizationCode)
+	if err != nil {
+		return err
+	}
+
+	key := codeStore.key(code)
+	return codeStore.client.Set(ctx, key, value, ttl).Err()
+}
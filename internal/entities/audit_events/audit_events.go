@@ -0,0 +1,78 @@
+package audit_events
+
+import "time"
+
+// типы событий журнала аутентификации
+const (
+	EventLogin           = "login"
+	EventMFAVerify       = "mfa.verify"
+	EventTokensIssued    = "tokens.issued"
+	EventTokensRefreshed = "tokens.refreshed"
+	EventLogout          = "logout"
+)
+
+var EventTypes = []string{
+	EventLogin,
+	EventMFAVerify,
+	EventTokensIssued,
+	EventTokensRefreshed,
+	EventLogout,
+}
+
+// кто совершил действие: сам пользователь своими учётными данными или токенами,
+// доверенный внутренний сервис (выдача токенов по user_id) или OAuth клиент — "client:<client_id>"
+const (
+	ActorUser          = "user"
+	ActorTrustedCaller = "trusted_caller"
+	ActorClientPrefix  = "client:"
+)
+
+// исход действия; при failure в ErrorCode — код ошибки
+const (
+	OutcomeSuccess     = "success"
+	OutcomeFailure     = "failure"
+	OutcomeMFARequired = "mfa_required"
+)
+
+type AuditEvents struct {
+	AuditID   int64     `db:"audit_id" json:"audit_id"`
+	EventType string    `db:"event_type" json:"event_type"`
+	Actor     string    `db:"actor" json:"actor"`
+	UserID    string    `db:"user_id" json:"user_id"`
+	SessionID string    `db:"session_id" json:"session_id"`
+	IPAddr    string    `db:"ip_addr" json:"ip_addr"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+	Outcome   string    `db:"outcome" json:"outcome"`
+	ErrorCode string    `db:"error_code" json:"error_code"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	PrevHash  string    `db:"prev_hash" json:"prev_hash"`
+	Hash      string    `db:"hash" json:"hash"`
+}
+
+// AuditCheckpoints — контрольная точка цепочки: Signature — JWT с audit_id и hash записи,
+// подписанный ключом подписи сервиса (см. audit_chain.CheckpointClaims)
+type AuditCheckpoints struct {
+	CheckpointID int64     `db:"checkpoint_id" json:"checkpoint_id"`
+	AuditID      int64     `db:"audit_id" json:"audit_id"`
+	Hash         string    `db:"hash" json:"hash"`
+	Signature    string    `db:"signature" json:"signature"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// ExportRecord — строка выгрузки журнала (JSON Lines): запись или контрольная точка.
+// Контрольная точка идёт сразу после записи, которую закрепляет.
+type ExportRecord struct {
+	Event      *AuditEvents      `json:"event,omitempty"`
+	Checkpoint *AuditCheckpoints `json:"checkpoint,omitempty"`
+}
+
+// AuditEventFilter — выборка журнала, новые записи первыми. Пустые поля не фильтруют;
+// BeforeID — курсор: вернуть записи старше записи с этим audit_id.
+type AuditEventFilter struct {
+	From       time.Time
+	To         time.Time
+	UserID     string
+	EventTypes []string
+	BeforeID   int64
+	Limit      int
+}
@@ -0,0 +1,23 @@
+package authorization_codes
+
+// AuthorizationCodes — то, что сервис помнит об authorization code до его обмена на токены.
+// RedirectURI — значение из запроса /oauth2/authorize (может быть пустым), при обмене оно должно совпасть.
+// AMR — способы входа через пробел (RFC 8176), они переходят в сессию, открытую по коду.
+type AuthorizationCodes struct {
+	ClientID      string `json:"client_id"`
+	UserID        string `json:"user_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	CodeChallenge string `json:"code_challenge"`
+	AMR           string `json:"amr"`
+}
+
+// AuthorizationRequest — параметры запроса /oauth2/authorize (RFC 6749, раздел 4.1.1, и RFC 7636)
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
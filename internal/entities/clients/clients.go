@@ -0,0 +1,15 @@
+package clients
+
+import "time"
+
+// Clients — зарегистрированный OAuth клиент. Scopes, GrantTypes и RedirectURIs — списки через пробел.
+// У публичного клиента (SPA, мобильное приложение) секрета нет, и ClientSecretHash пустой.
+type Clients struct {
+	ClientID         string    `db:"client_id" json:"client_id"`
+	ClientSecretHash []byte    `db:"client_secret_hash" json:"client_secret_hash"`
+	Name             string    `db:"name" json:"name"`
+	Scopes           string    `db:"scopes" json:"scopes"`
+	GrantTypes       string    `db:"grant_types" json:"grant_types"`
+	RedirectURIs     string    `db:"redirect_uris" json:"redirect_uris"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+}
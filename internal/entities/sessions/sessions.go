@@ -1,8 +1,16 @@
 package sessions
 
+import "time"
+
 type Sessions struct {
-	UserID           string `db:"user_id" json:"user_id"`
-	RefreshTokenHash []byte `db:"refresh_token_hash" json:"refresh_token_hash"`
-	UserAgent        string `db:"user_agent" json:"user_agent"`
-	IPAddr           string `db:"ip_addr" json:"ip_addr"`
+	SessionID        string    `db:"session_id" json:"session_id"`
+	UserID           string    `db:"user_id" json:"user_id"`
+	RefreshTokenHash []byte    `db:"refresh_token_hash" json:"refresh_token_hash"`
+	UserAgent        string    `db:"user_agent" json:"user_agent"`
+	IPAddr           string    `db:"ip_addr" json:"ip_addr"`
+	ClientID         string    `db:"client_id" json:"client_id"`
+	Scope            string    `db:"scope" json:"scope"`
+	AMR              string    `db:"amr" json:"amr"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	LastUsedAt       time.Time `db:"last_used_at" json:"last_used_at"`
 }
@@ -0,0 +1,14 @@
+package token_introspection
+
+// TokenIntrospection — ответ RFC 7662. Для неактивного токена заполняется только Active.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	Subject   string `json:"sub,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	TokenID   string `json:"jti,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+}
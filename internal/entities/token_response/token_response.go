@@ -0,0 +1,10 @@
+package token_response
+
+// TokenResponse — успешный ответ эндпоинта /oauth2/token (RFC 6749, раздел 5.1)
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
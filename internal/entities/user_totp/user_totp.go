@@ -0,0 +1,12 @@
+package user_totp
+
+import "time"
+
+// UserTOTP — привязанный к пользователю TOTP секрет. Привязка действует только после подтверждения (ConfirmedAt).
+type UserTOTP struct {
+	UserID           string     `db:"user_id" json:"user_id"`
+	SecretCiphertext []byte     `db:"secret_ciphertext" json:"secret_ciphertext"`
+	ConfirmedAt      *time.Time `db:"confirmed_at" json:"confirmed_at"`
+	LastUsedStep     int64      `db:"last_used_step" json:"last_used_step"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package users
+
+import "time"
+
+// Users — учётная запись пользователя. Email хранится в нижнем регистре, пароль — argon2id хешем в PHC формате.
+type Users struct {
+	UserID       string    `db:"user_id" json:"user_id"`
+	Email        string    `db:"email" json:"email"`
+	PasswordHash string    `db:"password_hash" json:"password_hash"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
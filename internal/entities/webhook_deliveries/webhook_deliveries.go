@@ -0,0 +1,48 @@
+package webhook_deliveries
+
+import "time"
+
+// статусы доставки
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	// StatusDead — попытки доставки исчерпаны, доставка больше не повторяется (dead letter)
+	StatusDead = "dead"
+)
+
+// WebhookDeliveries — доставка одного события одной подписке; EventType берётся из webhook_outbox
+type WebhookDeliveries struct {
+	DeliveryID     string     `db:"delivery_id" json:"delivery_id"`
+	EventID        string     `db:"event_id" json:"event_id"`
+	SubscriptionID string     `db:"subscription_id" json:"subscription_id"`
+	EventType      string     `db:"event_type" json:"event_type"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError      string     `db:"last_error" json:"last_error"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at"`
+}
+
+// PendingDelivery — доставка, которую dispatcher забрал на отправку, вместе с телом события и адресом подписки
+type PendingDelivery struct {
+	WebhookDeliveries
+	Payload []byte `db:"payload" json:"payload"`
+	URL     string `db:"url" json:"url"`
+	Secret  string `db:"secret" json:"secret"`
+	// TraceParent — W3C traceparent запроса, породившего событие; пустой, если трассы не было
+	TraceParent string `db:"trace_parent" json:"trace_parent"`
+	// LeasedUntil — next_attempt_at, выставленный при захвате. Результат попытки сохраняется, только пока
+	// next_attempt_at не изменился: иначе доставку после истечения lease уже забрал другой экземпляр
+	LeasedUntil time.Time `db:"leased_until" json:"-"`
+}
+
+// DeliveryAttempts — запись журнала попыток. StatusCode 0 — ответа не было (ошибка соединения или таймаут).
+type DeliveryAttempts struct {
+	AttemptID   int64     `db:"attempt_id" json:"attempt_id"`
+	DeliveryID  string    `db:"delivery_id" json:"delivery_id"`
+	AttemptedAt time.Time `db:"attempted_at" json:"attempted_at"`
+	StatusCode  int       `db:"status_code" json:"status_code"`
+	Error       string    `db:"error" json:"error"`
+	DurationMS  int64     `db:"duration_ms" json:"duration_ms"`
+}
@@ -0,0 +1,104 @@
+package webhook_events
+
+import "time"
+
+// каталог событий webhook
+const (
+	EventSessionCreated       = "session.created"
+	EventSessionRefreshed     = "session.refreshed"
+	EventSessionRevoked       = "session.revoked"
+	EventSessionUAMismatch    = "session.ua_mismatch"
+	EventSessionIPChanged     = "session.ip_changed"
+	EventRefreshReuseDetected = "refresh.reuse_detected"
+)
+
+// EventTypes — все типы событий, по ним проверяется фильтр подписчика
+var EventTypes = []string{
+	EventSessionCreated,
+	EventSessionRefreshed,
+	EventSessionRevoked,
+	EventSessionUAMismatch,
+	EventSessionIPChanged,
+	EventRefreshReuseDetected,
+}
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	CloudEventsContentType = "application/cloudevents+json"
+	// DataVersion — версия схемы data; меняется только при несовместимом изменении полей
+	DataVersion = "1"
+)
+
+// CloudEvent — конверт события в structured режиме CloudEvents 1.0 (JSON).
+// Subject — user_id, data — одна из структур ниже в зависимости от Type.
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	DataVersion     string      `json:"dataversion"`
+	Data            interface{} `json:"data"`
+}
+
+// причины завершения сессии в session.revoked
+const (
+	RevokeReasonLogout        = "logout"
+	RevokeReasonLogoutOthers  = "logout_others"
+	RevokeReasonRevokedByUser = "revoked_by_user"
+	RevokeReasonTokenRevoked  = "token_revoked"
+	RevokeReasonUAMismatch    = "ua_mismatch"
+	RevokeReasonRefreshReuse  = "refresh_reuse"
+)
+
+// SessionCreated — data события session.created
+type SessionCreated struct {
+	UserID    string   `json:"user_id"`
+	SessionID string   `json:"session_id"`
+	UserAgent string   `json:"user_agent"`
+	IPAddr    string   `json:"ip_addr"`
+	ClientID  string   `json:"client_id,omitempty"`
+	AMR       []string `json:"amr,omitempty"`
+}
+
+// SessionRefreshed — data события session.refreshed
+type SessionRefreshed struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+	UserAgent string `json:"user_agent"`
+	IPAddr    string `json:"ip_addr"`
+}
+
+// SessionRevoked — data события session.revoked
+type SessionRevoked struct {
+	UserID    string `json:"user_id,omitempty"`
+	SessionID string `json:"session_id"`
+	Reason    string `json:"reason"`
+}
+
+// SessionUAMismatch — data события session.ua_mismatch: refresh пришёл с другого устройства, сессия завершена
+type SessionUAMismatch struct {
+	UserID            string `json:"user_id"`
+	SessionID         string `json:"session_id"`
+	ExpectedUserAgent string `json:"expected_user_agent"`
+	UserAgent         string `json:"user_agent"`
+	IPAddr            string `json:"ip_addr"`
+}
+
+// SessionIPChanged — data события session.ip_changed
+type SessionIPChanged struct {
+	UserID     string `json:"user_id"`
+	SessionID  string `json:"session_id"`
+	OriginalIP string `json:"original_ip"`
+	NewIP      string `json:"new_ip"`
+}
+
+// RefreshReuseDetected — data события refresh.reuse_detected: предъявлен уже ротированный refresh токен
+type RefreshReuseDetected struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+	UserAgent string `json:"user_agent"`
+	IPAddr    string `json:"ip_addr"`
+}
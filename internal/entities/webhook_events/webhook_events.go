@@ -0,0 +1,12 @@
+package webhook_events
+
+import "time"
+
+// WebhookEvents — событие в outbox. Payload — готовое JSON тело запроса к получателю (CloudEvent).
+// Состояние доставки каждой подписке хранится в webhook_deliveries.
+type WebhookEvents struct {
+	EventID   string    `db:"event_id" json:"event_id"`
+	EventType string    `db:"event_type" json:"event_type"`
+	Payload   []byte    `db:"payload" json:"payload"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package webhook_subscriptions
+
+import "time"
+
+// статусы подписки: приостановленной события по-прежнему ставятся в доставку, но отправляются только после возобновления
+const (
+	StatusActive = "active"
+	StatusPaused = "paused"
+)
+
+// LegacySubscriptionID — подписка для WEBHOOK_URL из конфига. Миграция на подписки создаёт её приостановленной
+// и без URL, если в outbox оставались недоставленные события; при старте с WEBHOOK_URL сервис её заполняет.
+const LegacySubscriptionID = "00000000-0000-0000-0000-000000000001"
+
+// WebhookSubscriptions — получатель webhooks. EventTypes — типы событий через пробел, пустая строка — все.
+type WebhookSubscriptions struct {
+	SubscriptionID string    `db:"subscription_id" json:"subscription_id"`
+	URL            string    `db:"url" json:"url"`
+	Secret         string    `db:"secret" json:"secret"`
+	EventTypes     string    `db:"event_types" json:"event_types"`
+	Status         string    `db:"status" json:"status"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookSubscriptionUpdate — изменение подписки, nil поля не меняются
+type WebhookSubscriptionUpdate struct {
+	URL        *string
+	Secret     *string
+	EventTypes *[]string
+	Status     *string
+}
@@ -0,0 +1,14 @@
+package grpc_handlers
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+)
+
+type AuthService interface {
+	CreateTokens(ctx context.Context, userID string, userAgent string, userIP string) (string, string, error)
+	RefreshTokens(ctx context.Context, accessToken string, refreshToken string, userAgent string, userIP string) (string, string, error)
+	Logout(ctx context.Context, accessToken string, sessionID string, userAgent string, userIP string) error
+	CheckAccessTokenValidity(ctx context.Context, accessToken string) (string, string, error)
+	ListSessions(ctx context.Context, userID string) ([]*sessions.Sessions, error)
+}
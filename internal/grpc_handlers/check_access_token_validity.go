@@ -0,0 +1,19 @@
+package grpc_handlers
+
+import (
+	"context"
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+)
+
+// CheckAccessTokenValidity проверяет access токен и возвращает его владельца и сессию
+func (grpcHandler *GrpcHandler) CheckAccessTokenValidity(ctx context.Context, req *authv1.CheckAccessTokenValidityRequest) (*authv1.CheckAccessTokenValidityResponse, error) {
+	userID, sessionID, err := grpcHandler.authService.CheckAccessTokenValidity(ctx, req.GetAccessToken())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &authv1.CheckAccessTokenValidityResponse{
+		UserId:    userID,
+		SessionId: sessionID,
+	}, nil
+}
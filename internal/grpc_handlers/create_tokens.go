@@ -0,0 +1,21 @@
+package grpc_handlers
+
+import (
+	"context"
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+)
+
+// CreateTokens выдаёт пару токенов по user_id — аналог GET /api/v1/auth/tokens
+func (grpcHandler *GrpcHandler) CreateTokens(ctx context.Context, req *authv1.CreateTokensRequest) (*authv1.CreateTokensResponse, error) {
+	userAgent, ipAddr := clientInfo(ctx, req.GetClient())
+
+	accessToken, refreshToken, err := grpcHandler.authService.CreateTokens(ctx, req.GetUserId(), userAgent, ipAddr)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &authv1.CreateTokensResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
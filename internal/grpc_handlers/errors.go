@@ -0,0 +1,59 @@
+package grpc_handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+)
+
+// mfaRequiredReason — reason в google.rpc.ErrorInfo, когда вместо пары токенов нужен второй фактор
+const mfaRequiredReason = "MFA_REQUIRED"
+
+// errorCodes — коды gRPC для ошибок сервиса; остальные ошибки отдаются как INTERNAL
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{context.Canceled, codes.Canceled},
+	{apperrors.ErrInvalidUserID, codes.InvalidArgument},
+	{apperrors.ErrUserIDLoginDisabled, codes.PermissionDenied},
+	{apperrors.ErrInvalidToken, codes.Unauthenticated},
+	{apperrors.ErrTokensDontMatch, codes.Unauthenticated},
+	{apperrors.ErrRefreshTokenReused, codes.Unauthenticated},
+	{apperrors.ErrSessionNotFound, codes.Unauthenticated},
+	{apperrors.ErrUserNotFound, codes.NotFound},
+}
+
+// toStatus сообщает логу вызова ошибку сервиса вместе с причиной и переводит её в статус gRPC.
+// Текст статуса — текст ошибки сервиса: причина (Postgres, Redis) наружу не уходит.
+func toStatus(ctx context.Context, err error) error {
+	logging.SetError(ctx, err)
+
+	var mfaErr *apperrors.MFARequiredError
+	if errors.As(err, &mfaErr) {
+		st, detailsErr := status.New(codes.Unauthenticated, err.Error()).WithDetails(&errdetails.ErrorInfo{
+			Reason: mfaRequiredReason,
+			Metadata: map[string]string{
+				"mfa_token":  mfaErr.MFAToken,
+				"expires_in": strconv.FormatInt(int64(mfaErr.ExpiresIn.Seconds()), 10),
+			},
+		})
+		if detailsErr != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return st.Err()
+	}
+
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return status.Error(known.code, err.Error())
+		}
+	}
+	return status.Error(codes.Internal, err.Error())
+}
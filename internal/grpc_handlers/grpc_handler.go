@@ -0,0 +1,47 @@
+package grpc_handlers
+
+import (
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+	"github.com/Turalchik/authentication-service/internal/handlers"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"log/slog"
+)
+
+// GrpcHandler — gRPC API auth.v1.AuthService поверх того же AuthService, что и HttpHandler
+type GrpcHandler struct {
+	authv1.UnimplementedAuthServiceServer
+
+	authService    AuthService
+	rateLimitStore RateLimitStore
+	rateLimits     handlers.RateLimits
+	logger         *slog.Logger
+}
+
+// NewGrpcHandler — rateLimitStore и rateLimits те же, что у HttpHandler: счётчики по user_id и сессии общие
+func NewGrpcHandler(authService AuthService, rateLimitStore RateLimitStore, rateLimits handlers.RateLimits, logger *slog.Logger) *GrpcHandler {
+	return &GrpcHandler{
+		authService:    authService,
+		rateLimitStore: rateLimitStore,
+		rateLimits:     rateLimits,
+		logger:         logging.OrDiscard(logger),
+	}
+}
+
+// NewServer собирает gRPC сервер: AuthService, стандартный grpc.health.v1.Health и, если enableReflection,
+// reflection (grpcurl и Postman видят методы без .proto). Порт без TLS и аутентификации, поэтому reflection
+// по умолчанию выключен. healthServer остаётся у вызывающего, чтобы при остановке перевести его в NOT_SERVING.
+func (grpcHandler *GrpcHandler) NewServer(healthServer *health.Server, enableReflection bool) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcHandler.UnaryInterceptor, grpcHandler.RateLimitInterceptor))
+	authv1.RegisterAuthServiceServer(server, grpcHandler)
+	healthpb.RegisterHealthServer(server, healthServer)
+	if enableReflection {
+		reflection.Register(server)
+	}
+
+	healthServer.SetServingStatus(authv1.AuthService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	return server
+}
@@ -0,0 +1,399 @@
+package grpc_handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/handlers"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+type mockAuthService struct {
+	CreateTokensFunc             func(userID string, userAgent string, userIP string) (string, string, error)
+	RefreshTokensFunc            func(accessToken string, refreshToken string, userAgent string, userIP string) (string, string, error)
+	LogoutFunc                   func(accessToken string, sessionID string, userAgent string, userIP string) error
+	CheckAccessTokenValidityFunc func(accessToken string) (string, string, error)
+	ListSessionsFunc             func(userID string) ([]*sessions.Sessions, error)
+}
+
+func (m *mockAuthService) CreateTokens(_ context.Context, userID string, userAgent string, userIP string) (string, string, error) {
+	return m.CreateTokensFunc(userID, userAgent, userIP)
+}
+func (m *mockAuthService) RefreshTokens(_ context.Context, accessToken string, refreshToken string, userAgent string, userIP string) (string, string, error) {
+	return m.RefreshTokensFunc(accessToken, refreshToken, userAgent, userIP)
+}
+func (m *mockAuthService) Logout(_ context.Context, accessToken string, sessionID string, userAgent string, userIP string) error {
+	return m.LogoutFunc(accessToken, sessionID, userAgent, userIP)
+}
+func (m *mockAuthService) CheckAccessTokenValidity(_ context.Context, accessToken string) (string, string, error) {
+	return m.CheckAccessTokenValidityFunc(accessToken)
+}
+func (m *mockAuthService) ListSessions(_ context.Context, userID string) ([]*sessions.Sessions, error) {
+	return m.ListSessionsFunc(userID)
+}
+
+// validToken — access токен, который мок принимает в CheckAccessTokenValidity
+func validToken(accessToken string) (string, string, error) {
+	if accessToken != "access" {
+		return "", "", apperrors.ErrInvalidToken
+	}
+	return "u1", "s1", nil
+}
+
+type testServer struct {
+	client       authv1.AuthServiceClient
+	conn         *grpc.ClientConn
+	healthServer *health.Server
+	logs         *bytes.Buffer
+}
+
+type mockRateLimitStore struct {
+	AllowFunc func(key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
+func (m *mockRateLimitStore) Allow(_ context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	return m.AllowFunc(key, limit, window)
+}
+
+// startServer поднимает сервер на loopback: адрес соединения нужен для IP по умолчанию
+func startServer(t *testing.T, authService AuthService) *testServer {
+	return startServerWith(t, authService, nil, handlers.RateLimits{}, false)
+}
+
+func startServerWith(t *testing.T, authService AuthService, rateLimitStore RateLimitStore, rateLimits handlers.RateLimits, enableReflection bool) *testServer {
+	logs := &bytes.Buffer{}
+	healthServer := health.NewServer()
+	server := NewGrpcHandler(authService, rateLimitStore, rateLimits, logging.NewLogger(logs, slog.LevelInfo)).NewServer(healthServer, enableReflection)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUserAgent("orders-service"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{client: authv1.NewAuthServiceClient(conn), conn: conn, healthServer: healthServer, logs: logs}
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestGrpcHandler_CreateTokens(t *testing.T) {
+	var gotUserAgent, gotIP string
+	authService := &mockAuthService{
+		CreateTokensFunc: func(userID string, userAgent string, userIP string) (string, string, error) {
+			gotUserAgent, gotIP = userAgent, userIP
+			switch userID {
+			case "u1":
+				return "access", "refresh", nil
+			case "mfa":
+				return "", "", &apperrors.MFARequiredError{MFAToken: "mfa-token", ExpiresIn: 5 * time.Minute}
+			case "":
+				return "", "", apperrors.ErrInvalidUserID
+			default:
+				return "", "", apperrors.Wrap(apperrors.ErrCantCreateSession, errors.New("pq: connection refused"))
+			}
+		},
+	}
+	srv := startServer(t, authService)
+
+	t.Run("success", func(t *testing.T) {
+		resp, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{
+			UserId: "u1",
+			Client: &authv1.ClientInfo{UserAgent: "Mozilla/5.0", IpAddr: "203.0.113.7"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "access", resp.AccessToken)
+		assert.Equal(t, "refresh", resp.RefreshToken)
+		assert.Equal(t, "Mozilla/5.0", gotUserAgent)
+		assert.Equal(t, "203.0.113.7", gotIP)
+	})
+
+	t.Run("client info from connection", func(t *testing.T) {
+		_, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{UserId: "u1"})
+		require.NoError(t, err)
+		assert.Contains(t, gotUserAgent, "orders-service")
+		assert.Equal(t, "127.0.0.1", gotIP)
+	})
+
+	t.Run("mfa required", func(t *testing.T) {
+		_, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{UserId: "mfa"})
+		st := status.Convert(err)
+		assert.Equal(t, codes.Unauthenticated, st.Code())
+		require.Len(t, st.Details(), 1)
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, "MFA_REQUIRED", info.Reason)
+		assert.Equal(t, map[string]string{"mfa_token": "mfa-token", "expires_in": "300"}, info.Metadata)
+	})
+
+	t.Run("invalid argument", func(t *testing.T) {
+		_, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("internal error hides cause", func(t *testing.T) {
+		_, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{UserId: "u2"})
+		st := status.Convert(err)
+		assert.Equal(t, codes.Internal, st.Code())
+		assert.Equal(t, apperrors.ErrCantCreateSession.Error(), st.Message())
+	})
+}
+
+func TestGrpcHandler_RefreshTokens(t *testing.T) {
+	srv := startServer(t, &mockAuthService{
+		RefreshTokensFunc: func(accessToken string, refreshToken string, userAgent string, userIP string) (string, string, error) {
+			if refreshToken == "reused" {
+				return "", "", apperrors.ErrRefreshTokenReused
+			}
+			return "access2", "refresh2", nil
+		},
+	})
+
+	resp, err := srv.client.RefreshTokens(t.Context(), &authv1.RefreshTokensRequest{AccessToken: "access", RefreshToken: "refresh"})
+	require.NoError(t, err)
+	assert.Equal(t, "access2", resp.AccessToken)
+	assert.Equal(t, "refresh2", resp.RefreshToken)
+
+	_, err = srv.client.RefreshTokens(t.Context(), &authv1.RefreshTokensRequest{AccessToken: "access", RefreshToken: "reused"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGrpcHandler_Logout(t *testing.T) {
+	var loggedOut []string
+	srv := startServer(t, &mockAuthService{
+		CheckAccessTokenValidityFunc: validToken,
+		LogoutFunc: func(accessToken string, sessionID string, userAgent string, userIP string) error {
+			loggedOut = append(loggedOut, sessionID)
+			return nil
+		},
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		_, err := srv.client.Logout(t.Context(), &authv1.LogoutRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := srv.client.Logout(withToken(t.Context(), "forged"), &authv1.LogoutRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		_, err := srv.client.Logout(withToken(t.Context(), "access"), &authv1.LogoutRequest{})
+		require.NoError(t, err)
+	})
+
+	assert.Equal(t, []string{"s1"}, loggedOut)
+}
+
+func TestGrpcHandler_CheckAccessTokenValidity(t *testing.T) {
+	srv := startServer(t, &mockAuthService{CheckAccessTokenValidityFunc: validToken})
+
+	resp, err := srv.client.CheckAccessTokenValidity(t.Context(), &authv1.CheckAccessTokenValidityRequest{AccessToken: "access"})
+	require.NoError(t, err)
+	assert.Equal(t, "u1", resp.UserId)
+	assert.Equal(t, "s1", resp.SessionId)
+
+	_, err = srv.client.CheckAccessTokenValidity(t.Context(), &authv1.CheckAccessTokenValidityRequest{AccessToken: "forged"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGrpcHandler_ListSessions(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := startServer(t, &mockAuthService{
+		CheckAccessTokenValidityFunc: validToken,
+		ListSessionsFunc: func(userID string) ([]*sessions.Sessions, error) {
+			assert.Equal(t, "u1", userID)
+			return []*sessions.Sessions{
+				{SessionID: "s1", UserAgent: "ua1", IPAddr: "ip1", CreatedAt: createdAt, LastUsedAt: createdAt.Add(time.Hour)},
+				{SessionID: "s2", UserAgent: "ua2", IPAddr: "ip2", CreatedAt: createdAt, LastUsedAt: createdAt},
+			}, nil
+		},
+	})
+
+	resp, err := srv.client.ListSessions(withToken(t.Context(), "access"), &authv1.ListSessionsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Sessions, 2)
+	assert.Equal(t, "s1", resp.Sessions[0].SessionId)
+	assert.True(t, resp.Sessions[0].Current)
+	assert.False(t, resp.Sessions[1].Current)
+	assert.Equal(t, createdAt.Add(time.Hour), resp.Sessions[0].LastUsedAt.AsTime())
+}
+
+func TestGrpcHandler_HealthAndReflection(t *testing.T) {
+	srv := startServerWith(t, &mockAuthService{}, nil, handlers.RateLimits{}, true)
+	healthClient := healthpb.NewHealthClient(srv.conn)
+
+	resp, err := healthClient.Check(t.Context(), &healthpb.HealthCheckRequest{Service: "auth.v1.AuthService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// при остановке сервер перестаёт принимать трафик от балансировщика
+	srv.healthServer.Shutdown()
+	resp, err = healthClient.Check(t.Context(), &healthpb.HealthCheckRequest{Service: "auth.v1.AuthService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	stream, err := reflectionpb.NewServerReflectionClient(srv.conn).ServerReflectionInfo(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	reflectionResp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var services []string
+	for _, service := range reflectionResp.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	assert.Contains(t, services, "auth.v1.AuthService")
+	assert.Contains(t, services, "grpc.health.v1.Health")
+}
+
+func TestGrpcHandler_ReflectionDisabledByDefault(t *testing.T) {
+	srv := startServer(t, &mockAuthService{})
+
+	stream, err := reflectionpb.NewServerReflectionClient(srv.conn).ServerReflectionInfo(t.Context())
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestGrpcHandler_RateLimit(t *testing.T) {
+	authService := &mockAuthService{
+		CreateTokensFunc: func(userID string, userAgent string, userIP string) (string, string, error) {
+			return "access", "refresh", nil
+		},
+		RefreshTokensFunc: func(accessToken string, refreshToken string, userAgent string, userIP string) (string, string, error) {
+			return "access2", "refresh2", nil
+		},
+		CheckAccessTokenValidityFunc: validToken,
+	}
+	rateLimits := handlers.RateLimits{
+		PerIP:      handlers.RateLimit{Limit: 300, Window: time.Minute},
+		PerUser:    handlers.RateLimit{Limit: 120, Window: time.Minute},
+		PerSession: handlers.RateLimit{Limit: 10, Window: time.Minute},
+		PerRoute: map[string]handlers.RateLimit{
+			"/auth.v1.AuthService/CreateTokens":  {Limit: 30, Window: time.Minute},
+			"/auth.v1.AuthService/RefreshTokens": {Limit: 30, Window: time.Minute},
+		},
+	}
+
+	t.Run("keys by method, peer address, user and session", func(t *testing.T) {
+		var keys []string
+		store := &mockRateLimitStore{AllowFunc: func(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+			keys = append(keys, key)
+			return true, 0, nil
+		}}
+		srv := startServerWith(t, authService, store, rateLimits, false)
+
+		// IP клиента из запроса задаёт вызывающий сервис, лимит считается по адресу соединения
+		_, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{
+			UserId: "u1",
+			Client: &authv1.ClientInfo{IpAddr: "203.0.113.7"},
+		})
+		require.NoError(t, err)
+		_, err = srv.client.RefreshTokens(t.Context(), &authv1.RefreshTokensRequest{AccessToken: "access", RefreshToken: "s1.secret"})
+		require.NoError(t, err)
+		// без лимита в PerRoute метод не ограничивается: его вызывают на каждый запрос внутренних сервисов
+		_, err = srv.client.CheckAccessTokenValidity(t.Context(), &authv1.CheckAccessTokenValidityRequest{AccessToken: "access"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"route:/auth.v1.AuthService/CreateTokens:ip:127.0.0.1",
+			"user:u1",
+			"route:/auth.v1.AuthService/RefreshTokens:ip:127.0.0.1",
+			"session:s1",
+		}, keys)
+	})
+
+	t.Run("limit exceeded", func(t *testing.T) {
+		store := &mockRateLimitStore{AllowFunc: func(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+			return key != "user:u1", 1500 * time.Millisecond, nil
+		}}
+		srv := startServerWith(t, authService, store, rateLimits, false)
+
+		var header metadata.MD
+		_, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{UserId: "u1"}, grpc.Header(&header))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, []string{"2"}, header.Get("retry-after"))
+	})
+
+	t.Run("store unavailable", func(t *testing.T) {
+		store := &mockRateLimitStore{AllowFunc: func(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+			return false, 0, errors.New("redis: connection refused")
+		}}
+		srv := startServerWith(t, authService, store, rateLimits, false)
+
+		_, err := srv.client.CreateTokens(t.Context(), &authv1.CreateTokensRequest{UserId: "u1"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestGrpcHandler_RequestLog(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	srv := startServer(t, &mockAuthService{
+		CheckAccessTokenValidityFunc: validToken,
+		ListSessionsFunc: func(userID string) ([]*sessions.Sessions, error) {
+			return nil, apperrors.Wrap(apperrors.ErrCantGetSession, errors.New("pq: connection refused"))
+		},
+	})
+
+	ctx := metadata.AppendToOutgoingContext(withToken(t.Context(), "access"),
+		"x-request-id", "req-1",
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	var header metadata.MD
+	_, err := srv.client.ListSessions(ctx, &authv1.ListSessionsRequest{}, grpc.Header(&header))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(srv.logs.Bytes(), &line))
+	assert.Equal(t, "grpc request", line["msg"])
+	assert.Equal(t, "ERROR", line["level"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "/auth.v1.AuthService/ListSessions", line["route"])
+	assert.Equal(t, "u1", line["user_id"])
+	assert.Equal(t, "Internal", line["grpc_code"])
+	assert.Equal(t, "can't get session", line["error"])
+	assert.Equal(t, "pq: connection refused", line["cause"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line["trace_id"])
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, "auth.v1.AuthService/ListSessions", ended[0].Name())
+	assert.Equal(t, "00f067aa0ba902b7", ended[0].Parent().SpanID().String())
+}
@@ -0,0 +1,65 @@
+package grpc_handlers
+
+import (
+	"context"
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"strings"
+)
+
+// metadataValue — первое значение ключа metadata входящего вызова
+func metadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// clientInfo — user agent и IP конечного пользователя: из запроса, а если вызывающий сервис их
+// не передал — из metadata user-agent и адреса соединения
+func clientInfo(ctx context.Context, client *authv1.ClientInfo) (string, string) {
+	userAgent, ipAddr := client.GetUserAgent(), client.GetIpAddr()
+	if userAgent == "" {
+		userAgent = metadataValue(ctx, "user-agent")
+	}
+	if ipAddr == "" {
+		ipAddr = peerIP(ctx)
+	}
+	return userAgent, ipAddr
+}
+
+// peerIP — IP адреса соединения; metadata и поля запроса задаёт вызывающий, им для лимитов не верим
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// authenticate проверяет access токен из metadata authorization: Bearer <token>, как AuthMiddleware в REST
+func (grpcHandler *GrpcHandler) authenticate(ctx context.Context) (string, string, string, error) {
+	auth := metadataValue(ctx, "authorization")
+	accessToken, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok || accessToken == "" {
+		return "", "", "", status.Error(codes.Unauthenticated, "missing token")
+	}
+
+	userID, sessionID, err := grpcHandler.authService.CheckAccessTokenValidity(ctx, accessToken)
+	if err != nil {
+		logging.SetError(ctx, err)
+		return "", "", "", status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	logging.SetUser(ctx, userID, sessionID)
+	return accessToken, userID, sessionID, nil
+}
@@ -0,0 +1,112 @@
+package grpc_handlers
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// requestIDPattern — x-request-id вызывающего сервиса принимаем, только если он не сломает лог
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+var tracer = otel.Tracer("github.com/Turalchik/authentication-service/internal/grpc_handlers")
+
+// serverErrorCodes — исходы, которые считаются сбоем сервера: вызов пишется в лог с уровнем ERROR,
+// а span помечается ошибкой. Остальные коды — штатный ответ на неверный запрос.
+var serverErrorCodes = map[codes.Code]bool{
+	codes.Unknown:          true,
+	codes.DeadlineExceeded: true,
+	codes.Unimplemented:    true,
+	codes.Internal:         true,
+	codes.Unavailable:      true,
+	codes.DataLoss:         true,
+}
+
+// UnaryInterceptor открывает серверный span вызова, продолжая трассу из traceparent в metadata, и пишет
+// одну строку лога на вызов — как ServeHTTP у HttpHandler: request_id, метод, владелец токена, код,
+// время обработки и причина ошибки
+func (grpcHandler *GrpcHandler) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	requestID := metadataValue(ctx, "x-request-id")
+	if !requestIDPattern.MatchString(requestID) {
+		requestID = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+
+	service, method, _ := strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+	ctx, span := tracer.Start(tracing.Extract(ctx, incomingHeader(ctx)), service+"/"+method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(method),
+			attribute.String("rpc.request_id", requestID),
+		))
+	defer span.End()
+
+	ctx, fields := logging.NewContext(ctx, requestID)
+	logging.SetRoute(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	latency := time.Since(start)
+
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+
+	// причина, которую сообщил обработчик, подробнее статуса: статус несёт только текст ошибки сервиса
+	callErr := fields.Err()
+	if callErr == nil && err != nil {
+		callErr = err
+	}
+	if callErr != nil {
+		span.RecordError(callErr)
+	}
+	if serverErrorCodes[code] {
+		span.SetStatus(otelcodes.Error, code.String())
+	}
+
+	attrs := []slog.Attr{
+		slog.String("grpc_code", code.String()),
+		slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+	}
+	if callErr != nil {
+		attrs = append(attrs, slog.String("error", callErr.Error()))
+		if cause := apperrors.Cause(callErr); cause != nil {
+			attrs = append(attrs, slog.String("cause", cause.Error()))
+		}
+	}
+
+	level := slog.LevelInfo
+	if serverErrorCodes[code] {
+		level = slog.LevelError
+	}
+	grpcHandler.logger.LogAttrs(ctx, level, "grpc request", attrs...)
+
+	return resp, err
+}
+
+// incomingHeader — metadata вызова в виде http.Header для tracing.Extract: ключи metadata в нижнем регистре
+func incomingHeader(ctx context.Context) http.Header {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+	return header
+}
@@ -0,0 +1,35 @@
+package grpc_handlers
+
+import (
+	"context"
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ListSessions возвращает активные сессии владельца access токена из metadata — аналог GET /api/v1/auth/sessions
+func (grpcHandler *GrpcHandler) ListSessions(ctx context.Context, _ *authv1.ListSessionsRequest) (*authv1.ListSessionsResponse, error) {
+	_, userID, currentSessionID, err := grpcHandler.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userSessions, err := grpcHandler.authService.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &authv1.ListSessionsResponse{
+		Sessions: make([]*authv1.Session, 0, len(userSessions)),
+	}
+	for _, session := range userSessions {
+		resp.Sessions = append(resp.Sessions, &authv1.Session{
+			SessionId:  session.SessionID,
+			UserAgent:  session.UserAgent,
+			IpAddr:     session.IPAddr,
+			CreatedAt:  timestamppb.New(session.CreatedAt),
+			LastUsedAt: timestamppb.New(session.LastUsedAt),
+			Current:    session.SessionID == currentSessionID,
+		})
+	}
+	return resp, nil
+}
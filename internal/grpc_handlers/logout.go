@@ -0,0 +1,21 @@
+package grpc_handlers
+
+import (
+	"context"
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+)
+
+// Logout завершает сессию access токена из metadata — аналог POST /api/v1/auth/logout
+func (grpcHandler *GrpcHandler) Logout(ctx context.Context, req *authv1.LogoutRequest) (*authv1.LogoutResponse, error) {
+	accessToken, _, sessionID, err := grpcHandler.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent, ipAddr := clientInfo(ctx, req.GetClient())
+	if err = grpcHandler.authService.Logout(ctx, accessToken, sessionID, userAgent, ipAddr); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &authv1.LogoutResponse{}, nil
+}
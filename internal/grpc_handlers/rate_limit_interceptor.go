@@ -0,0 +1,76 @@
+package grpc_handlers
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/handlers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type rateLimitCheck struct {
+	key   string
+	limit handlers.RateLimit
+}
+
+// RateLimitInterceptor ограничивает частоту вызовов теми же окнами и ключами, что и REST: по методу с адреса
+// соединения (лимит из PerRoute по полному имени метода), по user_id из запроса и по сессии refresh токена.
+// Общий лимит PerIP не применяется: вызовы внутренних сервисов приходят с нескольких адресов и
+// упирались бы в него при обычной нагрузке. Ставится после UnaryInterceptor, чтобы отказ попал в лог вызова.
+func (grpcHandler *GrpcHandler) RateLimitInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var checks []rateLimitCheck
+	if limit, ok := grpcHandler.rateLimits.PerRoute[info.FullMethod]; ok {
+		checks = append(checks, rateLimitCheck{key: "route:" + info.FullMethod + ":ip:" + peerIP(ctx), limit: limit})
+	}
+
+	// выдача токенов по голому user_id: ограничиваем перебор по конкретному пользователю, общий счётчик с REST
+	if r, ok := req.(interface{ GetUserId() string }); ok && r.GetUserId() != "" {
+		checks = append(checks, rateLimitCheck{key: "user:" + r.GetUserId(), limit: grpcHandler.rateLimits.PerUser})
+	}
+
+	// refresh токен — <session_id>.<секрет>; у токенов старого формата без точки сессия не известна
+	if r, ok := req.(interface{ GetRefreshToken() string }); ok {
+		if sessionID, _, found := strings.Cut(r.GetRefreshToken(), "."); found && sessionID != "" {
+			checks = append(checks, rateLimitCheck{key: "session:" + sessionID, limit: grpcHandler.rateLimits.PerSession})
+		}
+	}
+
+	if err := grpcHandler.allowCall(ctx, checks); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// allowCall учитывает вызов во всех окнах и отвечает RESOURCE_EXHAUSTED с retry-after в metadata, если хотя бы
+// одно переполнено. Если хранилище недоступно, вызов пропускается: лимиты не должны останавливать вход пользователей.
+func (grpcHandler *GrpcHandler) allowCall(ctx context.Context, checks []rateLimitCheck) error {
+	if grpcHandler.rateLimitStore == nil {
+		return nil
+	}
+
+	for _, check := range checks {
+		if check.limit.Limit <= 0 {
+			continue
+		}
+
+		allowed, retryAfter, err := grpcHandler.rateLimitStore.Allow(ctx, check.key, check.limit.Limit, check.limit.Window)
+		if err != nil {
+			grpcHandler.logger.WarnContext(ctx, "rate limit check failed", "key", check.key, "error", err)
+			continue
+		}
+		if !allowed {
+			// retry-after в целых секундах, как заголовок Retry-After у REST; округляем вверх
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+			return status.Error(codes.ResourceExhausted, "too many requests")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package grpc_handlers
+
+import (
+	"context"
+	"time"
+)
+
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
@@ -0,0 +1,21 @@
+package grpc_handlers
+
+import (
+	"context"
+	authv1 "github.com/Turalchik/authentication-service/api/auth/v1"
+)
+
+// RefreshTokens обменивает действующую пару токенов на новую — аналог POST /api/v1/auth/refresh
+func (grpcHandler *GrpcHandler) RefreshTokens(ctx context.Context, req *authv1.RefreshTokensRequest) (*authv1.RefreshTokensResponse, error) {
+	userAgent, ipAddr := clientInfo(ctx, req.GetClient())
+
+	accessToken, refreshToken, err := grpcHandler.authService.RefreshTokens(ctx, req.GetAccessToken(), req.GetRefreshToken(), userAgent, ipAddr)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &authv1.RefreshTokensResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// scope токенов сервисов для административных ручек
+const (
+	// webhooksAdminScope — управление подписками на webhooks
+	webhooksAdminScope = "webhooks:admin"
+	// auditReadScope — чтение журнала аутентификации
+	auditReadScope = "audit:read"
+)
+
+// AdminMiddleware пускает только токены сервиса (client_credentials) с нужным scope
+func (httpHandler *HttpHandler) AdminMiddleware(scope string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			auth := req.Header.Get("Authorization")
+			if len(auth) < 7 || auth[:7] != "Bearer " {
+				http.Error(w, "missing token", http.StatusUnauthorized)
+				return
+			}
+
+			clientID, err := httpHandler.authService.CheckClientScope(req.Context(), auth[7:], scope)
+			if err != nil {
+				recordError(req, err)
+				if errors.Is(err, apperrors.ErrInsufficientScope) {
+					http.Error(w, "insufficient scope", http.StatusForbidden)
+					return
+				}
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			logging.SetClient(req.Context(), clientID)
+
+			ctx := context.WithValue(req.Context(), "args", map[string]string{
+				"clientID": clientID,
+			})
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	}
+}
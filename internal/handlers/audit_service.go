@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"io"
+)
+
+type AuditService interface {
+	ListEvents(ctx context.Context, filter *audit_events.AuditEventFilter, cursor string) ([]*audit_events.AuditEvents, string, error)
+	Export(ctx context.Context, w io.Writer) error
+}
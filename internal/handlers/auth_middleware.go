@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"github.com/Turalchik/authentication-service/internal/logging"
 	"net/http"
 )
 
@@ -15,17 +16,21 @@ func (httpHandler *HttpHandler) AuthMiddleware(next http.Handler) http.Handler {
 
 		// просим сервис проверить токен за нас
 		tokenStr := auth[7:]
-		userID, err := httpHandler.authService.CheckAccessTokenValidity(tokenStr)
+		userID, sessionID, err := httpHandler.authService.CheckAccessTokenValidity(req.Context(), tokenStr)
 		if err != nil {
+			recordError(req, err)
 			// TODO
 			// как же много нужно парсить ошибки
 			http.Error(w, "invalid token", http.StatusUnauthorized)
 			return
 		}
 
-		// прокидываем userID и access токен в контекст
+		logging.SetUser(req.Context(), userID, sessionID)
+
+		// прокидываем userID, sessionID и access токен в контекст
 		ctx := context.WithValue(req.Context(), "args", map[string]string{
 			"userID":      userID,
+			"sessionID":   sessionID,
 			"accessToken": tokenStr,
 		})
 		next.ServeHTTP(w, req.WithContext(ctx))
@@ -1,8 +1,33 @@
 package handlers
 
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/token_introspection"
+	"github.com/Turalchik/authentication-service/internal/entities/token_response"
+	"github.com/Turalchik/authentication-service/internal/token_signer"
+)
+
 type AuthService interface {
-	CreateTokens(userID string, userAgent string, userIP string) (string, string, error)
-	RefreshTokens(accessToken string, refreshToken string, userAgent string, userIP string) (string, string, error)
-	Logout(accessToken string, userID string) error
-	CheckAccessTokenValidity(accessToken string) (string, error)
+	CreateTokens(ctx context.Context, userID string, userAgent string, userIP string) (string, string, error)
+	Register(ctx context.Context, email string, password string) (string, error)
+	Login(ctx context.Context, email string, password string, userAgent string, userIP string) (string, string, error)
+	EnrollTOTP(ctx context.Context, userID string) (string, string, error)
+	ConfirmTOTP(ctx context.Context, userID string, code string) error
+	VerifyMFA(ctx context.Context, mfaToken string, code string, userAgent string, userIP string) (string, string, error)
+	RefreshTokens(ctx context.Context, accessToken string, refreshToken string, userAgent string, userIP string) (string, string, error)
+	Logout(ctx context.Context, accessToken string, sessionID string, userAgent string, userIP string) error
+	CheckAccessTokenValidity(ctx context.Context, accessToken string) (string, string, error)
+	CheckClientScope(ctx context.Context, accessToken string, scope string) (string, error)
+	ListSessions(ctx context.Context, userID string) ([]*sessions.Sessions, error)
+	RevokeSession(ctx context.Context, userID string, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID string, currentSessionID string) error
+	JWKS() token_signer.JWKS
+	IntrospectToken(ctx context.Context, clientID string, clientSecret string, token string) (*token_introspection.TokenIntrospection, error)
+	RevokeToken(ctx context.Context, clientID string, clientSecret string, token string, tokenTypeHint string) error
+	ClientCredentialsToken(ctx context.Context, clientID string, clientSecret string, scope string) (*token_response.TokenResponse, error)
+	Authorize(ctx context.Context, userID string, sessionID string, request *authorization_codes.AuthorizationRequest) (string, string, error)
+	AuthorizeWithPassword(ctx context.Context, email string, password string, totpCode string, request *authorization_codes.AuthorizationRequest, userAgent string, userIP string) (string, string, error)
+	ExchangeAuthorizationCode(ctx context.Context, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string, userAgent string, ipAddr string) (*token_response.TokenResponse, error)
 }
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"net/http"
+	"net/url"
+)
+
+// Authorize выдаёт authorization code от имени вошедшего пользователя (RFC 6749, раздел 4.1, + PKCE).
+// Браузер, пришедший по редиректу от клиента, Bearer заголовок передать не может — ему отдаётся форма входа,
+// которая отправляется на POST /oauth2/authorize (см. AuthorizeWithPassword).
+// @Summary      OAuth 2.0 authorization endpoint
+// @Description  Authorization code flow с обязательным PKCE S256 для SPA и мобильных приложений. Без заголовка Authorization возвращается HTML форма входа (email, пароль и TOTP код), которая отправляется на POST /oauth2/authorize вместе с параметрами запроса. С Bearer access‑токеном код выдаётся сразу: при успехе — редирект на redirect_uri с code и state, при ошибке после проверки redirect_uri — редирект с error и state. Если клиент неизвестен или redirect_uri не зарегистрирован, редиректа нет — возвращается 400.
+// @Tags         oauth2
+// @Produce      html
+// @Security     ApiKeyAuth
+// @Param        response_type          query  string  true   "code"
+// @Param        client_id              query  string  true   "ID клиента"
+// @Param        redirect_uri           query  string  false  "Один из зарегистрированных redirect_uri; можно не передавать, если он у клиента один"
+// @Param        scope                  query  string  false  "Запрашиваемые scope через пробел"
+// @Param        state                  query  string  false  "Непрозрачное значение, возвращается клиенту как есть"
+// @Param        code_challenge         query  string  true   "BASE64URL(SHA256(code_verifier))"
+// @Param        code_challenge_method  query  string  true   "S256"
+// @Success      200  {string}  string  "Форма входа, если запрос без Bearer токена"
+// @Success      302  "Редирект на redirect_uri с code или error"
+// @Failure      400  {object}  oauthErrorBody  "invalid_request: неизвестный клиент или redirect_uri"
+// @Failure      401  {string}  string  "invalid token"
+// @Failure      500  {object}  oauthErrorBody  "server_error"
+// @Router       /oauth2/authorize [get]
+func (httpHandler *HttpHandler) Authorize(w http.ResponseWriter, req *http.Request) {
+	request, state := authorizationRequestFromValues(req.URL.Query())
+
+	auth := req.Header.Get("Authorization")
+	if auth == "" {
+		writeAuthorizeLoginForm(w, http.StatusOK, &authorizeLoginForm{Params: authorizeFormParams(req.URL.Query())})
+		return
+	}
+	if len(auth) < 7 || auth[:7] != "Bearer " {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	userID, sessionID, err := httpHandler.authService.CheckAccessTokenValidity(req.Context(), auth[7:])
+	if err != nil {
+		recordError(req, err)
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	logging.SetUser(req.Context(), userID, sessionID)
+
+	redirectURI, code, err := httpHandler.authService.Authorize(req.Context(), userID, sessionID, request)
+	writeAuthorizeResult(w, req, redirectURI, code, state, err)
+}
+
+// authorizationRequestFromValues собирает параметры запроса /oauth2/authorize из query или из формы входа
+func authorizationRequestFromValues(values url.Values) (*authorization_codes.AuthorizationRequest, string) {
+	return &authorization_codes.AuthorizationRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+	}, values.Get("state")
+}
+
+// writeAuthorizeResult возвращает пользователя клиенту с кодом или с ошибкой
+func writeAuthorizeResult(w http.ResponseWriter, req *http.Request, redirectURI string, code string, state string, err error) {
+	if err != nil {
+		recordError(req, err)
+		// redirect_uri не проверен — уводить пользователя по нему нельзя (RFC 6749, раздел 4.1.2.1)
+		if redirectURI == "" {
+			if errors.Is(err, apperrors.ErrInvalidClient) {
+				writeOAuthError(w, http.StatusBadRequest, "invalid_request", "unknown client_id")
+				return
+			}
+			if errors.Is(err, apperrors.ErrInvalidRedirectURI) {
+				writeOAuthError(w, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered for the client")
+				return
+			}
+			writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+			return
+		}
+
+		redirectWithParams(w, req, redirectURI, url.Values{"error": {authorizeErrorCode(err)}}, state)
+		return
+	}
+
+	redirectWithParams(w, req, redirectURI, url.Values{"code": {code}}, state)
+}
+
+// authorizeErrorCode переводит ошибку сервиса в код ошибки authorization endpoint (RFC 6749, раздел 4.1.2.1)
+func authorizeErrorCode(err error) string {
+	switch {
+	case errors.Is(err, apperrors.ErrUnsupportedResponseType):
+		return "unsupported_response_type"
+	case errors.Is(err, apperrors.ErrUnauthorizedClient):
+		return "unauthorized_client"
+	case errors.Is(err, apperrors.ErrInvalidScope):
+		return "invalid_scope"
+	case errors.Is(err, apperrors.ErrInvalidRequest):
+		return "invalid_request"
+	default:
+		return "server_error"
+	}
+}
+
+// redirectWithParams добавляет параметры (и state, если он был) к query redirect_uri и делает 302
+func redirectWithParams(w http.ResponseWriter, req *http.Request, redirectURI string, params url.Values, state string) {
+	location, err := url.Parse(redirectURI)
+	if err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed redirect_uri")
+		return
+	}
+
+	query := location.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	location.RawQuery = query.Encode()
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, req, location.String(), http.StatusFound)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+// authorizeParams — параметры запроса /oauth2/authorize, которые форма входа передаёт дальше скрытыми полями
+var authorizeParams = []string{
+	"response_type",
+	"client_id",
+	"redirect_uri",
+	"scope",
+	"state",
+	"code_challenge",
+	"code_challenge_method",
+}
+
+// authorizeLoginForm — данные формы входа на authorization endpoint
+type authorizeLoginForm struct {
+	Params       map[string]string
+	Email        string
+	TOTPRequired bool
+	Error        string
+}
+
+// action относительный: форма уходит на тот же /oauth2/authorize, даже если сервис опубликован под префиксом
+var authorizeLoginTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Sign in</title>
+</head>
+<body>
+<form method="post" action="authorize">
+{{- if .Error}}
+<p role="alert">{{.Error}}</p>
+{{- end}}
+{{- range $name, $value := .Params}}
+<input type="hidden" name="{{$name}}" value="{{$value}}">
+{{- end}}
+<label>Email <input type="email" name="email" value="{{.Email}}" autocomplete="username" required></label>
+<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+{{- if .TOTPRequired}}
+<label>Code <input type="text" name="totp_code" inputmode="numeric" autocomplete="one-time-code" required autofocus></label>
+{{- end}}
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+// authorizeFormParams оставляет только параметры /oauth2/authorize; пустые не передаются
+func authorizeFormParams(values url.Values) map[string]string {
+	params := make(map[string]string, len(authorizeParams))
+	for _, name := range authorizeParams {
+		if value := values.Get(name); value != "" {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// writeAuthorizeLoginForm отдаёт форму входа. Её нельзя кэшировать и встраивать во фреймы чужих сайтов (clickjacking).
+func writeAuthorizeLoginForm(w http.ResponseWriter, status int, form *authorizeLoginForm) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+	w.WriteHeader(status)
+
+	// ошибку записи запоминает statusRecorder, она попадёт в лог запроса
+	_ = authorizeLoginTemplate.Execute(w, form)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// AuthorizeWithPassword принимает форму входа, которую отдаёт GET /oauth2/authorize, и выдаёт authorization code.
+// @Summary      Вход на OAuth 2.0 authorization endpoint
+// @Description  Форма входа из GET /oauth2/authorize: email, пароль, TOTP код (если у пользователя включён TOTP) и параметры исходного запроса. При неверных данных форма возвращается снова с ошибкой. При успехе — редирект на redirect_uri с code и state, как в GET /oauth2/authorize.
+// @Tags         oauth2
+// @Accept       x-www-form-urlencoded
+// @Produce      html
+// @Param        email                  formData  string  true   "Email"
+// @Param        password               formData  string  true   "Пароль"
+// @Param        totp_code              formData  string  false  "TOTP код, если у пользователя включён TOTP"
+// @Param        response_type          formData  string  true   "code"
+// @Param        client_id              formData  string  true   "ID клиента"
+// @Param        redirect_uri           formData  string  false  "Один из зарегистрированных redirect_uri"
+// @Param        scope                  formData  string  false  "Запрашиваемые scope через пробел"
+// @Param        state                  formData  string  false  "Непрозрачное значение, возвращается клиенту как есть"
+// @Param        code_challenge         formData  string  true   "BASE64URL(SHA256(code_verifier))"
+// @Param        code_challenge_method  formData  string  true   "S256"
+// @Success      302  "Редирект на redirect_uri с code или error"
+// @Failure      400  {object}  oauthErrorBody  "invalid_request: неизвестный клиент, redirect_uri или тело формы"
+// @Failure      401  {string}  string  "Форма входа с ошибкой: неверный email, пароль или TOTP код"
+// @Failure      500  {object}  oauthErrorBody  "server_error"
+// @Router       /oauth2/authorize [post]
+func (httpHandler *HttpHandler) AuthorizeWithPassword(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed form body")
+		return
+	}
+
+	request, state := authorizationRequestFromValues(req.PostForm)
+	email := req.PostFormValue("email")
+	ipAddr, _ := httpHandler.getIP(req)
+
+	redirectURI, code, err := httpHandler.authService.AuthorizeWithPassword(req.Context(),
+		email,
+		req.PostFormValue("password"),
+		req.PostFormValue("totp_code"),
+		request,
+		req.UserAgent(),
+		ipAddr,
+	)
+
+	// ошибки входа показываем в форме, а не клиенту: пользователь может попробовать ещё раз
+	form := &authorizeLoginForm{Params: authorizeFormParams(req.PostForm), Email: email}
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidCredentials):
+		recordError(req, err)
+		form.Error = "Invalid email or password."
+		writeAuthorizeLoginForm(w, http.StatusUnauthorized, form)
+		return
+	case errors.Is(err, apperrors.ErrMFARequired):
+		recordError(req, err)
+		form.TOTPRequired = true
+		writeAuthorizeLoginForm(w, http.StatusUnauthorized, form)
+		return
+	case errors.Is(err, apperrors.ErrInvalidTOTPCode):
+		recordError(req, err)
+		form.TOTPRequired = true
+		form.Error = "Invalid code."
+		writeAuthorizeLoginForm(w, http.StatusUnauthorized, form)
+		return
+	}
+
+	writeAuthorizeResult(w, req, redirectURI, code, state, err)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// ConfirmTOTP включает второй фактор после проверки первого кода из приложения.
+// @Summary      Подтверждение TOTP
+// @Description  Проверяет код из приложения-аутентификатора и включает второй фактор. После этого вход по паролю требует TOTP код.
+// @Tags         mfa
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Param        body  body      totpCodeBody  true  "Код из приложения"
+// @Success      204   {string}  string  "No Content"
+// @Failure      400   {string}  string  "invalid totp code"
+// @Failure      401   {string}  string  "unauthorized"
+// @Failure      404   {string}  string  "totp is not enrolled"
+// @Failure      409   {string}  string  "totp is already enrolled"
+// @Failure      500   {string}  string  "can't confirm totp"
+// @Router       /api/v1/auth/mfa/totp/confirm [post]
+func (httpHandler *HttpHandler) ConfirmTOTP(w http.ResponseWriter, req *http.Request) {
+	args := req.Context().Value("args").(map[string]string)
+
+	body := totpCodeBody{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if err := httpHandler.authService.ConfirmTOTP(req.Context(), args["userID"], body.Code); err != nil {
+		recordError(req, err)
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidTOTPCode):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, apperrors.ErrTOTPNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, apperrors.ErrTOTPAlreadyEnrolled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, apperrors.ErrTOTPUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, "can't confirm totp", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
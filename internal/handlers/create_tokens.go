@@ -5,19 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
-	"log"
 	"net/http"
 )
 
 // CreateTokens выдаёт новую пару токенов (access + refresh).
 // @Summary      Выдача токенов
-// @Description  Генерирует пару токенов для пользователя с указанным user_id в query‑параметре.
+// @Description  Открывает новую сессию и генерирует пару токенов для пользователя с указанным user_id в query‑параметре. Учётные данные не проверяются, поэтому ручка работает только в доверенном режиме (TRUSTED_USER_ID_LOGIN=true); иначе используйте /api/v1/auth/login. Если у пользователя включён TOTP, вместо пары возвращается 401 mfa_required.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        user_id  query     string  true  "GUID пользователя"
 // @Success      200      {object}  accessAndRefreshTokensBody
+// @Failure      401      {object}  mfaChallengeBody  "mfa_required: у пользователя включён TOTP"
 // @Failure      400      {string}  string  "user_id required"
+// @Failure      403      {string}  string  "issuing tokens by user id is disabled"
 // @Failure      500      {string}  string  "internal server error"
 // @Router       /api/v1/auth/tokens [get]
 func (httpHandler *HttpHandler) CreateTokens(w http.ResponseWriter, req *http.Request) {
@@ -28,12 +29,16 @@ func (httpHandler *HttpHandler) CreateTokens(w http.ResponseWriter, req *http.Re
 	}
 
 	userAgent := req.UserAgent()
-	ipAddr, _ := getIP(req)
+	ipAddr, _ := httpHandler.getIP(req)
 
-	accessToken, refreshToken, err := httpHandler.authService.CreateTokens(userID, userAgent, ipAddr)
+	accessToken, refreshToken, err := httpHandler.authService.CreateTokens(req.Context(), userID, userAgent, ipAddr)
 	if err != nil {
-		if errors.Is(err, apperrors.ErrUserAlreadyExists) {
-			http.Error(w, "user already exist", http.StatusConflict)
+		recordError(req, err)
+		if writeMFAChallenge(w, err) {
+			return
+		}
+		if errors.Is(err, apperrors.ErrUserIDLoginDisabled) {
+			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
 		http.Error(w, fmt.Sprintf("can't create tokens with error: %v", err), http.StatusInternalServerError)
@@ -49,6 +54,6 @@ func (httpHandler *HttpHandler) CreateTokens(w http.ResponseWriter, req *http.Re
 	w.WriteHeader(http.StatusOK)
 
 	if err = json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("CreateTokens: failed to write response: %v", err)
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
 	}
 }
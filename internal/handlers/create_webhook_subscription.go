@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// CreateWebhookSubscription регистрирует получателя webhooks.
+// @Summary      Создание подписки на webhooks
+// @Description  Регистрирует URL получателя с фильтром событий (пустой или ["*"] — все события). Если secret не передан, он генерируется; ключ подписи X-Signature возвращается только в этом ответе. Нужен токен сервиса со scope webhooks:admin.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        body  body      webhookSubscriptionRequestBody  true  "URL, фильтр событий и, при желании, свой ключ подписи"
+// @Success      201   {object}  webhookSubscriptionBody
+// @Failure      400   {string}  string  "invalid url or event type"
+// @Failure      401   {string}  string  "unauthorized"
+// @Failure      403   {string}  string  "insufficient scope"
+// @Failure      500   {string}  string  "can't save subscription"
+// @Router       /api/v1/admin/webhooks/subscriptions [post]
+func (httpHandler *HttpHandler) CreateWebhookSubscription(w http.ResponseWriter, req *http.Request) {
+	var body webhookSubscriptionRequestBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := httpHandler.webhookService.CreateSubscription(req.Context(), body.URL, body.EventTypes, body.Secret)
+	if err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	// ключ подписи показываем один раз — при создании
+	resp := newWebhookSubscriptionBody(subscription)
+	resp.Secret = subscription.Secret
+	writeJSON(w, http.StatusCreated, resp)
+}
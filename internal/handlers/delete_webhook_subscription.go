@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// DeleteWebhookSubscription удаляет подписку.
+// @Summary      Удаление подписки на webhooks
+// @Description  Удаляет подписку вместе с её доставками и журналом попыток. Нужен токен сервиса со scope webhooks:admin.
+// @Tags         webhooks
+// @Security     ApiKeyAuth
+// @Param        subscription_id  path      string  true  "ID подписки"
+// @Success      204              {string}  string  "No Content"
+// @Failure      401              {string}  string  "unauthorized"
+// @Failure      403              {string}  string  "insufficient scope"
+// @Failure      404              {string}  string  "subscription not found"
+// @Router       /api/v1/admin/webhooks/subscriptions/{subscription_id} [delete]
+func (httpHandler *HttpHandler) DeleteWebhookSubscription(w http.ResponseWriter, req *http.Request) {
+	if err := httpHandler.webhookService.DeleteSubscription(req.Context(), mux.Vars(req)["subscription_id"]); err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// EnrollTOTP начинает привязку TOTP к текущему пользователю.
+// @Summary      Привязка TOTP
+// @Description  Генерирует TOTP секрет (RFC 6238) и возвращает otpauth:// URI для QR кода и сам секрет в base32. Секрет хранится зашифрованным. Второй фактор включится после подтверждения кодом через /api/v1/auth/mfa/totp/confirm; до этого привязку можно начать заново.
+// @Tags         mfa
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  totpEnrollmentBody
+// @Failure      401  {string}  string  "unauthorized"
+// @Failure      409  {string}  string  "totp is already enrolled"
+// @Failure      500  {string}  string  "can't enroll totp"
+// @Failure      503  {string}  string  "totp is not configured"
+// @Router       /api/v1/auth/mfa/totp [post]
+func (httpHandler *HttpHandler) EnrollTOTP(w http.ResponseWriter, req *http.Request) {
+	args := req.Context().Value("args").(map[string]string)
+
+	otpauthURI, secret, err := httpHandler.authService.EnrollTOTP(req.Context(), args["userID"])
+	if err != nil {
+		recordError(req, err)
+		switch {
+		case errors.Is(err, apperrors.ErrTOTPAlreadyEnrolled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, apperrors.ErrTOTPUnavailable):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, "can't enroll totp", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := &totpEnrollmentBody{
+		OTPAuthURI: otpauthURI,
+		Secret:     secret,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
+	}
+}
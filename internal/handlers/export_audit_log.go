@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+)
+
+// ExportAuditLog выгружает журнал целиком для офлайн проверки цепочки.
+// @Summary      Выгрузка журнала аутентификации
+// @Description  Весь журнал в порядке записи, в формате JSON Lines: строка {"event": ...} на каждую запись и {"checkpoint": ...} сразу после записи, которую закрепляет подписанная контрольная точка. Выгрузку проверяет go run ./cmd/audit_verify. Нужен токен сервиса со scope audit:read.
+// @Tags         audit
+// @Produce      application/x-ndjson
+// @Security     ApiKeyAuth
+// @Success      200  {string}  string  "JSON Lines"
+// @Failure      401  {string}  string  "unauthorized"
+// @Failure      403  {string}  string  "insufficient scope"
+// @Failure      500  {string}  string  "can't export audit log"
+// @Router       /api/v1/admin/audit/export [get]
+func (httpHandler *HttpHandler) ExportAuditLog(w http.ResponseWriter, req *http.Request) {
+	// выгрузка журнала целиком дольше WriteTimeout сервера — снимаем дедлайн записи для этого ответа
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		httpHandler.logger.WarnContext(req.Context(), "can't clear write deadline", "error", err)
+	}
+
+	stream := &exportWriter{w: w}
+	if err := httpHandler.auditService.Export(req.Context(), stream); err != nil {
+		recordError(req, err)
+		// пока ничего не отправлено, можно честно ответить ошибкой; иначе остаётся оборвать выгрузку
+		if !stream.started {
+			http.Error(w, "can't export audit log", http.StatusInternalServerError)
+			return
+		}
+		httpHandler.logger.ErrorContext(req.Context(), "audit export aborted", "error", err)
+	}
+}
+
+// exportWriter откладывает заголовки до первой строки выгрузки
+type exportWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (exportWriter *exportWriter) Write(p []byte) (int, error) {
+	if !exportWriter.started {
+		exportWriter.w.Header().Set("Content-Type", "application/x-ndjson")
+		exportWriter.w.WriteHeader(http.StatusOK)
+		exportWriter.started = true
+	}
+	return exportWriter.w.Write(p)
+}
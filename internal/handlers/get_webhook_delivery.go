@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// GetWebhookDelivery возвращает доставку и журнал её попыток.
+// @Summary      Журнал попыток доставки
+// @Description  Состояние доставки и все попытки: время, код ответа получателя (0 — ответа не было), ошибка и длительность. Нужен токен сервиса со scope webhooks:admin.
+// @Tags         webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        delivery_id  path      string  true  "ID доставки"
+// @Success      200          {object}  webhookDeliveryDetailsBody
+// @Failure      401          {string}  string  "unauthorized"
+// @Failure      403          {string}  string  "insufficient scope"
+// @Failure      404          {string}  string  "delivery not found"
+// @Router       /api/v1/admin/webhooks/deliveries/{delivery_id} [get]
+func (httpHandler *HttpHandler) GetWebhookDelivery(w http.ResponseWriter, req *http.Request) {
+	delivery, attempts, err := httpHandler.webhookService.GetDelivery(req.Context(), mux.Vars(req)["delivery_id"])
+	if err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := &webhookDeliveryDetailsBody{
+		Delivery: newWebhookDeliveryBody(delivery),
+		Attempts: make([]*webhookDeliveryAttemptBody, 0, len(attempts)),
+	}
+	for _, attempt := range attempts {
+		resp.Attempts = append(resp.Attempts, &webhookDeliveryAttemptBody{
+			AttemptedAt: attempt.AttemptedAt,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMS:  attempt.DurationMS,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// GetWebhookSubscription возвращает одну подписку.
+// @Summary      Подписка на webhooks
+// @Tags         webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        subscription_id  path      string  true  "ID подписки"
+// @Success      200              {object}  webhookSubscriptionBody
+// @Failure      401              {string}  string  "unauthorized"
+// @Failure      403              {string}  string  "insufficient scope"
+// @Failure      404              {string}  string  "subscription not found"
+// @Router       /api/v1/admin/webhooks/subscriptions/{subscription_id} [get]
+func (httpHandler *HttpHandler) GetWebhookSubscription(w http.ResponseWriter, req *http.Request) {
+	subscription, err := httpHandler.webhookService.GetSubscription(req.Context(), mux.Vars(req)["subscription_id"])
+	if err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newWebhookSubscriptionBody(subscription))
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 )
 
@@ -26,6 +25,6 @@ func (httpHandler *HttpHandler) Guid(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("Guid: failed to write response: %v", err)
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
 	}
 }
@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
 	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type accessTokenBody struct {
@@ -16,35 +23,277 @@ type accessAndRefreshTokensBody struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type credentialsBody struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// mfaChallengeBody — первый фактор пройден, пару токенов выдаст /api/v1/auth/mfa/verify
+type mfaChallengeBody struct {
+	Error     string `json:"error"`
+	MFAToken  string `json:"mfa_token"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+type mfaVerifyBody struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type totpCodeBody struct {
+	Code string `json:"code"`
+}
+
+type totpEnrollmentBody struct {
+	OTPAuthURI string `json:"otpauth_uri"`
+	Secret     string `json:"secret"`
+}
+
 type userIDBody struct {
 	UserID string `json:"user_id"`
 }
 
-func getIP(r *http.Request) (string, error) {
-	ips := r.Header.Get("X-Forwarded-For")
-	splitIps := strings.Split(ips, ",")
+type sessionBody struct {
+	SessionID  string    `json:"session_id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddr     string    `json:"ip_addr"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+type sessionsBody struct {
+	Sessions []sessionBody `json:"sessions"`
+}
+
+type webhookSubscriptionRequestBody struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`
+}
+
+// webhookSubscriptionUpdateBody — изменяются только переданные поля
+type webhookSubscriptionUpdateBody struct {
+	URL        *string   `json:"url"`
+	EventTypes *[]string `json:"event_types"`
+	Secret     *string   `json:"secret"`
+	Status     *string   `json:"status"`
+}
+
+// webhookSubscriptionBody — ключ подписи отдаётся только в ответе на создание подписки
+type webhookSubscriptionBody struct {
+	SubscriptionID string    `json:"subscription_id"`
+	URL            string    `json:"url"`
+	EventTypes     []string  `json:"event_types"`
+	Status         string    `json:"status"`
+	Secret         string    `json:"secret,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type webhookSubscriptionsBody struct {
+	Subscriptions []*webhookSubscriptionBody `json:"subscriptions"`
+}
+
+type webhookDeliveryBody struct {
+	DeliveryID     string     `json:"delivery_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	SubscriptionID string     `json:"subscription_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type webhookDeliveriesBody struct {
+	Deliveries []*webhookDeliveryBody `json:"deliveries"`
+}
+
+type webhookDeliveryAttemptBody struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+}
+
+type webhookDeliveryDetailsBody struct {
+	Delivery *webhookDeliveryBody          `json:"delivery"`
+	Attempts []*webhookDeliveryAttemptBody `json:"attempts"`
+}
+
+type auditEventBody struct {
+	EventType string    `json:"event_type"`
+	Actor     string    `json:"actor"`
+	UserID    string    `json:"user_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	IPAddr    string    `json:"ip_addr"`
+	UserAgent string    `json:"user_agent"`
+	Outcome   string    `json:"outcome"`
+	ErrorCode string    `json:"error_code,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type auditEventsBody struct {
+	Events     []*auditEventBody `json:"events"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+// oauthErrorBody — ошибка в формате RFC 6749, раздел 5.2
+type oauthErrorBody struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+	}
+	writeOAuthJSON(w, status, &oauthErrorBody{Error: code, ErrorDescription: description})
+}
+
+// writeOAuthJSON — ответы OAuth эндпоинтов не должны кэшироваться (RFC 6749, раздел 5.1)
+func writeOAuthJSON(w http.ResponseWriter, status int, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+
+	// ошибку записи запоминает statusRecorder, она попадёт в лог запроса
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// writeWebhookError переводит ошибки сервиса webhooks в HTTP статусы
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrWebhookSubscriptionNotFound),
+		errors.Is(err, apperrors.ErrWebhookDeliveryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrInvalidWebhookURL),
+		errors.Is(err, apperrors.ErrInvalidWebhookEventType),
+		errors.Is(err, apperrors.ErrInvalidWebhookStatus):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func newWebhookSubscriptionBody(subscription *webhook_subscriptions.WebhookSubscriptions) *webhookSubscriptionBody {
+	// пустой фильтр — все события
+	eventTypes := strings.Fields(subscription.EventTypes)
+	if len(eventTypes) == 0 {
+		eventTypes = []string{"*"}
+	}
 
-	if len(splitIps) > 0 {
-		// get last IP in list since ELB prepends other user defined IPs, meaning the last one is the actual client IP.
-		netIP := net.ParseIP(splitIps[len(splitIps)-1])
-		if netIP != nil {
-			return netIP.String(), nil
+	return &webhookSubscriptionBody{
+		SubscriptionID: subscription.SubscriptionID,
+		URL:            subscription.URL,
+		EventTypes:     eventTypes,
+		Status:         subscription.Status,
+		CreatedAt:      subscription.CreatedAt,
+		UpdatedAt:      subscription.UpdatedAt,
+	}
+}
+
+func newWebhookDeliveryBody(delivery *webhook_deliveries.WebhookDeliveries) *webhookDeliveryBody {
+	return &webhookDeliveryBody{
+		DeliveryID:     delivery.DeliveryID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		SubscriptionID: delivery.SubscriptionID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+}
+
+// writeMFAChallenge отвечает 401 с MFA токеном, если сервис вместо пары токенов потребовал второй фактор
+func writeMFAChallenge(w http.ResponseWriter, err error) bool {
+	var mfaErr *apperrors.MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusUnauthorized)
+
+	resp := &mfaChallengeBody{
+		Error:     "mfa_required",
+		MFAToken:  mfaErr.MFAToken,
+		ExpiresIn: int64(mfaErr.ExpiresIn.Seconds()),
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+	return true
+}
+
+// getClientCredentials достаёт client_id и client_secret из Basic авторизации
+// (client_secret_basic) или из тела формы (client_secret_post)
+func getClientCredentials(req *http.Request) (string, string) {
+	if clientID, clientSecret, ok := req.BasicAuth(); ok {
+		// RFC 6749, раздел 2.3.1: перед base64 значения кодируются как form-urlencoded
+		if unescaped, err := url.QueryUnescape(clientID); err == nil {
+			clientID = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(clientSecret); err == nil {
+			clientSecret = unescaped
 		}
+		return clientID, clientSecret
 	}
+	return req.PostFormValue("client_id"), req.PostFormValue("client_secret")
+}
 
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+// getIP возвращает IP клиента. X-Forwarded-For учитывается, только если соединение пришло от доверенного прокси:
+// список разбирается справа налево, доверенные прокси пропускаются, и клиентом считается первый недоверенный адрес.
+// Иначе клиент подставил бы в заголовок любой IP и обошёл лимиты по IP.
+func (httpHandler *HttpHandler) getIP(r *http.Request) (string, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
 		return "", err
 	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return "", errors.New("IP not found")
+	}
+	ip = ip.Unmap()
 
-	netIP := net.ParseIP(ip)
-	if netIP != nil {
-		ip := netIP.String()
-		if ip == "::1" {
-			return "127.0.0.1", nil
+	if httpHandler.isTrustedProxy(ip) {
+		forwardedFor := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(forwardedFor) - 1; i >= 0; i-- {
+			forwardedIP, err := netip.ParseAddr(strings.TrimSpace(forwardedFor[i]))
+			if err != nil {
+				break
+			}
+			ip = forwardedIP.Unmap()
+			if !httpHandler.isTrustedProxy(ip) {
+				break
+			}
 		}
-		return ip, nil
 	}
 
-	return "", errors.New("IP not found")
+	if ip.IsLoopback() && ip.Is6() {
+		return "127.0.0.1", nil
+	}
+	return ip.String(), nil
+}
+
+func (httpHandler *HttpHandler) isTrustedProxy(ip netip.Addr) bool {
+	for _, prefix := range httpHandler.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
 }
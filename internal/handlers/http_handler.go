@@ -2,31 +2,86 @@ package handlers
 
 import (
 	_ "github.com/Turalchik/authentication-service/docs"
+	"github.com/Turalchik/authentication-service/internal/logging"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"log/slog"
 	"net/http"
+	"net/netip"
 )
 
 type HttpHandler struct {
-	authService AuthService
-	router      *mux.Router
+	authService    AuthService
+	webhookService WebhookService
+	auditService   AuditService
+	router         *mux.Router
+
+	// rateLimitStore может быть nil — тогда лимиты не применяются
+	rateLimitStore RateLimitStore
+	rateLimits     RateLimits
+
+	// trustedProxies — сети прокси и балансировщиков, которым доверяется X-Forwarded-For; пусто — IP берётся из соединения
+	trustedProxies []netip.Prefix
+
+	logger *slog.Logger
+
+	// metrics может быть nil — тогда время обработки запросов не учитывается
+	metrics Metrics
 }
 
-func NewHttpHandler(authService AuthService) *HttpHandler {
+func NewHttpHandler(authService AuthService, webhookService WebhookService, auditService AuditService, rateLimitStore RateLimitStore, rateLimits RateLimits, trustedProxies []netip.Prefix, logger *slog.Logger, metrics Metrics) *HttpHandler {
 	router := mux.NewRouter()
 	httpHandler := &HttpHandler{
-		authService: authService,
-		router:      router,
+		authService:    authService,
+		webhookService: webhookService,
+		auditService:   auditService,
+		router:         router,
+		rateLimitStore: rateLimitStore,
+		rateLimits:     rateLimits,
+		trustedProxies: trustedProxies,
+		logger:         logging.OrDiscard(logger),
+		metrics:        metrics,
 	}
+	router.Use(httpHandler.RouteMiddleware, httpHandler.RateLimitMiddleware)
 
 	router.HandleFunc("/api/v1/auth/tokens", httpHandler.CreateTokens).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/auth/refresh", httpHandler.RefreshTokens).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/auth/register", httpHandler.Register).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/auth/login", httpHandler.Login).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/auth/mfa/verify", httpHandler.VerifyMFA).Methods(http.MethodPost)
+	router.HandleFunc("/.well-known/jwks.json", httpHandler.JWKS).Methods(http.MethodGet)
+	router.HandleFunc("/oauth2/introspect", httpHandler.IntrospectToken).Methods(http.MethodPost)
+	router.HandleFunc("/oauth2/revoke", httpHandler.RevokeToken).Methods(http.MethodPost)
+	router.HandleFunc("/oauth2/token", httpHandler.Token).Methods(http.MethodPost)
+	router.HandleFunc("/oauth2/authorize", httpHandler.Authorize).Methods(http.MethodGet)
+	router.HandleFunc("/oauth2/authorize", httpHandler.AuthorizeWithPassword).Methods(http.MethodPost)
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
 	protectedRouter := router.PathPrefix("/api/v1/auth").Subrouter()
-	protectedRouter.Use(httpHandler.AuthMiddleware)
+	protectedRouter.Use(httpHandler.AuthMiddleware, httpHandler.UserRateLimitMiddleware)
 	protectedRouter.HandleFunc("/logout", httpHandler.Logout).Methods(http.MethodPost)
+	protectedRouter.HandleFunc("/logout/others", httpHandler.LogoutOthers).Methods(http.MethodPost)
+	protectedRouter.HandleFunc("/sessions", httpHandler.ListSessions).Methods(http.MethodGet)
+	protectedRouter.HandleFunc("/sessions/{session_id}", httpHandler.RevokeSession).Methods(http.MethodDelete)
+	protectedRouter.HandleFunc("/mfa/totp", httpHandler.EnrollTOTP).Methods(http.MethodPost)
+	protectedRouter.HandleFunc("/mfa/totp/confirm", httpHandler.ConfirmTOTP).Methods(http.MethodPost)
 	protectedRouter.HandleFunc("/guid", httpHandler.Guid).Methods(http.MethodGet)
 
+	adminRouter := router.PathPrefix("/api/v1/admin/webhooks").Subrouter()
+	adminRouter.Use(httpHandler.AdminMiddleware(webhooksAdminScope))
+	adminRouter.HandleFunc("/subscriptions", httpHandler.CreateWebhookSubscription).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/subscriptions", httpHandler.ListWebhookSubscriptions).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/subscriptions/{subscription_id}", httpHandler.GetWebhookSubscription).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/subscriptions/{subscription_id}", httpHandler.UpdateWebhookSubscription).Methods(http.MethodPatch)
+	adminRouter.HandleFunc("/subscriptions/{subscription_id}", httpHandler.DeleteWebhookSubscription).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/subscriptions/{subscription_id}/deliveries", httpHandler.ListWebhookDeliveries).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/deliveries/{delivery_id}", httpHandler.GetWebhookDelivery).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/deliveries/{delivery_id}/replay", httpHandler.ReplayWebhookDelivery).Methods(http.MethodPost)
+
+	auditRouter := router.PathPrefix("/api/v1/admin/audit").Subrouter()
+	auditRouter.Use(httpHandler.AdminMiddleware(auditReadScope))
+	auditRouter.HandleFunc("/events", httpHandler.ListAuditEvents).Methods(http.MethodGet)
+	auditRouter.HandleFunc("/export", httpHandler.ExportAuditLog).Methods(http.MethodGet)
+
 	return httpHandler
 }
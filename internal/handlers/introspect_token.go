@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// IntrospectToken сообщает, активен ли токен (RFC 7662).
+// @Summary      Token introspection
+// @Description  Проверяет access‑токен (подпись, срок жизни, black‑list) по запросу аутентифицированного OAuth клиента. Клиент передаёт client_id и client_secret через HTTP Basic или в теле формы.
+// @Tags         oauth2
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token            formData  string  true   "Проверяемый токен"
+// @Param        token_type_hint  formData  string  false  "access_token или refresh_token"
+// @Param        client_id        formData  string  false  "ID клиента, если не используется Basic"
+// @Param        client_secret    formData  string  false  "Секрет клиента, если не используется Basic"
+// @Success      200  {object}  token_introspection.TokenIntrospection
+// @Failure      400  {object}  oauthErrorBody  "invalid_request"
+// @Failure      401  {object}  oauthErrorBody  "invalid_client"
+// @Failure      500  {object}  oauthErrorBody  "server_error"
+// @Router       /oauth2/introspect [post]
+func (httpHandler *HttpHandler) IntrospectToken(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed form body")
+		return
+	}
+
+	token := req.PostFormValue("token")
+	if token == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "token required")
+		return
+	}
+
+	clientID, clientSecret := getClientCredentials(req)
+	introspection, err := httpHandler.authService.IntrospectToken(req.Context(), clientID, clientSecret, token)
+	if err != nil {
+		recordError(req, err)
+		if errors.Is(err, apperrors.ErrInvalidClient) {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "")
+			return
+		}
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	writeOAuthJSON(w, http.StatusOK, introspection)
+}
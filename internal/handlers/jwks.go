@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// JWKS публикует публичные ключи для офлайн‑проверки access‑токенов.
+// @Summary      JSON Web Key Set
+// @Description  Возвращает публичные ключи (RFC 7517), которыми подписываются access‑токены. При подписи общим секретом (HS512) набор пустой.
+// @Tags         keys
+// @Produce      json
+// @Success      200  {object}  token_signer.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (httpHandler *HttpHandler) JWKS(w http.ResponseWriter, req *http.Request) {
+	resp := httpHandler.authService.JWKS()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ListAuditEvents возвращает страницу журнала аутентификации.
+// @Summary      Журнал аутентификации
+// @Description  Выдача токенов, вход, MFA, refresh и logout, включая неудачные попытки, новые записи первыми. Фильтры необязательны; event_type можно передать несколько раз. Следующая страница — тот же запрос с cursor=next_cursor; пустой next_cursor — записей больше нет. Нужен токен сервиса со scope audit:read.
+// @Tags         audit
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        from        query     string  false  "Начало интервала, RFC3339, включительно"
+// @Param        to          query     string  false  "Конец интервала, RFC3339, не включительно"
+// @Param        user_id     query     string  false  "ID пользователя"
+// @Param        event_type  query     string  false  "login, mfa.verify, tokens.issued, tokens.refreshed или logout"
+// @Param        cursor      query     string  false  "Курсор из next_cursor предыдущей страницы"
+// @Param        limit       query     int     false  "Размер страницы, по умолчанию 100, не больше 1000"
+// @Success      200         {object}  auditEventsBody
+// @Failure      400         {string}  string  "invalid filter or cursor"
+// @Failure      401         {string}  string  "unauthorized"
+// @Failure      403         {string}  string  "insufficient scope"
+// @Failure      500         {string}  string  "can't get audit events"
+// @Router       /api/v1/admin/audit/events [get]
+func (httpHandler *HttpHandler) ListAuditEvents(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	filter := &audit_events.AuditEventFilter{
+		UserID:     query.Get("user_id"),
+		EventTypes: query["event_type"],
+	}
+
+	var err error
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid from", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid to", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	events, nextCursor, err := httpHandler.auditService.ListEvents(req.Context(), filter, query.Get("cursor"))
+	if err != nil {
+		recordError(req, err)
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidAuditCursor),
+			errors.Is(err, apperrors.ErrInvalidAuditEventType),
+			errors.Is(err, apperrors.ErrInvalidAuditTimeRange):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "can't get audit events", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := &auditEventsBody{
+		Events:     make([]*auditEventBody, 0, len(events)),
+		NextCursor: nextCursor,
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, &auditEventBody{
+			EventType: event.EventType,
+			Actor:     event.Actor,
+			UserID:    event.UserID,
+			SessionID: event.SessionID,
+			IPAddr:    event.IPAddr,
+			UserAgent: event.UserAgent,
+			Outcome:   event.Outcome,
+			ErrorCode: event.ErrorCode,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
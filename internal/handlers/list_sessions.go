@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ListSessions возвращает активные сессии текущего пользователя.
+// @Summary      Список сессий
+// @Description  Возвращает все активные сессии пользователя (устройства), отмечая текущую флагом current.
+// @Tags         sessions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  sessionsBody
+// @Failure      401  {string}  string  "unauthorized"
+// @Failure      500  {string}  string  "can't list sessions"
+// @Router       /api/v1/auth/sessions [get]
+func (httpHandler *HttpHandler) ListSessions(w http.ResponseWriter, req *http.Request) {
+	args := req.Context().Value("args").(map[string]string)
+
+	userSessions, err := httpHandler.authService.ListSessions(req.Context(), args["userID"])
+	if err != nil {
+		recordError(req, err)
+		http.Error(w, "can't list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	resp := &sessionsBody{
+		Sessions: make([]sessionBody, 0, len(userSessions)),
+	}
+	for _, session := range userSessions {
+		resp.Sessions = append(resp.Sessions, sessionBody{
+			SessionID:  session.SessionID,
+			UserAgent:  session.UserAgent,
+			IPAddr:     session.IPAddr,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.SessionID == args["sessionID"],
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
+	}
+}
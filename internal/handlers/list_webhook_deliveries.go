@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// ListWebhookDeliveries возвращает последние доставки подписки.
+// @Summary      Доставки подписки
+// @Description  Последние доставки событий подписке, новые первыми. status=dead — доставки, исчерпавшие попытки, их можно повторить через replay. Нужен токен сервиса со scope webhooks:admin.
+// @Tags         webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        subscription_id  path      string  true   "ID подписки"
+// @Param        status           query     string  false  "pending, delivered или dead"
+// @Param        limit            query     int     false  "Сколько доставок вернуть, по умолчанию 50, не больше 500"
+// @Success      200              {object}  webhookDeliveriesBody
+// @Failure      400              {string}  string  "invalid status"
+// @Failure      401              {string}  string  "unauthorized"
+// @Failure      403              {string}  string  "insufficient scope"
+// @Failure      404              {string}  string  "subscription not found"
+// @Router       /api/v1/admin/webhooks/subscriptions/{subscription_id}/deliveries [get]
+func (httpHandler *HttpHandler) ListWebhookDeliveries(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := httpHandler.webhookService.ListDeliveries(req.Context(), mux.Vars(req)["subscription_id"], query.Get("status"), limit)
+	if err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := &webhookDeliveriesBody{
+		Deliveries: make([]*webhookDeliveryBody, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, newWebhookDeliveryBody(delivery))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// ListWebhookSubscriptions возвращает все подписки на webhooks.
+// @Summary      Список подписок на webhooks
+// @Description  Возвращает подписки с фильтрами событий и статусом (active или paused), без ключей подписи. Нужен токен сервиса со scope webhooks:admin.
+// @Tags         webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  webhookSubscriptionsBody
+// @Failure      401  {string}  string  "unauthorized"
+// @Failure      403  {string}  string  "insufficient scope"
+// @Failure      500  {string}  string  "can't get subscriptions"
+// @Router       /api/v1/admin/webhooks/subscriptions [get]
+func (httpHandler *HttpHandler) ListWebhookSubscriptions(w http.ResponseWriter, req *http.Request) {
+	subscriptions, err := httpHandler.webhookService.ListSubscriptions(req.Context())
+	if err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	resp := &webhookSubscriptionsBody{
+		Subscriptions: make([]*webhookSubscriptionBody, 0, len(subscriptions)),
+	}
+	for _, subscription := range subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, newWebhookSubscriptionBody(subscription))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
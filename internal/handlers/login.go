@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// Login выдаёт пару токенов после проверки email и пароля.
+// @Summary      Вход по паролю
+// @Description  Проверяет email и пароль и открывает новую сессию с парой токенов. Если у пользователя включён TOTP, вместо пары возвращается 401 mfa_required с MFA токеном для /api/v1/auth/mfa/verify.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      credentialsBody  true  "Email и пароль"
+// @Success      200   {object}  accessAndRefreshTokensBody
+// @Failure      400   {string}  string  "Invalid request body"
+// @Failure      401   {object}  mfaChallengeBody  "mfa_required; при неверном email или пароле — текст invalid email or password"
+// @Failure      500   {string}  string  "can't login"
+// @Router       /api/v1/auth/login [post]
+func (httpHandler *HttpHandler) Login(w http.ResponseWriter, req *http.Request) {
+	body := credentialsBody{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	userAgent := req.UserAgent()
+	ipAddr, _ := httpHandler.getIP(req)
+
+	accessToken, refreshToken, err := httpHandler.authService.Login(req.Context(), body.Email, body.Password, userAgent, ipAddr)
+	if err != nil {
+		recordError(req, err)
+		if writeMFAChallenge(w, err) {
+			return
+		}
+		if errors.Is(err, apperrors.ErrInvalidCredentials) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "can't login", http.StatusInternalServerError)
+		return
+	}
+
+	resp := &accessAndRefreshTokensBody{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"net/http"
 )
 
-// Logout разлогинивает текущего пользователя, отзывая его refresh‑токен.
+// Logout завершает текущую сессию пользователя, отзывая её refresh‑токен.
 // @Summary      Выход пользователя (logout)
-// @Description  Инвалидирует refresh‑токен текущего пользователя, после чего refresh и protected‑маршруты недоступны.
+// @Description  Инвалидирует refresh‑токен текущей сессии, после чего refresh и protected‑маршруты с этим access‑токеном недоступны. Остальные сессии пользователя не затрагиваются.
 // @Tags         auth
 // @Security     ApiKeyAuth
 // @Success      204  {string}  string  "No Content"
@@ -16,7 +16,11 @@ import (
 func (httpHandler *HttpHandler) Logout(w http.ResponseWriter, req *http.Request) {
 	args := req.Context().Value("args").(map[string]string)
 
-	if err := httpHandler.authService.Logout(args["accessToken"], args["userID"]); err != nil {
+	userAgent := req.UserAgent()
+	ipAddr, _ := httpHandler.getIP(req)
+
+	if err := httpHandler.authService.Logout(req.Context(), args["accessToken"], args["sessionID"], userAgent, ipAddr); err != nil {
+		recordError(req, err)
 		// TODO
 		// тут тоже нужно распарсить ошибки дружище
 		http.Error(w, "invalid access token", http.StatusBadRequest)
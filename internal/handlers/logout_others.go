@@ -0,0 +1,24 @@
+package handlers
+
+import "net/http"
+
+// LogoutOthers завершает все сессии пользователя, кроме текущей.
+// @Summary      Выход на всех остальных устройствах
+// @Description  Удаляет refresh‑токены и отзывает access‑токены всех сессий пользователя, кроме той, с которой пришёл запрос.
+// @Tags         sessions
+// @Security     ApiKeyAuth
+// @Success      204  {string}  string  "No Content"
+// @Failure      401  {string}  string  "unauthorized"
+// @Failure      500  {string}  string  "can't revoke sessions"
+// @Router       /api/v1/auth/logout/others [post]
+func (httpHandler *HttpHandler) LogoutOthers(w http.ResponseWriter, req *http.Request) {
+	args := req.Context().Value("args").(map[string]string)
+
+	if err := httpHandler.authService.RevokeOtherSessions(req.Context(), args["userID"], args["sessionID"]); err != nil {
+		recordError(req, err)
+		http.Error(w, "can't revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,7 @@
+package handlers
+
+import "time"
+
+type Metrics interface {
+	ObserveHTTPRequest(method string, route string, status int, duration time.Duration)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit — не больше Limit запросов за скользящее окно Window; нулевой Limit — без ограничения
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+type RateLimits struct {
+	// PerIP — на все ручки с одного IP
+	PerIP RateLimit
+	// PerUser — на все ручки от одного user_id (из access токена или из query у GET /api/v1/auth/tokens)
+	PerUser RateLimit
+	// PerSession — на POST /api/v1/auth/refresh по session_id из refresh токена: перебор секрета одной сессии
+	PerSession RateLimit
+	// PerRoute — на конкретную ручку с одного IP; ключ — шаблон пути mux, например /api/v1/auth/refresh
+	PerRoute map[string]RateLimit
+}
+
+type rateLimitCheck struct {
+	key   string
+	limit RateLimit
+}
+
+// RateLimitMiddleware ограничивает частоту запросов по IP, по ручке и по user_id из query.
+// Подключается ко всему роутеру; лимит по пользователю из access токена — UserRateLimitMiddleware.
+func (httpHandler *HttpHandler) RateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ipAddr, _ := httpHandler.getIP(req)
+		checks := []rateLimitCheck{{key: "ip:" + ipAddr, limit: httpHandler.rateLimits.PerIP}}
+
+		if route := mux.CurrentRoute(req); route != nil {
+			if pathTemplate, err := route.GetPathTemplate(); err == nil {
+				if limit, ok := httpHandler.rateLimits.PerRoute[pathTemplate]; ok {
+					checks = append(checks, rateLimitCheck{key: "route:" + pathTemplate + ":ip:" + ipAddr, limit: limit})
+				}
+			}
+		}
+
+		// выдача токенов по голому user_id: ограничиваем перебор по конкретному пользователю
+		if userID := req.URL.Query().Get("user_id"); userID != "" {
+			checks = append(checks, rateLimitCheck{key: "user:" + userID, limit: httpHandler.rateLimits.PerUser})
+		}
+
+		if !httpHandler.allowRequest(w, req, checks) {
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// UserRateLimitMiddleware ограничивает частоту запросов пользователя; ставится после AuthMiddleware
+func (httpHandler *HttpHandler) UserRateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		args := req.Context().Value("args").(map[string]string)
+
+		checks := []rateLimitCheck{{key: "user:" + args["userID"], limit: httpHandler.rateLimits.PerUser}}
+		if !httpHandler.allowRequest(w, req, checks) {
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// allowRequest учитывает запрос во всех окнах и отвечает 429 с Retry-After, если хотя бы одно переполнено.
+// Если хранилище недоступно, запрос пропускается: лимиты не должны останавливать вход пользователей.
+func (httpHandler *HttpHandler) allowRequest(w http.ResponseWriter, req *http.Request, checks []rateLimitCheck) bool {
+	if httpHandler.rateLimitStore == nil {
+		return true
+	}
+
+	for _, check := range checks {
+		if check.limit.Limit <= 0 {
+			continue
+		}
+
+		allowed, retryAfter, err := httpHandler.rateLimitStore.Allow(req.Context(), check.key, check.limit.Limit, check.limit.Window)
+		if err != nil {
+			httpHandler.logger.WarnContext(req.Context(), "rate limit check failed", "key", check.key, "error", err)
+			continue
+		}
+		if !allowed {
+			// Retry-After в целых секундах, округляем вверх
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
 	"net/http"
+	"strings"
 )
 
 // RefreshTokens обновляет пару токенов по существующему access + refresh.
@@ -17,6 +19,7 @@ import (
 // @Success      200   {object}  accessAndRefreshTokensBody
 // @Failure      400   {string}  string  "Invalid request body or tokens"
 // @Failure      401   {string}  string  "Unauthorized or token revoked"
+// @Failure      429   {string}  string  "too many requests"
 // @Router       /api/v1/auth/tokens/refresh [post]
 func (httpHandler *HttpHandler) RefreshTokens(w http.ResponseWriter, req *http.Request) {
 	body := accessAndRefreshTokensBody{}
@@ -25,14 +28,22 @@ func (httpHandler *HttpHandler) RefreshTokens(w http.ResponseWriter, req *http.R
 		return
 	}
 
+	// refresh токен — <session_id>.<секрет>; у токенов старого формата без точки сессия не известна
+	if sessionID, _, found := strings.Cut(body.RefreshToken, "."); found && sessionID != "" {
+		checks := []rateLimitCheck{{key: "session:" + sessionID, limit: httpHandler.rateLimits.PerSession}}
+		if !httpHandler.allowRequest(w, req, checks) {
+			return
+		}
+	}
+
 	userAgent := req.UserAgent()
-	ipAddr, _ := getIP(req)
+	ipAddr, _ := httpHandler.getIP(req)
 
-	accessToken, refreshToken, err := httpHandler.authService.RefreshTokens(body.AccessToken, body.RefreshToken, userAgent, ipAddr)
+	accessToken, refreshToken, err := httpHandler.authService.RefreshTokens(req.Context(), body.AccessToken, body.RefreshToken, userAgent, ipAddr)
 	if err != nil {
-		// TODO
-		// распарсить ошибки
-		http.Error(w, fmt.Sprintf("Invalid request: %s", err.Error()), http.StatusUnauthorized)
+		recordError(req, err)
+		http.Error(w, fmt.Sprintf("Invalid request: %s", err.Error()), refreshErrorStatus(err))
+		return
 	}
 
 	resp := &accessAndRefreshTokensBody{
@@ -44,6 +55,18 @@ func (httpHandler *HttpHandler) RefreshTokens(w http.ResponseWriter, req *http.R
 	w.WriteHeader(http.StatusOK)
 
 	if err = json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("CreateTokens: failed to write response: %v", err)
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
+	}
+}
+
+func refreshErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidToken),
+		errors.Is(err, apperrors.ErrTokensDontMatch),
+		errors.Is(err, apperrors.ErrRefreshTokenReused),
+		errors.Is(err, apperrors.ErrSessionNotFound):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadRequest
 	}
 }
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// Register создаёт учётную запись пользователя.
+// @Summary      Регистрация
+// @Description  Создаёт пользователя с email и паролем (не короче 8 символов). Пароль хранится argon2id хешем.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      credentialsBody  true  "Email и пароль"
+// @Success      201   {object}  userIDBody
+// @Failure      400   {string}  string  "invalid email or weak password"
+// @Failure      409   {string}  string  "user already exists"
+// @Failure      500   {string}  string  "can't create user"
+// @Router       /api/v1/auth/register [post]
+func (httpHandler *HttpHandler) Register(w http.ResponseWriter, req *http.Request) {
+	body := credentialsBody{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := httpHandler.authService.Register(req.Context(), body.Email, body.Password)
+	if err != nil {
+		recordError(req, err)
+		switch {
+		case errors.Is(err, apperrors.ErrInvalidEmail), errors.Is(err, apperrors.ErrWeakPassword):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, apperrors.ErrUserAlreadyExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "can't create user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(&userIDBody{UserID: userID}); err != nil {
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
+	}
+}
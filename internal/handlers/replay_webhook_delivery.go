@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// ReplayWebhookDelivery ставит доставку в очередь заново.
+// @Summary      Повтор доставки
+// @Description  Возвращает доставку в очередь с чистым счётчиком попыток, например dead доставку после починки получателя. Журнал прошлых попыток сохраняется. Нужен токен сервиса со scope webhooks:admin.
+// @Tags         webhooks
+// @Security     ApiKeyAuth
+// @Param        delivery_id  path      string  true  "ID доставки"
+// @Success      202          {string}  string  "Accepted"
+// @Failure      401          {string}  string  "unauthorized"
+// @Failure      403          {string}  string  "insufficient scope"
+// @Failure      404          {string}  string  "delivery not found"
+// @Failure      500          {string}  string  "can't replay delivery"
+// @Router       /api/v1/admin/webhooks/deliveries/{delivery_id}/replay [post]
+func (httpHandler *HttpHandler) ReplayWebhookDelivery(w http.ResponseWriter, req *http.Request) {
+	if err := httpHandler.webhookService.ReplayDelivery(req.Context(), mux.Vars(req)["delivery_id"]); err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// RevokeSession завершает одну из сессий текущего пользователя.
+// @Summary      Завершение сессии
+// @Description  Удаляет refresh‑токен указанной сессии и отзывает её access‑токены. Можно завершить и текущую сессию.
+// @Tags         sessions
+// @Security     ApiKeyAuth
+// @Param        session_id  path      string  true  "ID сессии"
+// @Success      204         {string}  string  "No Content"
+// @Failure      401         {string}  string  "unauthorized"
+// @Failure      404         {string}  string  "session not found"
+// @Failure      500         {string}  string  "can't revoke session"
+// @Router       /api/v1/auth/sessions/{session_id} [delete]
+func (httpHandler *HttpHandler) RevokeSession(w http.ResponseWriter, req *http.Request) {
+	args := req.Context().Value("args").(map[string]string)
+	sessionID := mux.Vars(req)["session_id"]
+
+	if err := httpHandler.authService.RevokeSession(req.Context(), args["userID"], sessionID); err != nil {
+		recordError(req, err)
+		if errors.Is(err, apperrors.ErrSessionNotFound) {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "can't revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// RevokeToken отзывает access или refresh токен (RFC 7009).
+// @Summary      Token revocation
+// @Description  Отзывает access‑токен (black‑list) или refresh‑токен (завершает его сессию) по запросу аутентифицированного OAuth клиента. Неизвестный или уже отозванный токен тоже даёт 200.
+// @Tags         oauth2
+// @Accept       x-www-form-urlencoded
+// @Param        token            formData  string  true   "Отзываемый токен"
+// @Param        token_type_hint  formData  string  false  "access_token или refresh_token"
+// @Param        client_id        formData  string  false  "ID клиента, если не используется Basic"
+// @Param        client_secret    formData  string  false  "Секрет клиента, если не используется Basic"
+// @Success      200  "Токен отозван или не найден"
+// @Failure      400  {object}  oauthErrorBody  "invalid_request"
+// @Failure      401  {object}  oauthErrorBody  "invalid_client"
+// @Failure      500  {object}  oauthErrorBody  "server_error"
+// @Router       /oauth2/revoke [post]
+func (httpHandler *HttpHandler) RevokeToken(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed form body")
+		return
+	}
+
+	token := req.PostFormValue("token")
+	if token == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "token required")
+		return
+	}
+
+	clientID, clientSecret := getClientCredentials(req)
+	err := httpHandler.authService.RevokeToken(req.Context(), clientID, clientSecret, token, req.PostFormValue("token_type_hint"))
+	if err != nil {
+		recordError(req, err)
+		if errors.Is(err, apperrors.ErrInvalidClient) {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "")
+			return
+		}
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
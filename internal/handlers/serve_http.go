@@ -1,7 +1,152 @@
 package handlers
 
-import "net/http"
+import (
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
 
+// requestIDPattern — X-Request-ID от балансировщика принимаем, только если он не сломает лог
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+var tracer = otel.Tracer("github.com/Turalchik/authentication-service/internal/handlers")
+
+// unmatchedRoute — метка маршрута для запросов, которые не совпали ни с одним маршрутом
+const unmatchedRoute = "unmatched"
+
+// ServeHTTP открывает серверный span запроса, продолжая трассу из traceparent, и пишет одну строку лога
+// на запрос: request_id, маршрут, владелец токена, статус, время обработки и причина ошибки,
+// если обработчик её сообщил через recordError
 func (httpHandler *HttpHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	httpHandler.router.ServeHTTP(w, req)
+	start := time.Now()
+
+	requestID := req.Header.Get("X-Request-ID")
+	if !requestIDPattern.MatchString(requestID) {
+		requestID = uuid.NewString()
+	}
+	w.Header().Set("X-Request-ID", requestID)
+
+	// имя span уточняется шаблоном маршрута, когда он станет известен
+	ctx, span := tracer.Start(tracing.Extract(req.Context(), req.Header), req.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+			attribute.String("http.request_id", requestID),
+		))
+	defer span.End()
+
+	ctx, fields := logging.NewContext(ctx, requestID)
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	httpHandler.router.ServeHTTP(recorder, req.WithContext(ctx))
+	latency := time.Since(start)
+
+	endRequestSpan(span, req.Method, fields.Route(), recorder.status, fields.Err())
+
+	if httpHandler.metrics != nil {
+		// у несовпавших запросов шаблона нет, а путь в метку брать нельзя — сканеры раздуют число серий
+		route := fields.Route()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		httpHandler.metrics.ObserveHTTPRequest(req.Method, route, recorder.status, latency)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.Int("status", recorder.status),
+		slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+	}
+	if fields.Route() == "" {
+		attrs = append(attrs, slog.String("path", req.URL.Path))
+	}
+	if err := fields.Err(); err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		if cause := apperrors.Cause(err); cause != nil {
+			attrs = append(attrs, slog.String("cause", cause.Error()))
+		}
+	}
+	if recorder.writeErr != nil {
+		attrs = append(attrs, slog.String("write_error", recorder.writeErr.Error()))
+	}
+
+	level := slog.LevelInfo
+	if recorder.status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	httpHandler.logger.LogAttrs(ctx, level, "http request", attrs...)
+}
+
+// RouteMiddleware кладёт в поля лога шаблон маршрута, а не путь: id в пути не размножают маршруты
+func (httpHandler *HttpHandler) RouteMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if route := mux.CurrentRoute(req); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				logging.SetRoute(req.Context(), template)
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// endRequestSpan дописывает в span маршрут и исход запроса. Ошибкой span отмечается только при 5xx:
+// 4xx — штатный ответ сервера на неверный запрос.
+func endRequestSpan(span trace.Span, method string, route string, status int, err error) {
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if route != "" {
+		span.SetName(method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// recordError сообщает логу запроса ошибку сервиса вместе с причиной, которую клиент не видит
+func recordError(req *http.Request, err error) {
+	logging.SetError(req.Context(), err)
+}
+
+// statusRecorder запоминает статус ответа и ошибку записи: клиент мог уйти, не дочитав ответ
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	writeErr    error
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(p []byte) (int, error) {
+	recorder.wroteHeader = true
+	n, err := recorder.ResponseWriter.Write(p)
+	if err != nil && recorder.writeErr == nil {
+		recorder.writeErr = err
+	}
+	return n, err
+}
+
+// Unwrap нужен http.ResponseController, чтобы добраться до Flush исходного writer
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
 }
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// Token выдаёт токены OAuth клиентам (RFC 6749, раздел 3.2).
+// @Summary      OAuth 2.0 token endpoint
+// @Description  grant_type=client_credentials: access‑токен для сервис‑сервис взаимодействия, без refresh‑токена. В claims токена client_id, scope и sub = client_id.
+// @Description  grant_type=authorization_code: обмен кода из /oauth2/authorize на access/refresh токены новой сессии; обязателен code_verifier (PKCE). Публичный клиент передаёт только client_id.
+// @Description  Конфиденциальный клиент передаёт client_id и client_secret через HTTP Basic или в теле формы.
+// @Tags         oauth2
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "client_credentials или authorization_code"
+// @Param        scope          formData  string  false  "client_credentials: запрашиваемые scope через пробел; по умолчанию все разрешённые клиенту"
+// @Param        code           formData  string  false  "authorization_code: код из /oauth2/authorize"
+// @Param        redirect_uri   formData  string  false  "authorization_code: тот же redirect_uri, что и в /oauth2/authorize"
+// @Param        code_verifier  formData  string  false  "authorization_code: PKCE code_verifier"
+// @Param        client_id      formData  string  false  "ID клиента, если не используется Basic"
+// @Param        client_secret  formData  string  false  "Секрет клиента, если не используется Basic"
+// @Success      200  {object}  token_response.TokenResponse
+// @Failure      400  {object}  oauthErrorBody  "invalid_request, invalid_grant, unsupported_grant_type, unauthorized_client, invalid_scope"
+// @Failure      401  {object}  oauthErrorBody  "invalid_client"
+// @Failure      500  {object}  oauthErrorBody  "server_error"
+// @Router       /oauth2/token [post]
+func (httpHandler *HttpHandler) Token(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "malformed form body")
+		return
+	}
+
+	clientID, clientSecret := getClientCredentials(req)
+
+	switch req.PostFormValue("grant_type") {
+	case "client_credentials":
+		resp, err := httpHandler.authService.ClientCredentialsToken(req.Context(), clientID, clientSecret, req.PostFormValue("scope"))
+		if err != nil {
+			recordError(req, err)
+			writeTokenError(w, err)
+			return
+		}
+		writeOAuthJSON(w, http.StatusOK, resp)
+	case "authorization_code":
+		ipAddr, _ := httpHandler.getIP(req)
+		resp, err := httpHandler.authService.ExchangeAuthorizationCode(req.Context(),
+			clientID,
+			clientSecret,
+			req.PostFormValue("code"),
+			req.PostFormValue("redirect_uri"),
+			req.PostFormValue("code_verifier"),
+			req.UserAgent(),
+			ipAddr,
+		)
+		if err != nil {
+			recordError(req, err)
+			writeTokenError(w, err)
+			return
+		}
+		writeOAuthJSON(w, http.StatusOK, resp)
+	case "":
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "grant_type required")
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+	}
+}
+
+// writeTokenError переводит ошибку сервиса в ошибку token endpoint (RFC 6749, раздел 5.2)
+func writeTokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidClient):
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "")
+	case errors.Is(err, apperrors.ErrUnauthorizedClient):
+		writeOAuthError(w, http.StatusBadRequest, "unauthorized_client", "")
+	case errors.Is(err, apperrors.ErrInvalidScope):
+		writeOAuthError(w, http.StatusBadRequest, "invalid_scope", "")
+	case errors.Is(err, apperrors.ErrInvalidGrant):
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "")
+	case errors.Is(err, apperrors.ErrInvalidRequest):
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "")
+	default:
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "")
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// UpdateWebhookSubscription меняет URL, фильтр, ключ подписи или статус подписки.
+// @Summary      Изменение, пауза и возобновление подписки
+// @Description  Меняет только переданные поля. status=paused приостанавливает подписку: события на неё по-прежнему ставятся в доставку, но не отправляются до status=active. Нужен токен сервиса со scope webhooks:admin.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        subscription_id  path      string                         true  "ID подписки"
+// @Param        body             body      webhookSubscriptionUpdateBody  true  "Изменяемые поля"
+// @Success      200              {object}  webhookSubscriptionBody
+// @Failure      400              {string}  string  "invalid url, event type or status"
+// @Failure      401              {string}  string  "unauthorized"
+// @Failure      403              {string}  string  "insufficient scope"
+// @Failure      404              {string}  string  "subscription not found"
+// @Failure      500              {string}  string  "can't save subscription"
+// @Router       /api/v1/admin/webhooks/subscriptions/{subscription_id} [patch]
+func (httpHandler *HttpHandler) UpdateWebhookSubscription(w http.ResponseWriter, req *http.Request) {
+	var body webhookSubscriptionUpdateBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := httpHandler.webhookService.UpdateSubscription(req.Context(), mux.Vars(req)["subscription_id"], &webhook_subscriptions.WebhookSubscriptionUpdate{
+		URL:        body.URL,
+		Secret:     body.Secret,
+		EventTypes: body.EventTypes,
+		Status:     body.Status,
+	})
+	if err != nil {
+		recordError(req, err)
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newWebhookSubscriptionBody(subscription))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"net/http"
+)
+
+// VerifyMFA выдаёт пару токенов после проверки второго фактора.
+// @Summary      Проверка второго фактора
+// @Description  Обменивает MFA токен (из ответа mfa_required) и TOTP код на пару токенов. MFA токен одноразовый и живёт 5 минут. В access токене будет amr ["pwd","otp"].
+// @Tags         mfa
+// @Accept       json
+// @Produce      json
+// @Param        body  body      mfaVerifyBody  true  "MFA токен и код из приложения"
+// @Success      200   {object}  accessAndRefreshTokensBody
+// @Failure      400   {string}  string  "Invalid request body"
+// @Failure      401   {string}  string  "invalid token or totp code"
+// @Failure      500   {string}  string  "can't verify mfa"
+// @Router       /api/v1/auth/mfa/verify [post]
+func (httpHandler *HttpHandler) VerifyMFA(w http.ResponseWriter, req *http.Request) {
+	body := mfaVerifyBody{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	userAgent := req.UserAgent()
+	ipAddr, _ := httpHandler.getIP(req)
+
+	accessToken, refreshToken, err := httpHandler.authService.VerifyMFA(req.Context(), body.MFAToken, body.Code, userAgent, ipAddr)
+	if err != nil {
+		recordError(req, err)
+		if errors.Is(err, apperrors.ErrInvalidToken) || errors.Is(err, apperrors.ErrInvalidTOTPCode) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "can't verify mfa", http.StatusInternalServerError)
+		return
+	}
+
+	resp := &accessAndRefreshTokensBody{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(resp); err != nil {
+		httpHandler.logger.WarnContext(req.Context(), "failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, url string, eventTypes []string, secret string) (*webhook_subscriptions.WebhookSubscriptions, error)
+	ListSubscriptions(ctx context.Context) ([]*webhook_subscriptions.WebhookSubscriptions, error)
+	GetSubscription(ctx context.Context, subscriptionID string) (*webhook_subscriptions.WebhookSubscriptions, error)
+	UpdateSubscription(ctx context.Context, subscriptionID string, update *webhook_subscriptions.WebhookSubscriptionUpdate) (*webhook_subscriptions.WebhookSubscriptions, error)
+	DeleteSubscription(ctx context.Context, subscriptionID string) error
+	ListDeliveries(ctx context.Context, subscriptionID string, status string, limit int) ([]*webhook_deliveries.WebhookDeliveries, error)
+	GetDelivery(ctx context.Context, deliveryID string) (*webhook_deliveries.WebhookDeliveries, []*webhook_deliveries.DeliveryAttempts, error)
+	ReplayDelivery(ctx context.Context, deliveryID string) error
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Check — проверка одной зависимости для /readyz: ошибка значит, что запросы обслуживать нельзя
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Health отвечает на пробы Kubernetes: /healthz — процесс жив, /readyz — зависимости доступны
+// и сервис не останавливается
+type Health struct {
+	checks  []Check
+	timeout time.Duration
+	logger  *slog.Logger
+
+	draining atomic.Bool
+}
+
+// NewHealth — timeout ограничивает каждую проверку; logger может быть nil
+func NewHealth(logger *slog.Logger, timeout time.Duration, checks ...Check) *Health {
+	return &Health{
+		checks:  checks,
+		timeout: timeout,
+		logger:  logging.OrDiscard(logger).With("component", "health"),
+	}
+}
+
+// StartDraining вызывается в начале остановки: /readyz начинает отвечать 503, и балансировщик
+// перестаёт слать новые запросы, пока сервер дообслуживает начатые
+func (health *Health) StartDraining() {
+	health.draining.Store(true)
+}
+
+const (
+	statusOK           = "ok"
+	statusFail         = "fail"
+	statusReady        = "ready"
+	statusNotReady     = "not_ready"
+	statusShuttingDown = "shutting_down"
+)
+
+type statusBody struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
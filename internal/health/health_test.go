@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, handler http.Handler) (int, *statusBody) {
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := &statusBody{}
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(body))
+	return rw.Code, body
+}
+
+func ok(context.Context) error { return nil }
+
+func TestHealth_Readiness(t *testing.T) {
+	t.Run("all checks pass", func(t *testing.T) {
+		health := NewHealth(nil, time.Second, Check{Name: "postgres", Check: ok}, Check{Name: "redis", Check: ok})
+
+		code, body := probe(t, health.ReadinessHandler())
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, &statusBody{Status: "ready", Checks: map[string]string{"postgres": "ok", "redis": "ok"}}, body)
+	})
+
+	t.Run("dependency unavailable", func(t *testing.T) {
+		health := NewHealth(nil, time.Second,
+			Check{Name: "postgres", Check: ok},
+			Check{Name: "redis", Check: func(context.Context) error { return errors.New("dial tcp redis:6379: connection refused") }},
+		)
+
+		code, body := probe(t, health.ReadinessHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, &statusBody{Status: "not_ready", Checks: map[string]string{"postgres": "ok", "redis": "fail"}}, body)
+	})
+
+	t.Run("check exceeds timeout", func(t *testing.T) {
+		health := NewHealth(nil, 20*time.Millisecond, Check{Name: "postgres", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}})
+
+		start := time.Now()
+		code, body := probe(t, health.ReadinessHandler())
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "fail", body.Checks["postgres"])
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		health := NewHealth(nil, time.Second, Check{Name: "postgres", Check: ok})
+		health.StartDraining()
+
+		code, body := probe(t, health.ReadinessHandler())
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "shutting_down", body.Status)
+
+		// liveness при остановке не падает: иначе под перезапустят посреди дообслуживания запросов
+		code, body = probe(t, health.LivenessHandler())
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", body.Status)
+	})
+}
+
+func TestHealth_Liveness(t *testing.T) {
+	health := NewHealth(nil, time.Second, Check{Name: "postgres", Check: func(context.Context) error { return errors.New("down") }})
+
+	code, body := probe(t, health.LivenessHandler())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, &statusBody{Status: "ok"}, body)
+}
@@ -0,0 +1,15 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// LivenessHandler — /healthz: процесс отвечает. Зависимости не проверяются, иначе недоступная база
+// приводила бы к перезапуску всех подов разом. Во время остановки тоже 200: под завершается сам.
+func (health *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&statusBody{Status: statusOK})
+	})
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// ReadinessHandler — /readyz: 200, если все проверки прошли, иначе 503. Проверки идут параллельно,
+// каждая не дольше timeout. Текст ошибки только логируется: проба доступна на публичном порту.
+func (health *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if health.draining.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(&statusBody{Status: statusShuttingDown})
+			return
+		}
+
+		results := health.runChecks(req.Context())
+
+		body := &statusBody{Status: statusReady, Checks: results}
+		status := http.StatusOK
+		for _, result := range results {
+			if result != statusOK {
+				body.Status = statusNotReady
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	})
+}
+
+func (health *Health) runChecks(ctx context.Context) map[string]string {
+	results := make(map[string]string, len(health.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range health.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, health.timeout)
+			defer cancel()
+
+			result := statusOK
+			if err := check.Check(checkCtx); err != nil {
+				health.logger.WarnContext(ctx, "readiness check failed", "check", check.Name, "error", err)
+				result = statusFail
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type fieldsKey struct{}
+
+// RequestFields — поля, которые попадают в каждую запись лога в рамках запроса.
+// Заполняются по ходу обработки: request_id и route в начале, user_id и session_id после проверки токена.
+type RequestFields struct {
+	mu        sync.Mutex
+	requestID string
+	route     string
+	userID    string
+	sessionID string
+	clientID  string
+
+	// err — ошибка, с которой закончился запрос; пишется только в итоговую строку лога запроса
+	err error
+}
+
+// NewContext кладёт в контекст пустой набор полей запроса с его идентификатором
+func NewContext(ctx context.Context, requestID string) (context.Context, *RequestFields) {
+	fields := &RequestFields{requestID: requestID}
+	return context.WithValue(ctx, fieldsKey{}, fields), fields
+}
+
+// FieldsFromContext — поля запроса или nil вне запроса
+func FieldsFromContext(ctx context.Context) *RequestFields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(*RequestFields)
+	return fields
+}
+
+// SetRoute — шаблон маршрута, например /api/v1/admin/webhooks/subscriptions/{subscription_id}
+func SetRoute(ctx context.Context, route string) {
+	if fields := FieldsFromContext(ctx); fields != nil {
+		fields.mu.Lock()
+		fields.route = route
+		fields.mu.Unlock()
+	}
+}
+
+// SetUser — владелец проверенного access токена
+func SetUser(ctx context.Context, userID string, sessionID string) {
+	if fields := FieldsFromContext(ctx); fields != nil {
+		fields.mu.Lock()
+		fields.userID, fields.sessionID = userID, sessionID
+		fields.mu.Unlock()
+	}
+}
+
+// SetClient — OAuth клиент, который вызвал ручку своим токеном
+func SetClient(ctx context.Context, clientID string) {
+	if fields := FieldsFromContext(ctx); fields != nil {
+		fields.mu.Lock()
+		fields.clientID = clientID
+		fields.mu.Unlock()
+	}
+}
+
+// SetError запоминает ошибку, с которой закончился запрос
+func SetError(ctx context.Context, err error) {
+	if fields := FieldsFromContext(ctx); fields != nil {
+		fields.mu.Lock()
+		fields.err = err
+		fields.mu.Unlock()
+	}
+}
+
+func (fields *RequestFields) RequestID() string {
+	return fields.requestID
+}
+
+func (fields *RequestFields) Route() string {
+	fields.mu.Lock()
+	defer fields.mu.Unlock()
+	return fields.route
+}
+
+func (fields *RequestFields) Err() error {
+	fields.mu.Lock()
+	defer fields.mu.Unlock()
+	return fields.err
+}
+
+func (fields *RequestFields) attrs() []slog.Attr {
+	fields.mu.Lock()
+	defer fields.mu.Unlock()
+
+	attrs := []slog.Attr{slog.String("request_id", fields.requestID)}
+	for _, field := range []struct{ key, value string }{
+		{"route", fields.route},
+		{"user_id", fields.userID},
+		{"session_id", fields.sessionID},
+		{"client_id", fields.clientID},
+	} {
+		if field.value != "" {
+			attrs = append(attrs, slog.String(field.key, field.value))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// NewLogger — JSON логгер сервиса: секреты вырезаются из всех записей,
+// а поля запроса (request_id, route, user_id, session_id) и trace_id добавляются из контекста
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(&contextHandler{
+		handler: slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: redactAttr,
+		}),
+	})
+}
+
+// ParseLevel принимает debug, info, warn или error; пустая строка — info
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(strings.TrimSpace(s)))
+	return level, err
+}
+
+// OrDiscard подставляет логгер, который ничего не пишет, вместо nil — для тестов и утилит
+func OrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return logger
+}
+
+// contextHandler дописывает к каждой записи поля запроса и trace_id/span_id из контекста
+type contextHandler struct {
+	handler slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields := FieldsFromContext(ctx); fields != nil {
+		record.AddAttrs(fields.attrs()...)
+	}
+	// по trace_id строка лога находится в трассе и наоборот
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()))
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{handler: h.handler.WithGroup(name)}
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys — значения этих полей не пишутся никогда, на каком бы уровне вложенности они ни были
+var sensitiveKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"mfa_token":     true,
+	"token":         true,
+	"id_token":      true,
+	"authorization": true,
+	"cookie":        true,
+	"password":      true,
+	"secret":        true,
+	"client_secret": true,
+	"totp_secret":   true,
+	"totp_code":     true,
+	"code":          true,
+	"code_verifier": true,
+	"signature":     true,
+}
+
+// secretPatterns вырезают токены, случайно попавшие в текст ошибки или сообщения:
+// JWT (заголовок всегда начинается с eyJ), Bearer авторизация и refresh токен вида <session_id>.<secret>
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	regexp.MustCompile(`(?i)bearer\s+\S+`),
+	regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.[A-Za-z0-9+/=_-]{16,}`),
+}
+
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, RedactString(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, RedactString(err.Error()))
+		}
+	}
+	return attr
+}
+
+// RedactString заменяет токены в произвольном тексте на [REDACTED]
+func RedactString(s string) string {
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, redacted)
+	}
+	return s
+}
@@ -0,0 +1,29 @@
+package memory_authorization_code_store
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+)
+
+// Consume — читает и удаляет код под mu, поэтому обменять его на токены можно только один раз
+func (codeStore *MemoryAuthorizationCodeStore) Consume(ctx context.Context, code string) (*authorization_codes.AuthorizationCodes, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	codeStore.mu.Lock()
+	defer codeStore.mu.Unlock()
+
+	entry, ok := codeStore.entries[code]
+	if !ok {
+		return nil, apperrors.ErrAuthorizationCodeNotFound
+	}
+	delete(codeStore.entries, code)
+	if !codeStore.now().Before(entry.expiresAt) {
+		return nil, apperrors.ErrAuthorizationCodeNotFound
+	}
+
+	authorizationCode := entry.authorizationCode
+	return &authorizationCode, nil
+}
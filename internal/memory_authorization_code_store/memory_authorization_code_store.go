@@ -0,0 +1,27 @@
+package memory_authorization_code_store
+
+import (
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"sync"
+	"time"
+)
+
+// MemoryAuthorizationCodeStore — authorization codes в памяти процесса, для STATE_STORE=memory без Redis.
+// Коды не переживают перезапуск, и обменять код можно только на том экземпляре, который его выдал
+type MemoryAuthorizationCodeStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+func NewMemoryAuthorizationCodeStore() *MemoryAuthorizationCodeStore {
+	return &MemoryAuthorizationCodeStore{
+		entries: make(map[string]entry),
+		now:     time.Now,
+	}
+}
+
+type entry struct {
+	authorizationCode authorization_codes.AuthorizationCodes
+	expiresAt         time.Time
+}
@@ -0,0 +1,84 @@
+package memory_authorization_code_store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStore() (*MemoryAuthorizationCodeStore, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryAuthorizationCodeStore()
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryAuthorizationCodeStore(t *testing.T) {
+	store, advance := setupStore()
+	authorizationCode := &authorization_codes.AuthorizationCodes{
+		ClientID:      "spa",
+		UserID:        "u",
+		RedirectURI:   "https://app.example.com/callback",
+		Scope:         "profile",
+		CodeChallenge: "challenge",
+	}
+
+	t.Run("code is consumed once", func(t *testing.T) {
+		require.NoError(t, store.Save(t.Context(), "code", authorizationCode, time.Minute))
+
+		got, err := store.Consume(t.Context(), "code")
+		require.NoError(t, err)
+		assert.Equal(t, authorizationCode, got)
+
+		_, err = store.Consume(t.Context(), "code")
+		assert.ErrorIs(t, err, apperrors.ErrAuthorizationCodeNotFound)
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		require.NoError(t, store.Save(t.Context(), "short-lived", authorizationCode, time.Minute))
+		advance(time.Minute)
+
+		_, err := store.Consume(t.Context(), "short-lived")
+		assert.ErrorIs(t, err, apperrors.ErrAuthorizationCodeNotFound)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		assert.ErrorIs(t, store.Save(ctx, "code", authorizationCode, time.Minute), context.Canceled)
+		_, err := store.Consume(ctx, "code")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestMemoryAuthorizationCodeStore_RunJanitor(t *testing.T) {
+	store, advance := setupStore()
+	require.NoError(t, store.Save(t.Context(), "short", &authorization_codes.AuthorizationCodes{}, time.Minute))
+	require.NoError(t, store.Save(t.Context(), "long", &authorization_codes.AuthorizationCodes{}, time.Hour))
+	advance(time.Minute)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		store.RunJanitor(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	// истёкший код удаляется без обращения к нему
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		_, ok := store.entries["short"]
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Len(t, store.entries, 1)
+}
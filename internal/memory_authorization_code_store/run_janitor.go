@@ -0,0 +1,29 @@
+package memory_authorization_code_store
+
+import (
+	"context"
+	"time"
+)
+
+// RunJanitor раз в interval удаляет истёкшие коды, которые так и не обменяли, до отмены ctx
+func (codeStore *MemoryAuthorizationCodeStore) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		codeStore.mu.Lock()
+		now := codeStore.now()
+		for code, entry := range codeStore.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(codeStore.entries, code)
+			}
+		}
+		codeStore.mu.Unlock()
+	}
+}
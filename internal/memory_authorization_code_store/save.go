@@ -0,0 +1,20 @@
+package memory_authorization_code_store
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/authorization_codes"
+	"time"
+)
+
+// Save — запоминает копию данных кода на ttl
+func (codeStore *MemoryAuthorizationCodeStore) Save(ctx context.Context, code string, authorizationCode *authorization_codes.AuthorizationCodes, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	codeStore.mu.Lock()
+	defer codeStore.mu.Unlock()
+
+	codeStore.entries[code] = entry{authorizationCode: *authorizationCode, expiresAt: codeStore.now().Add(ttl)}
+	return nil
+}
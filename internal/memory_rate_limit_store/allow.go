@@ -0,0 +1,38 @@
+package memory_rate_limit_store
+
+import (
+	"context"
+	"time"
+)
+
+// Allow учитывает запрос по ключу: не больше limit запросов за скользящее окно window.
+// Как и скрипт Redis, отклонённые запросы не учитываются, а retryAfter — через сколько из окна выпадет самый старый.
+func (rateLimitStore *MemoryRateLimitStore) Allow(ctx context.Context, key string, limit int, windowSize time.Duration) (bool, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return false, 0, err
+	}
+
+	rateLimitStore.mu.Lock()
+	defer rateLimitStore.mu.Unlock()
+
+	now := rateLimitStore.now()
+	w, ok := rateLimitStore.windows[key]
+	if !ok {
+		w = &window{}
+		rateLimitStore.windows[key] = w
+	}
+
+	start := now.Add(-windowSize)
+	i := 0
+	for i < len(w.hits) && !w.hits[i].After(start) {
+		i++
+	}
+	w.hits = w.hits[i:]
+
+	if len(w.hits) < limit {
+		w.hits = append(w.hits, now)
+		w.expiresAt = now.Add(windowSize)
+		return true, 0, nil
+	}
+	return false, w.hits[0].Add(windowSize).Sub(now), nil
+}
@@ -0,0 +1,28 @@
+package memory_rate_limit_store
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryRateLimitStore — скользящие окна лимитов в памяти процесса, для STATE_STORE=memory без Redis.
+// Счётчики не общие между экземплярами: с несколькими экземплярами лимит фактически умножается на их число
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	now     func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: make(map[string]*window),
+		now:     time.Now,
+	}
+}
+
+type window struct {
+	// hits — время принятых запросов по возрастанию
+	hits []time.Time
+	// expiresAt — когда из окна выпадет последний запрос, как PEXPIRE в Redis
+	expiresAt time.Time
+}
@@ -0,0 +1,114 @@
+package memory_rate_limit_store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStore() (*MemoryRateLimitStore, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryRateLimitStore_Allow(t *testing.T) {
+	t.Run("allows up to limit", func(t *testing.T) {
+		store, _ := setupStore()
+
+		for i := 0; i < 3; i++ {
+			allowed, _, err := store.Allow(t.Context(), "ip:1.1.1.1", 3, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, allowed, i)
+		}
+
+		allowed, retryAfter, err := store.Allow(t.Context(), "ip:1.1.1.1", 3, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, time.Minute, retryAfter)
+	})
+
+	t.Run("window slides", func(t *testing.T) {
+		store, advance := setupStore()
+
+		allowed, _, err := store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		advance(40 * time.Second)
+		allowed, _, err = store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		// первый запрос ещё в окне
+		advance(10 * time.Second)
+		allowed, retryAfter, err := store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, 10*time.Second, retryAfter)
+
+		// первый выпал из окна, второй — ещё нет
+		advance(10 * time.Second)
+		allowed, _, err = store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, retryAfter, err = store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, 40*time.Second, retryAfter)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		store, _ := setupStore()
+
+		allowed, _, err := store.Allow(t.Context(), "user:a", 1, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, _, err = store.Allow(t.Context(), "user:b", 1, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		store, _ := setupStore()
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, _, err := store.Allow(ctx, "k", 1, time.Minute)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestMemoryRateLimitStore_RunJanitor(t *testing.T) {
+	store, advance := setupStore()
+	_, _, err := store.Allow(t.Context(), "short", 1, time.Minute)
+	require.NoError(t, err)
+	_, _, err = store.Allow(t.Context(), "long", 1, time.Hour)
+	require.NoError(t, err)
+	advance(time.Minute)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		store.RunJanitor(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	// окно без запросов удаляется без обращения к нему
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		_, ok := store.windows["short"]
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Len(t, store.windows, 1)
+}
@@ -0,0 +1,29 @@
+package memory_rate_limit_store
+
+import (
+	"context"
+	"time"
+)
+
+// RunJanitor раз в interval удаляет окна, из которых выпали все запросы, до отмены ctx
+func (rateLimitStore *MemoryRateLimitStore) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		rateLimitStore.mu.Lock()
+		now := rateLimitStore.now()
+		for key, w := range rateLimitStore.windows {
+			if !now.Before(w.expiresAt) {
+				delete(rateLimitStore.windows, key)
+			}
+		}
+		rateLimitStore.mu.Unlock()
+	}
+}
@@ -0,0 +1,16 @@
+package memory_revocation_store
+
+import "context"
+
+// Delete — удаляет ключ, как DEL в Redis; отсутствующий ключ не ошибка
+func (revocationStore *MemoryRevocationStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	revocationStore.mu.Lock()
+	defer revocationStore.mu.Unlock()
+
+	delete(revocationStore.entries, key)
+	return nil
+}
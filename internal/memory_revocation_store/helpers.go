@@ -0,0 +1,39 @@
+package memory_revocation_store
+
+import (
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"time"
+)
+
+func expired(expiresAt time.Time, now time.Time) bool {
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// deleteExpired вызывается под mu
+func (revocationStore *MemoryRevocationStore) deleteExpired(now time.Time) {
+	for key, entry := range revocationStore.entries {
+		if expired(entry.expiresAt, now) {
+			delete(revocationStore.entries, key)
+		}
+	}
+}
+
+// reserve проверяет, что для нового ключа есть место; вызывается под mu
+func (revocationStore *MemoryRevocationStore) reserve(key string, now time.Time) error {
+	if _, ok := revocationStore.entries[key]; ok || len(revocationStore.entries) < revocationStore.maxKeys {
+		return nil
+	}
+	revocationStore.deleteExpired(now)
+	if len(revocationStore.entries) >= revocationStore.maxKeys {
+		return apperrors.ErrRevocationStoreFull
+	}
+	return nil
+}
+
+// expiresAt — момент истечения для ttl; ttl <= 0 — без истечения, как SET без EX в Redis
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,31 @@
+package memory_revocation_store
+
+import (
+	"context"
+	"time"
+)
+
+// Increment — увеличивает счётчик ключа и продлевает его на ttl, как INCR + PEXPIRE в Redis
+func (revocationStore *MemoryRevocationStore) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	revocationStore.mu.Lock()
+	defer revocationStore.mu.Unlock()
+
+	now := revocationStore.now()
+	current, ok := revocationStore.entries[key]
+	if ok && expired(current.expiresAt, now) {
+		delete(revocationStore.entries, key)
+		current = entry{}
+	}
+	if err := revocationStore.reserve(key, now); err != nil {
+		return 0, err
+	}
+
+	current.count++
+	current.expiresAt = expiresAt(now, ttl)
+	revocationStore.entries[key] = current
+	return current.count, nil
+}
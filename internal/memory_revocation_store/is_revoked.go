@@ -0,0 +1,23 @@
+package memory_revocation_store
+
+import "context"
+
+// IsRevoked — проверяет, что ключ есть и его TTL не истёк
+func (revocationStore *MemoryRevocationStore) IsRevoked(ctx context.Context, token string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	revocationStore.mu.Lock()
+	defer revocationStore.mu.Unlock()
+
+	entry, ok := revocationStore.entries[token]
+	if !ok {
+		return false, nil
+	}
+	if expired(entry.expiresAt, revocationStore.now()) {
+		delete(revocationStore.entries, token)
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,33 @@
+package memory_revocation_store
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryRevocationStore — black-list отозванных токенов в памяти процесса, для тестов и одного экземпляра
+// в dev окружении. Отзывы не переживают перезапуск и не видны другим экземплярам сервиса
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+
+	// maxKeys ограничивает память: при переполнении новые отзывы отклоняются, а не вытесняют старые,
+	// иначе вытесненный токен снова стал бы действительным
+	maxKeys int
+}
+
+func NewMemoryRevocationStore(maxKeys int) *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		entries: make(map[string]entry),
+		now:     time.Now,
+		maxKeys: maxKeys,
+	}
+}
+
+type entry struct {
+	// expiresAt — момент истечения ключа; нулевое время — ключ без TTL
+	expiresAt time.Time
+	// count — значение счётчика Increment; Revoke ставит 1, как SET "1" в Redis
+	count int64
+}
@@ -0,0 +1,85 @@
+package memory_revocation_store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/revocation_store_conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStore(maxKeys int) (*MemoryRevocationStore, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryRevocationStore(maxKeys)
+	store.now = func() time.Time { return now }
+	return store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryRevocationStore_Conformance(t *testing.T) {
+	revocation_store_conformance.Run(t, func(t *testing.T) (revocation_store_conformance.TokenRevocationStore, func(time.Duration)) {
+		return setupStore(100)
+	})
+}
+
+func TestMemoryRevocationStore_MaxKeys(t *testing.T) {
+	store, advance := setupStore(2)
+	require.NoError(t, store.Revoke(t.Context(), "a", time.Minute))
+	require.NoError(t, store.Revoke(t.Context(), "b", 2*time.Minute))
+
+	// переполнение не вытесняет действующие отзывы
+	assert.ErrorIs(t, store.Revoke(t.Context(), "c", time.Minute), apperrors.ErrRevocationStoreFull)
+	_, err := store.Increment(t.Context(), "c", time.Minute)
+	assert.ErrorIs(t, err, apperrors.ErrRevocationStoreFull)
+	revoked, err := store.IsRevoked(t.Context(), "a")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// уже отозванный ключ обновляется и в полном хранилище
+	require.NoError(t, store.Revoke(t.Context(), "a", time.Minute))
+
+	// место освобождают истёкшие ключи
+	advance(time.Minute)
+	require.NoError(t, store.Revoke(t.Context(), "c", time.Minute))
+	assert.Len(t, store.entries, 2)
+}
+
+func TestMemoryRevocationStore_RunJanitor(t *testing.T) {
+	store, advance := setupStore(100)
+	require.NoError(t, store.Revoke(t.Context(), "short", time.Minute))
+	require.NoError(t, store.Revoke(t.Context(), "long", time.Hour))
+	require.NoError(t, store.Revoke(t.Context(), "forever", 0))
+	advance(time.Minute)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		store.RunJanitor(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	// истёкший ключ удаляется без обращения к нему
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		_, ok := store.entries["short"]
+		return !ok
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	assert.ElementsMatch(t, []string{"long", "forever"}, keys(store))
+}
+
+func keys(store *MemoryRevocationStore) []string {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var keys []string
+	for key := range store.entries {
+		keys = append(keys, key)
+	}
+	return keys
+}
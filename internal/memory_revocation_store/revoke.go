@@ -0,0 +1,24 @@
+package memory_revocation_store
+
+import (
+	"context"
+	"time"
+)
+
+// Revoke — запоминает ключ на ttl; ttl <= 0 — без истечения, как SET без EX в Redis
+func (revocationStore *MemoryRevocationStore) Revoke(ctx context.Context, token string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	revocationStore.mu.Lock()
+	defer revocationStore.mu.Unlock()
+
+	now := revocationStore.now()
+	if err := revocationStore.reserve(token, now); err != nil {
+		return err
+	}
+
+	revocationStore.entries[token] = entry{expiresAt: expiresAt(now, ttl), count: 1}
+	return nil
+}
@@ -0,0 +1,24 @@
+package memory_revocation_store
+
+import (
+	"context"
+	"time"
+)
+
+// RunJanitor раз в interval удаляет истёкшие ключи, которые больше никто не проверяет, до отмены ctx
+func (revocationStore *MemoryRevocationStore) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		revocationStore.mu.Lock()
+		revocationStore.deleteExpired(revocationStore.now())
+		revocationStore.mu.Unlock()
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics — метрики сервиса для Prometheus. Каждый пакет видит только свой узкий интерфейс
+// (handlers.Metrics, auth_service.Metrics, repo.Metrics, token_revocation_store.Metrics).
+type Metrics struct {
+	registry *prometheus.Registry
+
+	tokens        *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	hashDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	redisDuration *prometheus.HistogramVec
+}
+
+// SessionCounter считает активные сессии на момент сбора метрик
+type SessionCounter interface {
+	CountSessions(ctx context.Context) (int64, error)
+}
+
+func NewMetrics() *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_tokens_total",
+			Help: "Token issuance, refresh and revocation by outcome and error code.",
+		}, []string{"operation", "outcome", "error_code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP handler latency by route template.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		// argon2id и bcrypt занимают десятки и сотни миллисекунд — мелкие бакеты не нужны
+		hashDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "auth_hash_duration_seconds",
+			Help:    "Password and refresh token hashing time (argon2id, bcrypt).",
+			Buckets: []float64{.01, .025, .05, .1, .2, .3, .5, 1, 2},
+		}, []string{"algorithm", "operation"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "auth_db_query_duration_seconds",
+			Help:    "Postgres time per Repo method.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"method"}),
+		redisDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "auth_redis_duration_seconds",
+			Help:    "Redis latency of the token revocation store.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+		}, []string{"operation"}),
+	}
+
+	metrics.registry.MustRegister(
+		metrics.tokens,
+		metrics.httpDuration,
+		metrics.hashDuration,
+		metrics.queryDuration,
+		metrics.redisDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return metrics
+}
+
+// RegisterSessionCounter включает метрику auth_active_sessions. Отдельным шагом, потому что счётчик — это repo,
+// а repo сам создаётся с метриками.
+func (metrics *Metrics) RegisterSessionCounter(sessionCounter SessionCounter) {
+	metrics.registry.MustRegister(newSessionsCollector(sessionCounter))
+}
+
+// Handler отдаёт метрики в формате Prometheus. Ошибка одного коллектора (например, база недоступна)
+// не ломает весь ответ.
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// ObserveTokens — operation: issued, refreshed или revoked; outcome: success, failure или mfa_required
+func (metrics *Metrics) ObserveTokens(operation string, outcome string, errorCode string) {
+	metrics.tokens.WithLabelValues(operation, outcome, errorCode).Inc()
+}
+
+func (metrics *Metrics) ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	metrics.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func (metrics *Metrics) ObserveHash(algorithm string, operation string, duration time.Duration) {
+	metrics.hashDuration.WithLabelValues(algorithm, operation).Observe(duration.Seconds())
+}
+
+func (metrics *Metrics) ObserveQuery(method string, duration time.Duration) {
+	metrics.queryDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+func (metrics *Metrics) ObserveRedis(operation string, duration time.Duration) {
+	metrics.redisDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type sessionCounterFunc func() (int64, error)
+
+func (f sessionCounterFunc) CountSessions(_ context.Context) (int64, error) {
+	return f()
+}
+
+func scrape(t *testing.T, metrics *Metrics) string {
+	rw := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+	body, err := io.ReadAll(rw.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.RegisterSessionCounter(sessionCounterFunc(func() (int64, error) {
+		return 7, nil
+	}))
+
+	metrics.ObserveTokens("issued", "success", "")
+	metrics.ObserveTokens("refreshed", "failure", "refresh_token_reused")
+	metrics.ObserveTokens("refreshed", "failure", "refresh_token_reused")
+	metrics.ObserveHTTPRequest(http.MethodPost, "/api/v1/auth/refresh", http.StatusUnauthorized, 20*time.Millisecond)
+	metrics.ObserveHash("bcrypt", "compare", 80*time.Millisecond)
+	metrics.ObserveQuery("GetSessionByID", 3*time.Millisecond)
+	metrics.ObserveRedis("is_revoked", time.Millisecond)
+
+	body := scrape(t, metrics)
+	assert.Contains(t, body, `auth_tokens_total{error_code="",operation="issued",outcome="success"} 1`)
+	assert.Contains(t, body, `auth_tokens_total{error_code="refresh_token_reused",operation="refreshed",outcome="failure"} 2`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="POST",route="/api/v1/auth/refresh",status="401"} 1`)
+	assert.Contains(t, body, `auth_hash_duration_seconds_bucket{algorithm="bcrypt",operation="compare",le="0.1"} 1`)
+	assert.Contains(t, body, `auth_db_query_duration_seconds_count{method="GetSessionByID"} 1`)
+	assert.Contains(t, body, `auth_redis_duration_seconds_count{operation="is_revoked"} 1`)
+	assert.Contains(t, body, "auth_active_sessions 7")
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestMetrics_SessionCounterError(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.RegisterSessionCounter(sessionCounterFunc(func() (int64, error) {
+		return 0, errors.New("database is down")
+	}))
+	metrics.ObserveTokens("revoked", "success", "")
+
+	// недоступная база не должна прятать остальные метрики
+	body := scrape(t, metrics)
+	assert.NotContains(t, body, "auth_active_sessions")
+	assert.Contains(t, body, `auth_tokens_total{error_code="",operation="revoked",outcome="success"} 1`)
+}
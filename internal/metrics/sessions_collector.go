@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var activeSessionsDesc = prometheus.NewDesc("auth_active_sessions", "Sessions with a live refresh token.", nil, nil)
+
+// sessionsCollector считает сессии в момент сбора: отдельный фоновый счётчик разошёлся бы
+// с базой при нескольких экземплярах сервиса
+type sessionsCollector struct {
+	sessionCounter SessionCounter
+}
+
+func newSessionsCollector(sessionCounter SessionCounter) *sessionsCollector {
+	return &sessionsCollector{sessionCounter: sessionCounter}
+}
+
+func (collector *sessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeSessionsDesc
+}
+
+func (collector *sessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	count, err := collector.sessionCounter.CountSessions(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(activeSessionsDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(activeSessionsDesc, prometheus.GaugeValue, float64(count))
+}
@@ -0,0 +1,71 @@
+package password_hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// Параметры argon2id по RFC 9106, раздел 4 (второй рекомендованный вариант: 64 MiB памяти, 3 прохода).
+// Они записываются в сам хеш, поэтому их можно поднимать, не ломая старые пароли.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	saltLen       = 16
+)
+
+var b64 = base64.RawStdEncoding
+
+// GenerateFromPassword возвращает argon2id хеш пароля в PHC формате:
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+func GenerateFromPassword(password []byte) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey(password, salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// CompareHashAndPassword проверяет пароль против хеша из GenerateFromPassword.
+// Возвращает nil при совпадении, ErrMismatchedPassword — если пароль неверный.
+func CompareHashAndPassword(encodedHash string, password []byte) error {
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return apperrors.ErrCantParsePasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return apperrors.ErrCantParsePasswordHash
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return apperrors.ErrCantParsePasswordHash
+	}
+
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return apperrors.ErrCantParsePasswordHash
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return apperrors.ErrCantParsePasswordHash
+	}
+
+	otherKey := argon2.IDKey(password, salt, time, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return apperrors.ErrMismatchedPassword
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package password_hasher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+)
+
+func TestGenerateFromPassword(t *testing.T) {
+	hash, err := GenerateFromPassword([]byte("correct horse"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=4$"))
+
+	// соль случайная, поэтому хеши одного пароля различаются
+	other, err := GenerateFromPassword([]byte("correct horse"))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestCompareHashAndPassword(t *testing.T) {
+	hash, err := GenerateFromPassword([]byte("correct horse"))
+	require.NoError(t, err)
+
+	t.Run("match", func(t *testing.T) {
+		assert.NoError(t, CompareHashAndPassword(hash, []byte("correct horse")))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		assert.ErrorIs(t, CompareHashAndPassword(hash, []byte("battery staple")), apperrors.ErrMismatchedPassword)
+	})
+
+	t.Run("params are read from the hash", func(t *testing.T) {
+		// хеш с более слабыми параметрами, чем текущие
+		key := argon2.IDKey([]byte("password"), []byte("somesalt"), 1, 1024, 1, 32)
+		legacy := "$argon2id$v=19$m=1024,t=1,p=1$" + b64.EncodeToString([]byte("somesalt")) + "$" + b64.EncodeToString(key)
+		assert.NoError(t, CompareHashAndPassword(legacy, []byte("password")))
+	})
+
+	t.Run("malformed hash", func(t *testing.T) {
+		for _, malformed := range []string{
+			"",
+			"$2a$10$bcrypthash",
+			"$argon2i$v=19$m=65536,t=3,p=4$c2FsdA$a2V5",
+			"$argon2id$v=18$m=65536,t=3,p=4$c2FsdA$a2V5",
+			"$argon2id$v=19$m=x,t=3,p=4$c2FsdA$a2V5",
+			"$argon2id$v=19$m=65536,t=3,p=4$!!!$a2V5",
+			"$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$",
+		} {
+			assert.ErrorIs(t, CompareHashAndPassword(malformed, []byte("password")), apperrors.ErrCantParsePasswordHash, malformed)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package rate_limit_store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// slidingWindow — скользящее окно на sorted set: score каждого принятого запроса — время в миллисекундах.
+// Записи старше окна удаляются, и если оставшихся меньше лимита, запрос учитывается. Иначе возвращается,
+// через сколько миллисекунд из окна выпадет самая старая запись. Скрипт выполняется атомарно,
+// поэтому параллельные запросы не проскочат лимит. Отклонённые запросы не учитываются.
+var slidingWindow = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+if redis.call('ZCARD', key) < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	return {1, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+return {0, tonumber(oldest[2]) + window - now}
+`)
+
+// Allow учитывает запрос по ключу <prefix><key>: не больше limit запросов за скользящее окно window
+func (rateLimitStore *RateLimitStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return false, 0, err
+	}
+
+	now := rateLimitStore.now().UnixMilli()
+	member := fmt.Sprintf("%d-%s", now, hex.EncodeToString(nonce))
+
+	if rateLimitStore.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rateLimitStore.timeout)
+		defer cancel()
+	}
+
+	result, err := slidingWindow.Run(ctx, rateLimitStore.client,
+		[]string{rateLimitStore.keyPrefix + key},
+		now, window.Milliseconds(), limit, member,
+	).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
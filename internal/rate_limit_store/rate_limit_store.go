@@ -0,0 +1,24 @@
+package rate_limit_store
+
+import (
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+type RateLimitStore struct {
+	client    *redis.Client
+	keyPrefix string
+	now       func() time.Time
+
+	// timeout ограничивает проверку лимита: медленный Redis не должен задерживать каждый запрос
+	timeout time.Duration
+}
+
+func NewRateLimitStore(client *redis.Client, keyPrefix string, timeout time.Duration) *RateLimitStore {
+	return &RateLimitStore{
+		client:    client,
+		keyPrefix: keyPrefix,
+		now:       time.Now,
+		timeout:   timeout,
+	}
+}
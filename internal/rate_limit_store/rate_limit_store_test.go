@@ -0,0 +1,134 @@
+package rate_limit_store
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStore(t *testing.T) (*RateLimitStore, *miniredis.Miniredis, *time.Time) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewRateLimitStore(client, "ratelimit:", 0)
+	store.now = func() time.Time { return now }
+	return store, server, &now
+}
+
+func TestRateLimitStore_Allow(t *testing.T) {
+	t.Run("allows up to limit", func(t *testing.T) {
+		store, server, _ := setupStore(t)
+
+		for i := 0; i < 3; i++ {
+			allowed, _, err := store.Allow(t.Context(), "ip:1.1.1.1", 3, time.Minute)
+			require.NoError(t, err)
+			assert.True(t, allowed, i)
+		}
+
+		allowed, retryAfter, err := store.Allow(t.Context(), "ip:1.1.1.1", 3, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, time.Minute, retryAfter)
+
+		assert.True(t, server.Exists("ratelimit:ip:1.1.1.1"))
+		assert.Equal(t, time.Minute, server.TTL("ratelimit:ip:1.1.1.1"))
+	})
+
+	t.Run("window slides", func(t *testing.T) {
+		store, _, now := setupStore(t)
+
+		allowed, _, err := store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		*now = now.Add(40 * time.Second)
+		allowed, _, err = store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		// первый запрос ещё в окне
+		*now = now.Add(10 * time.Second)
+		allowed, retryAfter, err := store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, 10*time.Second, retryAfter)
+
+		// первый выпал из окна, второй — ещё нет
+		*now = now.Add(10 * time.Second)
+		allowed, _, err = store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, retryAfter, err = store.Allow(t.Context(), "k", 2, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, 40*time.Second, retryAfter)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		store, _, _ := setupStore(t)
+
+		allowed, _, err := store.Allow(t.Context(), "user:a", 1, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, _, err = store.Allow(t.Context(), "user:b", 1, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, _, err = store.Allow(t.Context(), "user:a", 1, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("redis unavailable", func(t *testing.T) {
+		store, server, _ := setupStore(t)
+		server.Close()
+
+		_, _, err := store.Allow(t.Context(), "k", 1, time.Minute)
+		assert.Error(t, err)
+	})
+
+	t.Run("request cancelled", func(t *testing.T) {
+		store, server, _ := setupStore(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, _, err := store.Allow(ctx, "k", 1, time.Minute)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, server.Exists("ratelimit:k"))
+	})
+
+	t.Run("redis does not answer within timeout", func(t *testing.T) {
+		// принимает соединения, но ничего не отвечает
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { listener.Close() })
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				t.Cleanup(func() { conn.Close() })
+			}
+		}()
+
+		client := redis.NewClient(&redis.Options{Addr: listener.Addr().String(), MaxRetries: -1})
+		t.Cleanup(func() { client.Close() })
+		store := NewRateLimitStore(client, "ratelimit:", 50*time.Millisecond)
+
+		start := time.Now()
+		_, _, err = store.Allow(t.Context(), "k", 1, time.Minute)
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+// AdoptLegacyWebhookSubscription создаёт подписку с известным id или заполняет заглушку без URL, оставленную миграцией.
+// Подписку с уже заданным URL не трогает: ею управляют через API. Возвращает false, если ничего не изменилось.
+func (repo *Repo) AdoptLegacyWebhookSubscription(ctx context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) (bool, error) {
+	ctx, done := repo.startQuery(ctx, "AdoptLegacyWebhookSubscription")
+	defer done()
+
+	sb := psql.Insert("webhook_subscriptions").
+		Columns("subscription_id", "url", "secret", "event_types", "status").
+		Values(subscription.SubscriptionID, subscription.URL, subscription.Secret, subscription.EventTypes, subscription.Status).
+		Suffix("ON CONFLICT (subscription_id) DO UPDATE SET url = EXCLUDED.url, secret = EXCLUDED.secret, status = EXCLUDED.status, updated_at = now() " +
+			"WHERE webhook_subscriptions.url = ''")
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return false, apperrors.ErrCantBuildSQLQuery
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, repo.queryFailed(ctx, "AdoptLegacyWebhookSubscription", err)
+	}
+	adopted, err := res.RowsAffected()
+	if err != nil {
+		return false, repo.queryFailed(ctx, "AdoptLegacyWebhookSubscription", err)
+	}
+	return adopted > 0, nil
+}
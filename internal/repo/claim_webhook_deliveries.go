@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"strings"
+	"time"
+)
+
+// ClaimWebhookDeliveries забирает до limit доставок активным подпискам, которым пора отправляться,
+// и откладывает их следующую попытку на lease. Так несколько экземпляров сервиса не отправят одно событие
+// одновременно, а доставка, чей dispatcher упал посреди отправки, через lease снова станет доступна.
+func (repo *Repo) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*webhook_deliveries.PendingDelivery, error) {
+	ctx, done := repo.startQuery(ctx, "ClaimWebhookDeliveries")
+	defer done()
+
+	due := sq.Select("pd.delivery_id").
+		From("webhook_deliveries pd").
+		Join("webhook_subscriptions ps ON ps.subscription_id = pd.subscription_id").
+		Where(sq.Eq{"pd.status": webhook_deliveries.StatusPending, "ps.status": webhook_subscriptions.StatusActive}).
+		Where("pd.next_attempt_at <= now()").
+		OrderBy("pd.next_attempt_at").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE OF pd SKIP LOCKED")
+
+	sb := psql.Update("webhook_deliveries d").
+		Set("next_attempt_at", sq.Expr("now() + ? * interval '1 second'", lease.Seconds())).
+		From("webhook_outbox e, webhook_subscriptions s").
+		Where("e.event_id = d.event_id AND s.subscription_id = d.subscription_id").
+		Where(sq.Expr("d.delivery_id IN (?)", due)).
+		Suffix("RETURNING " + strings.Join(pendingDeliveryColumns, ", "))
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	deliveries := make([]*webhook_deliveries.PendingDelivery, 0)
+	if err = repo.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ClaimWebhookDeliveries", err)
+	}
+
+	return deliveries, nil
+}
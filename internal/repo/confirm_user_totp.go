@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// ConfirmUserTOTP включает второй фактор; step — окно кода, которым пользователь подтвердил привязку
+func (repo *Repo) ConfirmUserTOTP(ctx context.Context, userID string, step int64) error {
+	ctx, done := repo.startQuery(ctx, "ConfirmUserTOTP")
+	defer done()
+
+	sb := psql.Update("user_totp").
+		Set("confirmed_at", sq.Expr("now()")).
+		Set("last_used_step", step).
+		Where(sq.Eq{"user_id": userID, "confirmed_at": nil})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return repo.queryFailed(ctx, "ConfirmUserTOTP", err)
+	}
+	confirmed, err := res.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "ConfirmUserTOTP", err)
+	}
+	if confirmed == 0 {
+		return apperrors.ErrTOTPAlreadyEnrolled
+	}
+	return nil
+}
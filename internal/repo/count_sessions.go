@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// CountSessions — число сессий с живым refresh токеном, для метрики auth_active_sessions
+func (repo *Repo) CountSessions(ctx context.Context) (int64, error) {
+	ctx, done := repo.startQuery(ctx, "CountSessions")
+	defer done()
+
+	query, args, err := psql.Select("count(*)").From("sessions").ToSql()
+	if err != nil {
+		return 0, apperrors.ErrCantBuildSQLQuery
+	}
+
+	var count int64
+	if err = repo.db.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, repo.queryFailed(ctx, "CountSessions", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
+)
+
+func (repo *Repo) CreateClient(ctx context.Context, client *clients.Clients) error {
+	ctx, done := repo.startQuery(ctx, "CreateClient")
+	defer done()
+
+	sb := psql.Insert("oauth_clients").
+		Columns("client_id", "client_secret_hash", "name", "scopes", "grant_types", "redirect_uris").
+		Values(client.ClientID, client.ClientSecretHash, client.Name, client.Scopes, client.GrantTypes, client.RedirectURIs)
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = repo.db.ExecContext(ctx, query, args...); err != nil {
+		return repo.queryFailed(ctx, "CreateClient", err)
+	}
+	return nil
+}
@@ -1,22 +1,27 @@
 package repo
 
 import (
+	"context"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
 	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
 )
 
-func (repo *Repo) CreateSession(session *sessions.Sessions) error {
+func (repo *Repo) CreateSession(ctx context.Context, session *sessions.Sessions, events ...*webhook_events.WebhookEvents) error {
+	ctx, done := repo.startQuery(ctx, "CreateSession")
+	defer done()
+
 	sb := psql.Insert("sessions").
-		Columns("user_id", "refresh_token_hash", "user_agent", "ip_addr").
-		Values(session.UserID, session.RefreshTokenHash, session.UserAgent, session.IPAddr)
+		Columns("session_id", "user_id", "refresh_token_hash", "user_agent", "ip_addr", "client_id", "scope", "amr").
+		Values(session.SessionID, session.UserID, session.RefreshTokenHash, session.UserAgent, session.IPAddr, session.ClientID, session.Scope, session.AMR)
 
 	query, args, err := sb.ToSql()
 	if err != nil {
 		return apperrors.ErrCantBuildSQLQuery
 	}
 
-	if _, err = repo.db.Exec(query, args...); err != nil {
-		return apperrors.ErrCantExecSQLQuery
+	if _, err = repo.execWithWebhookEvents(ctx, query, args, events); err != nil {
+		return err
 	}
 	return nil
 }
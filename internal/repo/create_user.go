@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation — код ошибки Postgres при нарушении UNIQUE
+const uniqueViolation = "23505"
+
+func (repo *Repo) CreateUser(ctx context.Context, user *users.Users) error {
+	ctx, done := repo.startQuery(ctx, "CreateUser")
+	defer done()
+
+	sb := psql.Insert("users").
+		Columns("user_id", "email", "password_hash").
+		Values(user.UserID, user.Email, user.PasswordHash)
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = repo.db.ExecContext(ctx, query, args...); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return apperrors.ErrUserAlreadyExists
+		}
+		return repo.queryFailed(ctx, "CreateUser", err)
+	}
+	return nil
+}
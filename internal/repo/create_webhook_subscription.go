@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+func (repo *Repo) CreateWebhookSubscription(ctx context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) error {
+	ctx, done := repo.startQuery(ctx, "CreateWebhookSubscription")
+	defer done()
+
+	sb := psql.Insert("webhook_subscriptions").
+		Columns("subscription_id", "url", "secret", "event_types", "status").
+		Values(subscription.SubscriptionID, subscription.URL, subscription.Secret, subscription.EventTypes, subscription.Status)
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = repo.db.ExecContext(ctx, query, args...); err != nil {
+		return repo.queryFailed(ctx, "CreateWebhookSubscription", err)
+	}
+	return nil
+}
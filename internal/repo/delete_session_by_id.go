@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+)
+
+func (repo *Repo) DeleteSessionByID(ctx context.Context, sessionID string, events ...*webhook_events.WebhookEvents) error {
+	ctx, done := repo.startQuery(ctx, "DeleteSessionByID")
+	defer done()
+
+	sb := psql.Delete("sessions").
+		Where(sq.Eq{"session_id": sessionID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = repo.execWithWebhookEvents(ctx, query, args, events); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+)
+
+// DeleteSessionsByIDs удаляет перечисленные сессии пользователя; события webhook пишутся в той же транзакции
+func (repo *Repo) DeleteSessionsByIDs(ctx context.Context, userID string, sessionIDs []string, events ...*webhook_events.WebhookEvents) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	ctx, done := repo.startQuery(ctx, "DeleteSessionsByIDs")
+	defer done()
+
+	sb := psql.Delete("sessions").
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Eq{"session_id": sessionIDs})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = repo.execWithWebhookEvents(ctx, query, args, events); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// DeleteWebhookSubscription удаляет подписку вместе с её доставками и журналом попыток
+func (repo *Repo) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	ctx, done := repo.startQuery(ctx, "DeleteWebhookSubscription")
+	defer done()
+
+	sb := psql.Delete("webhook_subscriptions").
+		Where(sq.Eq{"subscription_id": subscriptionID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return repo.queryFailed(ctx, "DeleteWebhookSubscription", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "DeleteWebhookSubscription", err)
+	}
+	if deleted == 0 {
+		return apperrors.ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
+)
+
+func (repo *Repo) GetClientByID(ctx context.Context, clientID string) (*clients.Clients, error) {
+	ctx, done := repo.startQuery(ctx, "GetClientByID")
+	defer done()
+
+	sb := psql.Select(clientColumns...).
+		From("oauth_clients").
+		Where(sq.Eq{"client_id": clientID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	client := &clients.Clients{}
+	if err = repo.db.GetContext(ctx, client, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrClientNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetClientByID", err)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+func (repo *Repo) GetLastAuditCheckpoint(ctx context.Context) (*audit_events.AuditCheckpoints, error) {
+	ctx, done := repo.startQuery(ctx, "GetLastAuditCheckpoint")
+	defer done()
+
+	sb := psql.Select(auditCheckpointColumns...).
+		From("audit_checkpoints").
+		OrderBy("checkpoint_id DESC").
+		Limit(1)
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	checkpoint := &audit_events.AuditCheckpoints{}
+	if err = repo.db.GetContext(ctx, checkpoint, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrAuditCheckpointNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetLastAuditCheckpoint", err)
+	}
+
+	return checkpoint, nil
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+func (repo *Repo) GetLastAuditEvent(ctx context.Context) (*audit_events.AuditEvents, error) {
+	ctx, done := repo.startQuery(ctx, "GetLastAuditEvent")
+	defer done()
+
+	sb := psql.Select(auditEventColumns...).
+		From("audit_events").
+		OrderBy("audit_id DESC").
+		Limit(1)
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	event := &audit_events.AuditEvents{}
+	if err = repo.db.GetContext(ctx, event, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrAuditEventNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetLastAuditEvent", err)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+)
+
+func (repo *Repo) GetSessionByID(ctx context.Context, sessionID string) (*sessions.Sessions, error) {
+	ctx, done := repo.startQuery(ctx, "GetSessionByID")
+	defer done()
+
+	sb := psql.Select(sessionColumns...).
+		From("sessions").
+		Where(sq.Eq{"session_id": sessionID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	session := &sessions.Sessions{}
+	if err = repo.db.GetContext(ctx, session, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrSessionNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetSessionByID", err)
+	}
+
+	return session, nil
+}
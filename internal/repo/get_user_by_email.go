@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
+)
+
+func (repo *Repo) GetUserByEmail(ctx context.Context, email string) (*users.Users, error) {
+	ctx, done := repo.startQuery(ctx, "GetUserByEmail")
+	defer done()
+
+	sb := psql.Select(userColumns...).
+		From("users").
+		Where(sq.Eq{"email": email})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	user := &users.Users{}
+	if err = repo.db.GetContext(ctx, user, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetUserByEmail", err)
+	}
+
+	return user, nil
+}
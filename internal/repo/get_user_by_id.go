@@ -1,16 +1,20 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
-	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
 )
 
-func (repo *Repo) GetSessionByUserID(userID string) (*sessions.Sessions, error) {
-	sb := psql.Select("*").
-		From("sessions").
+func (repo *Repo) GetUserByID(ctx context.Context, userID string) (*users.Users, error) {
+	ctx, done := repo.startQuery(ctx, "GetUserByID")
+	defer done()
+
+	sb := psql.Select(userColumns...).
+		From("users").
 		Where(sq.Eq{"user_id": userID})
 
 	query, args, err := sb.ToSql()
@@ -18,13 +22,13 @@ func (repo *Repo) GetSessionByUserID(userID string) (*sessions.Sessions, error)
 		return nil, apperrors.ErrCantBuildSQLQuery
 	}
 
-	session := &sessions.Sessions{}
-	if err = repo.db.Get(session, query, args...); err != nil {
+	user := &users.Users{}
+	if err = repo.db.GetContext(ctx, user, query, args...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, apperrors.ErrUserNotFound
 		}
-		return nil, apperrors.ErrCantExecSQLQuery
+		return nil, repo.queryFailed(ctx, "GetUserByID", err)
 	}
 
-	return session, nil
+	return user, nil
 }
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/user_totp"
+)
+
+func (repo *Repo) GetUserTOTP(ctx context.Context, userID string) (*user_totp.UserTOTP, error) {
+	ctx, done := repo.startQuery(ctx, "GetUserTOTP")
+	defer done()
+
+	sb := psql.Select(userTOTPColumns...).
+		From("user_totp").
+		Where(sq.Eq{"user_id": userID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	userTOTP := &user_totp.UserTOTP{}
+	if err = repo.db.GetContext(ctx, userTOTP, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrTOTPNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetUserTOTP", err)
+	}
+
+	return userTOTP, nil
+}
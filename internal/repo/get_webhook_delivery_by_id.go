@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+)
+
+func (repo *Repo) GetWebhookDeliveryByID(ctx context.Context, deliveryID string) (*webhook_deliveries.WebhookDeliveries, error) {
+	ctx, done := repo.startQuery(ctx, "GetWebhookDeliveryByID")
+	defer done()
+
+	sb := psql.Select(webhookDeliveryColumns...).
+		From("webhook_deliveries d").
+		Join("webhook_outbox e ON e.event_id = d.event_id").
+		Where(sq.Eq{"d.delivery_id": deliveryID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	delivery := &webhook_deliveries.WebhookDeliveries{}
+	if err = repo.db.GetContext(ctx, delivery, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrWebhookDeliveryNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetWebhookDeliveryByID", err)
+	}
+
+	return delivery, nil
+}
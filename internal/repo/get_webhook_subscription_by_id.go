@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+func (repo *Repo) GetWebhookSubscriptionByID(ctx context.Context, subscriptionID string) (*webhook_subscriptions.WebhookSubscriptions, error) {
+	ctx, done := repo.startQuery(ctx, "GetWebhookSubscriptionByID")
+	defer done()
+
+	sb := psql.Select(webhookSubscriptionColumns...).
+		From("webhook_subscriptions").
+		Where(sq.Eq{"subscription_id": subscriptionID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	subscription := &webhook_subscriptions.WebhookSubscriptions{}
+	if err = repo.db.GetContext(ctx, subscription, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.ErrWebhookSubscriptionNotFound
+		}
+		return nil, repo.queryFailed(ctx, "GetWebhookSubscriptionByID", err)
+	}
+
+	return subscription, nil
+}
@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+func (repo *Repo) InsertAuditCheckpoint(ctx context.Context, checkpoint *audit_events.AuditCheckpoints) error {
+	ctx, done := repo.startQuery(ctx, "InsertAuditCheckpoint")
+	defer done()
+
+	sb := psql.Insert("audit_checkpoints").
+		Columns("audit_id", "hash", "signature").
+		Values(checkpoint.AuditID, checkpoint.Hash, checkpoint.Signature)
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = repo.db.ExecContext(ctx, query, args...); err != nil {
+		return repo.queryFailed(ctx, "InsertAuditCheckpoint", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/audit_chain"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"time"
+)
+
+// InsertAuditEvent добавляет запись в конец hash chain: под advisory lock читает хеш последней записи
+// и сохраняет новую вместе с ним и её собственным хешем. Заполняет CreatedAt, PrevHash и Hash события.
+func (repo *Repo) InsertAuditEvent(ctx context.Context, event *audit_events.AuditEvents) error {
+	ctx, done := repo.startQuery(ctx, "InsertAuditEvent")
+	defer done()
+
+	lockQuery, lockArgs, err := psql.Select().
+		Column(sq.Expr("pg_advisory_xact_lock(?)", auditChainLockKey)).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return repo.queryFailed(ctx, "InsertAuditEvent", err)
+	}
+	defer tx.Rollback()
+
+	// параллельные записи иначе прочитали бы один и тот же хеш и разветвили цепочку
+	if _, err = tx.ExecContext(ctx, lockQuery, lockArgs...); err != nil {
+		return repo.queryFailed(ctx, "InsertAuditEvent", err)
+	}
+
+	lastQuery, lastArgs, err := psql.Select("hash").
+		From("audit_events").
+		OrderBy("audit_id DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	var prevHash string
+	if err = tx.GetContext(ctx, &prevHash, lastQuery, lastArgs...); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return repo.queryFailed(ctx, "InsertAuditEvent", err)
+	}
+
+	// время задаём сами: оно входит в хеш, а Postgres хранит его с точностью до микросекунды
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	event.PrevHash = prevHash
+	event.Hash = audit_chain.Hash(prevHash, event)
+
+	insertQuery, insertArgs, err := psql.Insert("audit_events").
+		Columns("event_type", "actor", "user_id", "session_id", "ip_addr", "user_agent", "outcome", "error_code", "created_at", "prev_hash", "hash").
+		Values(event.EventType, event.Actor, event.UserID, event.SessionID, event.IPAddr, event.UserAgent, event.Outcome, event.ErrorCode, event.CreatedAt, event.PrevHash, event.Hash).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = tx.ExecContext(ctx, insertQuery, insertArgs...); err != nil {
+		return repo.queryFailed(ctx, "InsertAuditEvent", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return repo.queryFailed(ctx, "InsertAuditEvent", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"github.com/jmoiron/sqlx"
+)
+
+// insertWebhookEvents кладёт события в outbox в рамках транзакции изменения сессии:
+// событие появится тогда и только тогда, когда изменение зафиксировано.
+// Там же событие ставится в доставку всем подпискам, которым оно подходит по фильтру, в том числе
+// приостановленным: их доставки ждут в очереди (ClaimWebhookDeliveries их не забирает) и уйдут после возобновления.
+func insertWebhookEvents(ctx context.Context, tx *sqlx.Tx, events []*webhook_events.WebhookEvents) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	sb := psql.Insert("webhook_outbox").
+		Columns("event_id", "event_type", "payload", "trace_parent")
+	// доставка продолжит трассу запроса, в котором событие возникло
+	traceParent := tracing.TraceParent(ctx)
+	eventIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		sb = sb.Values(event.EventID, event.EventType, event.Payload, traceParent)
+		eventIDs = append(eventIDs, event.EventID)
+	}
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return apperrors.Wrap(apperrors.ErrCantExecSQLQuery, err)
+	}
+
+	subscribers := sq.Select("gen_random_uuid()", "e.event_id", "s.subscription_id").
+		From("webhook_outbox e").
+		Join("webhook_subscriptions s ON s.status IN (?, ?) AND (s.event_types = '' OR e.event_type = ANY(string_to_array(s.event_types, ' ')))",
+			webhook_subscriptions.StatusActive, webhook_subscriptions.StatusPaused).
+		Where(sq.Eq{"e.event_id": eventIDs})
+
+	query, args, err = psql.Insert("webhook_deliveries").
+		Columns("delivery_id", "event_id", "subscription_id").
+		Select(subscribers).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return apperrors.Wrap(apperrors.ErrCantExecSQLQuery, err)
+	}
+	return nil
+}
+
+// InsertWebhookEvents пишет события в outbox отдельно от изменения сессии
+func (repo *Repo) InsertWebhookEvents(ctx context.Context, events ...*webhook_events.WebhookEvents) error {
+	ctx, done := repo.startQuery(ctx, "InsertWebhookEvents")
+	defer done()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return repo.queryFailed(ctx, "InsertWebhookEvents", err)
+	}
+	defer tx.Rollback()
+
+	if err = insertWebhookEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return repo.queryFailed(ctx, "InsertWebhookEvents", err)
+	}
+	return nil
+}
+
+// execWithWebhookEvents выполняет запрос и, если есть события, пишет их в outbox в той же транзакции
+func (repo *Repo) execWithWebhookEvents(ctx context.Context, query string, args []interface{}, events []*webhook_events.WebhookEvents) (sql.Result, error) {
+	if len(events) == 0 {
+		res, err := repo.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, repo.queryFailed(ctx, "execWithWebhookEvents", err)
+		}
+		return res, nil
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, repo.queryFailed(ctx, "execWithWebhookEvents", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, repo.queryFailed(ctx, "execWithWebhookEvents", err)
+	}
+	if err = insertWebhookEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, repo.queryFailed(ctx, "execWithWebhookEvents", err)
+	}
+	return res, nil
+}
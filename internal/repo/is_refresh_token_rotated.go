@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// IsRefreshTokenRotated проверяет, был ли токен с таким дайджестом уже использован в этой сессии
+func (repo *Repo) IsRefreshTokenRotated(ctx context.Context, sessionID string, refreshTokenDigest string) (bool, error) {
+	ctx, done := repo.startQuery(ctx, "IsRefreshTokenRotated")
+	defer done()
+
+	sb := psql.Select("count(*)").
+		From("refresh_token_history").
+		Where(sq.Eq{"session_id": sessionID, "token_digest": refreshTokenDigest})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return false, apperrors.ErrCantBuildSQLQuery
+	}
+
+	var count int
+	if err = repo.db.GetContext(ctx, &count, query, args...); err != nil {
+		return false, repo.queryFailed(ctx, "IsRefreshTokenRotated", err)
+	}
+
+	return count > 0, nil
+}
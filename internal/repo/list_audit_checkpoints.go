@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+// ListAuditCheckpoints возвращает все контрольные точки по порядку
+func (repo *Repo) ListAuditCheckpoints(ctx context.Context) ([]*audit_events.AuditCheckpoints, error) {
+	ctx, done := repo.startQuery(ctx, "ListAuditCheckpoints")
+	defer done()
+
+	sb := psql.Select(auditCheckpointColumns...).
+		From("audit_checkpoints").
+		OrderBy("checkpoint_id")
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	checkpoints := make([]*audit_events.AuditCheckpoints, 0)
+	if err = repo.db.SelectContext(ctx, &checkpoints, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ListAuditCheckpoints", err)
+	}
+
+	return checkpoints, nil
+}
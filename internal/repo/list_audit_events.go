@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+// ListAuditEvents возвращает до filter.Limit записей журнала, новые первыми
+func (repo *Repo) ListAuditEvents(ctx context.Context, filter *audit_events.AuditEventFilter) ([]*audit_events.AuditEvents, error) {
+	ctx, done := repo.startQuery(ctx, "ListAuditEvents")
+	defer done()
+
+	sb := psql.Select(auditEventColumns...).
+		From("audit_events").
+		OrderBy("audit_id DESC").
+		Limit(uint64(filter.Limit))
+	if !filter.From.IsZero() {
+		sb = sb.Where(sq.GtOrEq{"created_at": filter.From})
+	}
+	if !filter.To.IsZero() {
+		sb = sb.Where(sq.Lt{"created_at": filter.To})
+	}
+	if filter.UserID != "" {
+		sb = sb.Where(sq.Eq{"user_id": filter.UserID})
+	}
+	if len(filter.EventTypes) > 0 {
+		sb = sb.Where(sq.Eq{"event_type": filter.EventTypes})
+	}
+	if filter.BeforeID > 0 {
+		sb = sb.Where(sq.Lt{"audit_id": filter.BeforeID})
+	}
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	events := make([]*audit_events.AuditEvents, 0)
+	if err = repo.db.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ListAuditEvents", err)
+	}
+
+	return events, nil
+}
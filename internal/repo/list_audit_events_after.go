@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+)
+
+// ListAuditEventsAfter возвращает до limit записей журнала после afterID в порядке цепочки — для выгрузки
+func (repo *Repo) ListAuditEventsAfter(ctx context.Context, afterID int64, limit int) ([]*audit_events.AuditEvents, error) {
+	ctx, done := repo.startQuery(ctx, "ListAuditEventsAfter")
+	defer done()
+
+	sb := psql.Select(auditEventColumns...).
+		From("audit_events").
+		Where(sq.Gt{"audit_id": afterID}).
+		OrderBy("audit_id").
+		Limit(uint64(limit))
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	events := make([]*audit_events.AuditEvents, 0)
+	if err = repo.db.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ListAuditEventsAfter", err)
+	}
+
+	return events, nil
+}
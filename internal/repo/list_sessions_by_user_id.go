@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+)
+
+func (repo *Repo) ListSessionsByUserID(ctx context.Context, userID string) ([]*sessions.Sessions, error) {
+	ctx, done := repo.startQuery(ctx, "ListSessionsByUserID")
+	defer done()
+
+	sb := psql.Select(sessionColumns...).
+		From("sessions").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("last_used_at DESC")
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	userSessions := make([]*sessions.Sessions, 0)
+	if err = repo.db.SelectContext(ctx, &userSessions, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ListSessionsByUserID", err)
+	}
+
+	return userSessions, nil
+}
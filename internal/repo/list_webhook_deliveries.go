@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+)
+
+// ListWebhookDeliveries возвращает последние limit доставок подписки, новые первыми; пустой status — любые
+func (repo *Repo) ListWebhookDeliveries(ctx context.Context, subscriptionID string, status string, limit int) ([]*webhook_deliveries.WebhookDeliveries, error) {
+	ctx, done := repo.startQuery(ctx, "ListWebhookDeliveries")
+	defer done()
+
+	sb := psql.Select(webhookDeliveryColumns...).
+		From("webhook_deliveries d").
+		Join("webhook_outbox e ON e.event_id = d.event_id").
+		Where(sq.Eq{"d.subscription_id": subscriptionID}).
+		OrderBy("d.created_at DESC").
+		Limit(uint64(limit))
+	if status != "" {
+		sb = sb.Where(sq.Eq{"d.status": status})
+	}
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	deliveries := make([]*webhook_deliveries.WebhookDeliveries, 0)
+	if err = repo.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ListWebhookDeliveries", err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+)
+
+// ListWebhookDeliveryAttempts возвращает журнал попыток доставки в порядке их выполнения
+func (repo *Repo) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID string) ([]*webhook_deliveries.DeliveryAttempts, error) {
+	ctx, done := repo.startQuery(ctx, "ListWebhookDeliveryAttempts")
+	defer done()
+
+	sb := psql.Select(webhookDeliveryAttemptColumns...).
+		From("webhook_delivery_attempts").
+		Where(sq.Eq{"delivery_id": deliveryID}).
+		OrderBy("attempted_at", "attempt_id")
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	attempts := make([]*webhook_deliveries.DeliveryAttempts, 0)
+	if err = repo.db.SelectContext(ctx, &attempts, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ListWebhookDeliveryAttempts", err)
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+func (repo *Repo) ListWebhookSubscriptions(ctx context.Context) ([]*webhook_subscriptions.WebhookSubscriptions, error) {
+	ctx, done := repo.startQuery(ctx, "ListWebhookSubscriptions")
+	defer done()
+
+	sb := psql.Select(webhookSubscriptionColumns...).
+		From("webhook_subscriptions").
+		OrderBy("created_at")
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, apperrors.ErrCantBuildSQLQuery
+	}
+
+	subscriptions := make([]*webhook_subscriptions.WebhookSubscriptions, 0)
+	if err = repo.db.SelectContext(ctx, &subscriptions, query, args...); err != nil {
+		return nil, repo.queryFailed(ctx, "ListWebhookSubscriptions", err)
+	}
+
+	return subscriptions, nil
+}
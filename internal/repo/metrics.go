@@ -0,0 +1,7 @@
+package repo
+
+import "time"
+
+type Metrics interface {
+	ObserveQuery(method string, duration time.Duration)
+}
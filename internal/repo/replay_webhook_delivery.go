@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+)
+
+// ReplayWebhookDelivery возвращает доставку в очередь с чистым счётчиком попыток; журнал прошлых попыток сохраняется
+func (repo *Repo) ReplayWebhookDelivery(ctx context.Context, deliveryID string) error {
+	ctx, done := repo.startQuery(ctx, "ReplayWebhookDelivery")
+	defer done()
+
+	sb := psql.Update("webhook_deliveries").
+		Set("status", webhook_deliveries.StatusPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", sq.Expr("now()")).
+		Set("last_error", "").
+		Set("delivered_at", nil).
+		Where(sq.Eq{"delivery_id": deliveryID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return repo.queryFailed(ctx, "ReplayWebhookDelivery", err)
+	}
+	replayed, err := res.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "ReplayWebhookDelivery", err)
+	}
+	if replayed == 0 {
+		return apperrors.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
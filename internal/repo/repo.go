@@ -1,18 +1,95 @@
 package repo
 
 import (
+	"context"
 	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"github.com/Turalchik/authentication-service/internal/tracing"
 	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"time"
 )
 
 type Repo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	logger *slog.Logger
+
+	// metrics может быть nil — тогда время запросов не измеряется
+	metrics Metrics
+
+	// queryTimeout ограничивает каждый метод вместе с транзакцией; 0 — только дедлайн вызывающего
+	queryTimeout time.Duration
 }
 
-func NewRepo(db *sqlx.DB) *Repo {
+// NewRepo — logger может быть nil, тогда ошибки запросов не логируются
+func NewRepo(db *sqlx.DB, logger *slog.Logger, metrics Metrics, queryTimeout time.Duration) *Repo {
 	return &Repo{
-		db: db,
+		db:           db,
+		logger:       logging.OrDiscard(logger).With("component", "repo"),
+		metrics:      metrics,
+		queryTimeout: queryTimeout,
 	}
 }
 
+var tracer = otel.Tracer("github.com/Turalchik/authentication-service/internal/repo")
+
+// startQuery открывает span метода, засекает время и ограничивает метод queryTimeout; done вызывается
+// через defer и учитывает всё время метода, включая транзакцию
+func (repo *Repo) startQuery(ctx context.Context, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "repo."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(method)))
+
+	cancel := context.CancelFunc(func() {})
+	if repo.queryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, repo.queryTimeout)
+	}
+
+	return ctx, func() {
+		cancel()
+		span.End()
+		if repo.metrics != nil {
+			repo.metrics.ObserveQuery(method, time.Since(start))
+		}
+	}
+}
+
+// queryFailed логирует ошибку Postgres, отмечает ею span метода и прячет её за ErrCantExecSQLQuery:
+// наружу уходит общий текст, а причина остаётся в ошибке для лога запроса
+func (repo *Repo) queryFailed(ctx context.Context, method string, err error) error {
+	repo.logger.ErrorContext(ctx, "sql query failed", "method", method, "error", err)
+	tracing.RecordError(trace.SpanFromContext(ctx), err)
+	return apperrors.Wrap(apperrors.ErrCantExecSQLQuery, err)
+}
+
 var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+var sessionColumns = []string{"session_id", "user_id", "refresh_token_hash", "user_agent", "ip_addr", "client_id", "scope", "amr", "created_at", "last_used_at"}
+
+var clientColumns = []string{"client_id", "client_secret_hash", "name", "scopes", "grant_types", "redirect_uris", "created_at"}
+
+var userColumns = []string{"user_id", "email", "password_hash", "created_at"}
+
+var userTOTPColumns = []string{"user_id", "secret_ciphertext", "confirmed_at", "last_used_step", "created_at"}
+
+var webhookSubscriptionColumns = []string{"subscription_id", "url", "secret", "event_types", "status", "created_at", "updated_at"}
+
+// webhookDeliveryColumns — доставка d вместе с типом события e из webhook_outbox
+var webhookDeliveryColumns = []string{"d.delivery_id", "d.event_id", "d.subscription_id", "e.event_type", "d.status", "d.attempts", "d.next_attempt_at", "d.last_error", "d.created_at", "d.delivered_at"}
+
+// pendingDeliveryColumns — доставка вместе с телом события и адресом подписки для отправки
+var pendingDeliveryColumns = append(append([]string{}, webhookDeliveryColumns...), "e.payload", "s.url", "s.secret", "e.trace_parent", "d.next_attempt_at AS leased_until")
+
+var webhookDeliveryAttemptColumns = []string{"attempt_id", "delivery_id", "attempted_at", "status_code", "error", "duration_ms"}
+
+var auditEventColumns = []string{"audit_id", "event_type", "actor", "user_id", "session_id", "ip_addr", "user_agent", "outcome", "error_code", "created_at", "prev_hash", "hash"}
+
+var auditCheckpointColumns = []string{"checkpoint_id", "audit_id", "hash", "signature", "created_at"}
+
+// auditChainLockKey — advisory lock, под которым записи журнала добавляются в цепочку по одной
+const auditChainLockKey = 7_150_001
@@ -1,13 +1,28 @@
 package repo
 
 import (
+	"context"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/audit_chain"
+	"github.com/Turalchik/authentication-service/internal/entities/audit_events"
+	"github.com/Turalchik/authentication-service/internal/entities/clients"
 	"github.com/Turalchik/authentication-service/internal/entities/sessions"
+	"github.com/Turalchik/authentication-service/internal/entities/user_totp"
+	"github.com/Turalchik/authentication-service/internal/entities/users"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -18,43 +33,52 @@ func setupDataBase(t *testing.T) (*Repo, sqlmock.Sqlmock, func(), error) {
 	}
 
 	db := sqlx.NewDb(sqlDB, "sqlmock")
-	repoObj := NewRepo(db)
+	repoObj := NewRepo(db, nil, nil, 0)
 
 	return repoObj, mock, func() { db.Close() }, nil
 }
 
-func TestRepo_GetSessionByUserID(t *testing.T) {
+func TestRepo_GetSessionByID(t *testing.T) {
 	repo, mock, closer, err := setupDataBase(t)
 	if err != nil {
 		t.Fatalf("failed to open sqlmock database: %s", err)
 	}
 	defer closer()
 
-	expectQuery := regexp.QuoteMeta("SELECT * FROM sessions WHERE user_id = $1")
+	expectQuery := regexp.QuoteMeta("SELECT session_id, user_id, refresh_token_hash, user_agent, ip_addr, client_id, scope, amr, created_at, last_used_at FROM sessions WHERE session_id = $1")
 	expectSession := sessions.Sessions{
+		SessionID:        "session_id_test",
 		UserID:           "user_id_test",
 		RefreshTokenHash: []byte("refresh_token_hash_test"),
 		UserAgent:        "user_agent_test",
 		IPAddr:           "ip_addr_test",
+		ClientID:         "spa",
+		Scope:            "profile",
+		AMR:              "pwd otp",
+		CreatedAt:        time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		LastUsedAt:       time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
 	}
 
 	t.Run("success", func(t *testing.T) {
 		rows := sqlmock.
-			NewRows([]string{"user_id", "refresh_token_hash", "user_agent", "ip_addr"}).
-			AddRow(expectSession.UserID, expectSession.RefreshTokenHash, expectSession.UserAgent, expectSession.IPAddr)
+			NewRows([]string{"session_id", "user_id", "refresh_token_hash", "user_agent", "ip_addr", "client_id", "scope", "amr", "created_at", "last_used_at"}).
+			AddRow(expectSession.SessionID, expectSession.UserID, expectSession.RefreshTokenHash, expectSession.UserAgent, expectSession.IPAddr, expectSession.ClientID, expectSession.Scope, expectSession.AMR, expectSession.CreatedAt, expectSession.LastUsedAt)
 
 		mock.
 			ExpectQuery(expectQuery).
-			WithArgs("user_id_test").
+			WithArgs("session_id_test").
 			WillReturnRows(rows)
 
-		session, err := repo.GetSessionByUserID("user_id_test")
+		session, err := repo.GetSessionByID(t.Context(), "session_id_test")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 		if session == nil {
 			t.Fatal("expected non-nil session")
 		}
+		if session.SessionID != expectSession.SessionID {
+			t.Errorf("SessionID = %s; want %s", session.SessionID, expectSession.SessionID)
+		}
 		if session.UserID != expectSession.UserID {
 			t.Errorf("UserID = %s; want %s", session.UserID, expectSession.UserID)
 		}
@@ -67,29 +91,38 @@ func TestRepo_GetSessionByUserID(t *testing.T) {
 		if session.IPAddr != expectSession.IPAddr {
 			t.Errorf("IPAddr = %s; want %s", session.IPAddr, expectSession.IPAddr)
 		}
+		if session.ClientID != expectSession.ClientID || session.Scope != expectSession.Scope {
+			t.Errorf("ClientID, Scope = %s, %s; want %s, %s", session.ClientID, session.Scope, expectSession.ClientID, expectSession.Scope)
+		}
+		if session.AMR != expectSession.AMR {
+			t.Errorf("AMR = %s; want %s", session.AMR, expectSession.AMR)
+		}
+		if !session.LastUsedAt.Equal(expectSession.LastUsedAt) {
+			t.Errorf("LastUsedAt = %s; want %s", session.LastUsedAt, expectSession.LastUsedAt)
+		}
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
 		}
 	})
 
-	t.Run("user not found", func(t *testing.T) {
+	t.Run("session not found", func(t *testing.T) {
 		rows := sqlmock.
-			NewRows([]string{"user_id", "refresh_token_hash", "user_agent", "ip_addr"})
+			NewRows([]string{"session_id", "user_id", "refresh_token_hash", "user_agent", "ip_addr", "client_id", "scope", "amr", "created_at", "last_used_at"})
 
 		mock.
 			ExpectQuery(expectQuery).
-			WithArgs("user_id_test").
+			WithArgs("session_id_test").
 			WillReturnRows(rows)
 
-		session, err := repo.GetSessionByUserID("user_id_test")
+		session, err := repo.GetSessionByID(t.Context(), "session_id_test")
 		if err == nil {
 			t.Fatalf("expected error, got nil")
 		}
 		if session != nil {
 			t.Fatal("expected nil session")
 		}
-		if !errors.Is(err, apperrors.ErrUserNotFound) {
+		if !errors.Is(err, apperrors.ErrSessionNotFound) {
 			t.Fatalf("expected sql no rows error, got: %v", err)
 		}
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -98,6 +131,55 @@ func TestRepo_GetSessionByUserID(t *testing.T) {
 	})
 }
 
+func TestRepo_QueryTimeout(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("SELECT session_id, user_id, refresh_token_hash, user_agent, ip_addr, client_id, scope, amr, created_at, last_used_at FROM sessions WHERE session_id = $1")
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"session_id"}).AddRow("session_id_test")
+	}
+
+	t.Run("query exceeds timeout", func(t *testing.T) {
+		repo.queryTimeout = 20 * time.Millisecond
+		defer func() { repo.queryTimeout = 0 }()
+
+		mock.
+			ExpectQuery(expectQuery).
+			WithArgs("session_id_test").
+			WillDelayFor(time.Second).
+			WillReturnRows(rows())
+
+		start := time.Now()
+		_, err := repo.GetSessionByID(t.Context(), "session_id_test")
+		if !errors.Is(err, apperrors.ErrCantExecSQLQuery) {
+			t.Fatalf("expected ErrCantExecSQLQuery, got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("query was not cancelled by timeout, took %s", elapsed)
+		}
+	})
+
+	t.Run("caller cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		mock.
+			ExpectQuery(expectQuery).
+			WithArgs("session_id_test").
+			WillDelayFor(time.Second).
+			WillReturnRows(rows())
+
+		_, err := repo.GetSessionByID(ctx, "session_id_test")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+	})
+}
+
 func TestRepo_CreateSession(t *testing.T) {
 	repo, mock, closer, err := setupDataBase(t)
 	if err != nil {
@@ -105,20 +187,24 @@ func TestRepo_CreateSession(t *testing.T) {
 	}
 	defer closer()
 
-	expectQuery := regexp.QuoteMeta("INSERT INTO sessions (user_id,refresh_token_hash,user_agent,ip_addr) VALUES ($1,$2,$3,$4)")
+	expectQuery := regexp.QuoteMeta("INSERT INTO sessions (session_id,user_id,refresh_token_hash,user_agent,ip_addr,client_id,scope,amr) VALUES ($1,$2,$3,$4,$5,$6,$7,$8)")
 	sess := &sessions.Sessions{
+		SessionID:        "session_id_test",
 		UserID:           "user_id_test",
 		RefreshTokenHash: []byte("refresh_token_hash_test"),
 		UserAgent:        "user_agent_test",
 		IPAddr:           "ip_addr_test",
+		ClientID:         "spa",
+		Scope:            "profile",
+		AMR:              "pwd",
 	}
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectExec(expectQuery).
-			WithArgs(sess.UserID, sess.RefreshTokenHash, sess.UserAgent, sess.IPAddr).
+			WithArgs(sess.SessionID, sess.UserID, sess.RefreshTokenHash, sess.UserAgent, sess.IPAddr, sess.ClientID, sess.Scope, sess.AMR).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		err := repo.CreateSession(sess)
+		err := repo.CreateSession(t.Context(), sess)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -129,10 +215,10 @@ func TestRepo_CreateSession(t *testing.T) {
 
 	t.Run("sql error", func(t *testing.T) {
 		mock.ExpectExec(expectQuery).
-			WithArgs(sess.UserID, sess.RefreshTokenHash, sess.UserAgent, sess.IPAddr).
+			WithArgs(sess.SessionID, sess.UserID, sess.RefreshTokenHash, sess.UserAgent, sess.IPAddr, sess.ClientID, sess.Scope, sess.AMR).
 			WillReturnError(errors.New("db error"))
 
-		err := repo.CreateSession(sess)
+		err := repo.CreateSession(t.Context(), sess)
 		if err == nil {
 			t.Fatalf("expected error, got nil")
 		}
@@ -140,22 +226,80 @@ func TestRepo_CreateSession(t *testing.T) {
 			t.Errorf("unmet expectations: %v", err)
 		}
 	})
+	t.Run("with webhook event", func(t *testing.T) {
+		event := &webhook_events.WebhookEvents{EventID: "event_id_test", EventType: webhook_events.EventSessionCreated, Payload: []byte(`{}`)}
+		// событие запоминает трассу запроса, чтобы dispatcher продолжил её при доставке
+		const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		ctx := tracing.ContextWithTraceParent(t.Context(), traceParent)
+		mock.ExpectBegin()
+		mock.ExpectExec(expectQuery).
+			WithArgs(sess.SessionID, sess.UserID, sess.RefreshTokenHash, sess.UserAgent, sess.IPAddr, sess.ClientID, sess.Scope, sess.AMR).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_outbox (event_id,event_type,payload,trace_parent) VALUES ($1,$2,$3,$4)")).
+			WithArgs(event.EventID, event.EventType, event.Payload, traceParent).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectWebhookDeliveries(mock, event.EventID)
+		mock.ExpectCommit()
+
+		if err := repo.CreateSession(ctx, sess, event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_InsertWebhookEvents(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	first := &webhook_events.WebhookEvents{EventID: "e1", EventType: webhook_events.EventSessionRevoked, Payload: []byte(`{}`)}
+	second := &webhook_events.WebhookEvents{EventID: "e2", EventType: webhook_events.EventSessionRevoked, Payload: []byte(`{}`)}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_outbox (event_id,event_type,payload,trace_parent) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)")).
+			WithArgs(first.EventID, first.EventType, first.Payload, "", second.EventID, second.EventType, second.Payload, "").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		expectWebhookDeliveries(mock, first.EventID, second.EventID)
+		mock.ExpectCommit()
+
+		if err := repo.InsertWebhookEvents(t.Context(), first, second); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("no events", func(t *testing.T) {
+		if err := repo.InsertWebhookEvents(t.Context()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
 }
 
-func TestRepo_DeleteSessionByUserID(t *testing.T) {
+func TestRepo_DeleteSessionByID(t *testing.T) {
 	repo, mock, closer, err := setupDataBase(t)
 	if err != nil {
 		t.Fatalf("failed to open sqlmock database: %s", err)
 	}
 	defer closer()
 
-	expectQuery := regexp.QuoteMeta("DELETE FROM sessions WHERE user_id = $1")
+	expectQuery := regexp.QuoteMeta("DELETE FROM sessions WHERE session_id = $1")
 
 	t.Run("success", func(t *testing.T) {
 		mock.ExpectExec(expectQuery).
-			WithArgs("user_id_test").
+			WithArgs("session_id_test").
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		err := repo.DeleteSessionByUserID("user_id_test")
+		err := repo.DeleteSessionByID(t.Context(), "session_id_test")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -166,9 +310,9 @@ func TestRepo_DeleteSessionByUserID(t *testing.T) {
 
 	t.Run("sql error", func(t *testing.T) {
 		mock.ExpectExec(expectQuery).
-			WithArgs("user_id_test").
+			WithArgs("session_id_test").
 			WillReturnError(errors.New("db error"))
-		err := repo.DeleteSessionByUserID("user_id_test")
+		err := repo.DeleteSessionByID(t.Context(), "session_id_test")
 		if err == nil {
 			t.Fatalf("expected error, got nil")
 		}
@@ -176,25 +320,293 @@ func TestRepo_DeleteSessionByUserID(t *testing.T) {
 			t.Errorf("unmet expectations: %v", err)
 		}
 	})
+
+	t.Run("with webhook event", func(t *testing.T) {
+		event := &webhook_events.WebhookEvents{EventID: "event_id_test", EventType: webhook_events.EventRefreshReuseDetected, Payload: []byte(`{}`)}
+		mock.ExpectBegin()
+		mock.ExpectExec(expectQuery).
+			WithArgs("session_id_test").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_outbox (event_id,event_type,payload,trace_parent) VALUES ($1,$2,$3,$4)")).
+			WithArgs(event.EventID, event.EventType, event.Payload, "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectWebhookDeliveries(mock, event.EventID)
+		mock.ExpectCommit()
+
+		if err := repo.DeleteSessionByID(t.Context(), "session_id_test", event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("webhook event not saved", func(t *testing.T) {
+		event := &webhook_events.WebhookEvents{EventID: "event_id_test", EventType: webhook_events.EventRefreshReuseDetected, Payload: []byte(`{}`)}
+		mock.ExpectBegin()
+		mock.ExpectExec(expectQuery).
+			WithArgs("session_id_test").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_outbox")).
+			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		if err := repo.DeleteSessionByID(t.Context(), "session_id_test", event); !errors.Is(err, apperrors.ErrCantExecSQLQuery) {
+			t.Fatalf("expected ErrCantExecSQLQuery, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
 }
 
-func TestRepo_UpdateRefreshTokenByUserID(t *testing.T) {
+func TestRepo_RotateRefreshToken(t *testing.T) {
 	repo, mock, closer, err := setupDataBase(t)
 	if err != nil {
 		t.Fatalf("failed to open sqlmock database: %s", err)
 	}
 	defer closer()
 
-	expectQuery := regexp.QuoteMeta("UPDATE sessions SET refresh_token_hash = $1 WHERE user_id = $2")
+	expectUpdate := regexp.QuoteMeta("UPDATE sessions SET refresh_token_hash = $1, last_used_at = now() WHERE refresh_token_hash = $2 AND session_id = $3")
+	expectInsert := regexp.QuoteMeta("INSERT INTO refresh_token_history (token_digest,session_id) VALUES ($1,$2)")
 
 	t.Run("success", func(t *testing.T) {
-		mock.ExpectExec(expectQuery).
-			WithArgs("refresh_token_hash_test", "user_id_test").
-			WillReturnResult(sqlmock.NewResult(1, 1))
-		err := repo.UpdateRefreshTokenByUserID("user_id_test", "refresh_token_hash_test")
+		mock.ExpectBegin()
+		mock.ExpectExec(expectUpdate).
+			WithArgs("new_hash", "old_hash", "session_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectInsert).
+			WithArgs("used_digest", "session_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.RotateRefreshToken(t.Context(), "session_id_test", "used_digest", "old_hash", "new_hash")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("with webhook events", func(t *testing.T) {
+		first := &webhook_events.WebhookEvents{EventID: "event_1", EventType: webhook_events.EventSessionIPChanged, Payload: []byte(`{"n":1}`)}
+		second := &webhook_events.WebhookEvents{EventID: "event_2", EventType: webhook_events.EventSessionIPChanged, Payload: []byte(`{"n":2}`)}
+		mock.ExpectBegin()
+		mock.ExpectExec(expectUpdate).
+			WithArgs("new_hash", "old_hash", "session_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectInsert).
+			WithArgs("used_digest", "session_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_outbox (event_id,event_type,payload,trace_parent) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)")).
+			WithArgs(first.EventID, first.EventType, first.Payload, "", second.EventID, second.EventType, second.Payload, "").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		expectWebhookDeliveries(mock, first.EventID, second.EventID)
+		mock.ExpectCommit()
+
+		err := repo.RotateRefreshToken(t.Context(), "session_id_test", "used_digest", "old_hash", "new_hash", first, second)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("already rotated", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(expectUpdate).
+			WithArgs("new_hash", "old_hash", "session_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.RotateRefreshToken(t.Context(), "session_id_test", "used_digest", "old_hash", "new_hash")
+		if !errors.Is(err, apperrors.ErrRefreshTokenReused) {
+			t.Fatalf("expected ErrRefreshTokenReused, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("sql error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(expectUpdate).
+			WithArgs("new_hash", "old_hash", "session_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectInsert).
+			WithArgs("used_digest", "session_id_test").
+			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		err := repo.RotateRefreshToken(t.Context(), "session_id_test", "used_digest", "old_hash", "new_hash")
+		if !errors.Is(err, apperrors.ErrCantExecSQLQuery) {
+			t.Fatalf("expected ErrCantExecSQLQuery, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_IsRefreshTokenRotated(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("SELECT count(*) FROM refresh_token_history WHERE session_id = $1 AND token_digest = $2")
+
+	t.Run("rotated", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("session_id_test", "digest").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		isRotated, err := repo.IsRefreshTokenRotated(t.Context(), "session_id_test", "digest")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !isRotated {
+			t.Error("expected token to be rotated")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("session_id_test", "digest").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		isRotated, err := repo.IsRefreshTokenRotated(t.Context(), "session_id_test", "digest")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if isRotated {
+			t.Error("expected token not to be rotated")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_ListSessionsByUserID(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("SELECT session_id, user_id, refresh_token_hash, user_agent, ip_addr, client_id, scope, amr, created_at, last_used_at FROM sessions WHERE user_id = $1 ORDER BY last_used_at DESC")
+	columns := []string{"session_id", "user_id", "refresh_token_hash", "user_agent", "ip_addr", "client_id", "scope", "amr", "created_at", "last_used_at"}
+
+	t.Run("success", func(t *testing.T) {
+		now := time.Now()
+		rows := sqlmock.NewRows(columns).
+			AddRow("session_1", "user_id_test", []byte("hash_1"), "laptop", "1.1.1.1", "", "", "", now, now).
+			AddRow("session_2", "user_id_test", []byte("hash_2"), "phone", "2.2.2.2", "spa", "profile", "pwd otp", now, now)
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user_id_test").
+			WillReturnRows(rows)
+
+		userSessions, err := repo.ListSessionsByUserID(t.Context(), "user_id_test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(userSessions) != 2 {
+			t.Fatalf("len(sessions) = %d; want 2", len(userSessions))
+		}
+		if userSessions[1].SessionID != "session_2" || userSessions[1].UserAgent != "phone" {
+			t.Errorf("unexpected session: %+v", userSessions[1])
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("no sessions", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user_id_test").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		userSessions, err := repo.ListSessionsByUserID(t.Context(), "user_id_test")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		if userSessions == nil || len(userSessions) != 0 {
+			t.Errorf("expected empty non-nil slice, got %v", userSessions)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("sql error", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user_id_test").
+			WillReturnError(errors.New("db error"))
+
+		_, err := repo.ListSessionsByUserID(t.Context(), "user_id_test")
+		if !errors.Is(err, apperrors.ErrCantExecSQLQuery) {
+			t.Fatalf("expected ErrCantExecSQLQuery, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_DeleteSessionsByIDs(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("DELETE FROM sessions WHERE user_id = $1 AND session_id IN ($2,$3)")
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs("user_id_test", "session_1", "session_2").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		if err := repo.DeleteSessionsByIDs(t.Context(), "user_id_test", []string{"session_1", "session_2"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("nothing to delete", func(t *testing.T) {
+		if err := repo.DeleteSessionsByIDs(t.Context(), "user_id_test", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("with webhook events in one transaction", func(t *testing.T) {
+		first := &webhook_events.WebhookEvents{EventID: "event_1", EventType: webhook_events.EventSessionRevoked, Payload: []byte(`{}`)}
+		second := &webhook_events.WebhookEvents{EventID: "event_2", EventType: webhook_events.EventSessionRevoked, Payload: []byte(`{}`)}
+		mock.ExpectBegin()
+		mock.ExpectExec(expectQuery).
+			WithArgs("user_id_test", "session_1", "session_2").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_outbox (event_id,event_type,payload,trace_parent) VALUES ($1,$2,$3,$4),($5,$6,$7,$8)")).
+			WithArgs(first.EventID, first.EventType, first.Payload, "", second.EventID, second.EventType, second.Payload, "").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		expectWebhookDeliveries(mock, first.EventID, second.EventID)
+		mock.ExpectCommit()
+
+		if err := repo.DeleteSessionsByIDs(t.Context(), "user_id_test", []string{"session_1", "session_2"}, first, second); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
 		}
@@ -202,10 +614,10 @@ func TestRepo_UpdateRefreshTokenByUserID(t *testing.T) {
 
 	t.Run("sql error", func(t *testing.T) {
 		mock.ExpectExec(expectQuery).
-			WithArgs("refresh_token_hash_test", "user_id_test").
+			WithArgs("user_id_test", "session_1", "session_2").
 			WillReturnError(errors.New("db error"))
-		err := repo.UpdateRefreshTokenByUserID("user_id_test", "refresh_token_hash_test")
-		if err == nil {
+
+		if err := repo.DeleteSessionsByIDs(t.Context(), "user_id_test", []string{"session_1", "session_2"}); err == nil {
 			t.Fatalf("expected error, got nil")
 		}
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -213,3 +625,832 @@ func TestRepo_UpdateRefreshTokenByUserID(t *testing.T) {
 		}
 	})
 }
+
+func TestRepo_GetClientByID(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("SELECT client_id, client_secret_hash, name, scopes, grant_types, redirect_uris, created_at FROM oauth_clients WHERE client_id = $1")
+	columns := []string{"client_id", "client_secret_hash", "name", "scopes", "grant_types", "redirect_uris", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("client_id_test").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("client_id_test", []byte("hash"), "gateway", "jobs:read jobs:write", "client_credentials", "", time.Now()))
+
+		client, err := repo.GetClientByID(t.Context(), "client_id_test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.ClientID != "client_id_test" || string(client.ClientSecretHash) != "hash" || client.Name != "gateway" ||
+			client.Scopes != "jobs:read jobs:write" || client.GrantTypes != "client_credentials" {
+			t.Errorf("unexpected client: %+v", client)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("client_id_test").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetClientByID(t.Context(), "client_id_test")
+		if !errors.Is(err, apperrors.ErrClientNotFound) {
+			t.Fatalf("expected ErrClientNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_CreateClient(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("INSERT INTO oauth_clients (client_id,client_secret_hash,name,scopes,grant_types,redirect_uris) VALUES ($1,$2,$3,$4,$5,$6)")
+	client := &clients.Clients{ClientID: "client_id_test", ClientSecretHash: []byte("hash"), Name: "gateway", Scopes: "profile", GrantTypes: "authorization_code", RedirectURIs: "https://app.example.com/callback"}
+
+	mock.ExpectExec(expectQuery).
+		WithArgs(client.ClientID, client.ClientSecretHash, client.Name, client.Scopes, client.GrantTypes, client.RedirectURIs).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := repo.CreateClient(t.Context(), client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRepo_CreateUser(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("INSERT INTO users (user_id,email,password_hash) VALUES ($1,$2,$3)")
+	user := &users.Users{UserID: "user_id_test", Email: "user@example.com", PasswordHash: "hash"}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(user.UserID, user.Email, user.PasswordHash).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		if err := repo.CreateUser(t.Context(), user); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("email already taken", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(user.UserID, user.Email, user.PasswordHash).
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		if err := repo.CreateUser(t.Context(), user); !errors.Is(err, apperrors.ErrUserAlreadyExists) {
+			t.Fatalf("expected ErrUserAlreadyExists, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("sql error", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(user.UserID, user.Email, user.PasswordHash).
+			WillReturnError(errors.New("db error"))
+
+		if err := repo.CreateUser(t.Context(), user); !errors.Is(err, apperrors.ErrCantExecSQLQuery) {
+			t.Fatalf("expected ErrCantExecSQLQuery, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_GetUserByEmail(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("SELECT user_id, email, password_hash, created_at FROM users WHERE email = $1")
+	columns := []string{"user_id", "email", "password_hash", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user@example.com").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("user_id_test", "user@example.com", "hash", time.Now()))
+
+		user, err := repo.GetUserByEmail(t.Context(), "user@example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if user.UserID != "user_id_test" || user.PasswordHash != "hash" {
+			t.Errorf("unexpected user: %+v", user)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user@example.com").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetUserByEmail(t.Context(), "user@example.com")
+		if !errors.Is(err, apperrors.ErrUserNotFound) {
+			t.Fatalf("expected ErrUserNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_GetUserTOTP(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("SELECT user_id, secret_ciphertext, confirmed_at, last_used_step, created_at FROM user_totp WHERE user_id = $1")
+	columns := []string{"user_id", "secret_ciphertext", "confirmed_at", "last_used_step", "created_at"}
+
+	t.Run("confirmed", func(t *testing.T) {
+		confirmedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user_id_test").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("user_id_test", []byte("ciphertext"), confirmedAt, int64(42), time.Now()))
+
+		userTOTP, err := repo.GetUserTOTP(t.Context(), "user_id_test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(userTOTP.SecretCiphertext) != "ciphertext" || userTOTP.LastUsedStep != 42 {
+			t.Errorf("unexpected totp: %+v", userTOTP)
+		}
+		if userTOTP.ConfirmedAt == nil || !userTOTP.ConfirmedAt.Equal(confirmedAt) {
+			t.Errorf("ConfirmedAt = %v; want %s", userTOTP.ConfirmedAt, confirmedAt)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("not confirmed", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user_id_test").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("user_id_test", []byte("ciphertext"), nil, int64(0), time.Now()))
+
+		userTOTP, err := repo.GetUserTOTP(t.Context(), "user_id_test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if userTOTP.ConfirmedAt != nil {
+			t.Errorf("ConfirmedAt = %v; want nil", userTOTP.ConfirmedAt)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs("user_id_test").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetUserTOTP(t.Context(), "user_id_test")
+		if !errors.Is(err, apperrors.ErrTOTPNotFound) {
+			t.Fatalf("expected ErrTOTPNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_SaveUserTOTP(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("INSERT INTO user_totp (user_id,secret_ciphertext) VALUES ($1,$2) ON CONFLICT (user_id) DO UPDATE SET secret_ciphertext = EXCLUDED.secret_ciphertext, last_used_step = 0, created_at = now() WHERE user_totp.confirmed_at IS NULL")
+	userTOTP := &user_totp.UserTOTP{UserID: "user_id_test", SecretCiphertext: []byte("ciphertext")}
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(userTOTP.UserID, userTOTP.SecretCiphertext).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.SaveUserTOTP(t.Context(), userTOTP); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("already confirmed", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(userTOTP.UserID, userTOTP.SecretCiphertext).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.SaveUserTOTP(t.Context(), userTOTP); !errors.Is(err, apperrors.ErrTOTPAlreadyEnrolled) {
+			t.Fatalf("expected ErrTOTPAlreadyEnrolled, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_ConfirmUserTOTP(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("UPDATE user_totp SET confirmed_at = now(), last_used_step = $1 WHERE confirmed_at IS NULL AND user_id = $2")
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(int64(100), "user_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.ConfirmUserTOTP(t.Context(), "user_id_test", 100); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("already confirmed", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(int64(100), "user_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.ConfirmUserTOTP(t.Context(), "user_id_test", 100); !errors.Is(err, apperrors.ErrTOTPAlreadyEnrolled) {
+			t.Fatalf("expected ErrTOTPAlreadyEnrolled, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_UseTOTPStep(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("UPDATE user_totp SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $3")
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(int64(100), "user_id_test", int64(100)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.UseTOTPStep(t.Context(), "user_id_test", 100); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("code already used", func(t *testing.T) {
+		mock.ExpectExec(expectQuery).
+			WithArgs(int64(100), "user_id_test", int64(100)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.UseTOTPStep(t.Context(), "user_id_test", 100); !errors.Is(err, apperrors.ErrInvalidTOTPCode) {
+			t.Fatalf("expected ErrInvalidTOTPCode, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+// expectWebhookDeliveries — постановка событий в доставку подпискам после вставки в outbox
+func expectWebhookDeliveries(mock sqlmock.Sqlmock, eventIDs ...string) {
+	placeholders := make([]string, 0, len(eventIDs))
+	args := []driver.Value{"active", "paused"}
+	for i, eventID := range eventIDs {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", i+3))
+		args = append(args, eventID)
+	}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_deliveries (delivery_id,event_id,subscription_id) SELECT gen_random_uuid(), e.event_id, s.subscription_id FROM webhook_outbox e JOIN webhook_subscriptions s ON s.status IN ($1, $2) AND (s.event_types = '' OR e.event_type = ANY(string_to_array(s.event_types, ' '))) WHERE e.event_id IN (" + strings.Join(placeholders, ",") + ")")).
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestRepo_WebhookSubscriptions(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	subscription := &webhook_subscriptions.WebhookSubscriptions{
+		SubscriptionID: "sub_id_test",
+		URL:            "https://example.com/hook",
+		Secret:         "secret",
+		EventTypes:     "session.revoked refresh.reuse_detected",
+		Status:         webhook_subscriptions.StatusActive,
+	}
+	columns := []string{"subscription_id", "url", "secret", "event_types", "status", "created_at", "updated_at"}
+
+	t.Run("create", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_subscriptions (subscription_id,url,secret,event_types,status) VALUES ($1,$2,$3,$4,$5)")).
+			WithArgs(subscription.SubscriptionID, subscription.URL, subscription.Secret, subscription.EventTypes, subscription.Status).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.CreateWebhookSubscription(t.Context(), subscription); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT subscription_id, url, secret, event_types, status, created_at, updated_at FROM webhook_subscriptions WHERE subscription_id = $1")).
+			WithArgs("unknown").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		if _, err := repo.GetWebhookSubscriptionByID(t.Context(), "unknown"); !errors.Is(err, apperrors.ErrWebhookSubscriptionNotFound) {
+			t.Fatalf("expected ErrWebhookSubscriptionNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT subscription_id, url, secret, event_types, status, created_at, updated_at FROM webhook_subscriptions ORDER BY created_at")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("sub_id_test", "https://example.com/hook", "secret", "", "paused", now, now))
+
+		subscriptions, err := repo.ListWebhookSubscriptions(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(subscriptions) != 1 || subscriptions[0].Status != webhook_subscriptions.StatusPaused {
+			t.Errorf("unexpected subscriptions: %+v", subscriptions)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("adopt legacy subscription", func(t *testing.T) {
+		legacy := &webhook_subscriptions.WebhookSubscriptions{
+			SubscriptionID: webhook_subscriptions.LegacySubscriptionID,
+			URL:            "https://example.com/hook",
+			Secret:         "secret",
+			Status:         webhook_subscriptions.StatusActive,
+		}
+		query := regexp.QuoteMeta("INSERT INTO webhook_subscriptions (subscription_id,url,secret,event_types,status) VALUES ($1,$2,$3,$4,$5) " +
+			"ON CONFLICT (subscription_id) DO UPDATE SET url = EXCLUDED.url, secret = EXCLUDED.secret, status = EXCLUDED.status, updated_at = now() WHERE webhook_subscriptions.url = ''")
+		mock.ExpectExec(query).
+			WithArgs(legacy.SubscriptionID, legacy.URL, legacy.Secret, "", legacy.Status).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		// подписка уже с URL: управляется через API и не перезаписывается
+		mock.ExpectExec(query).
+			WithArgs(legacy.SubscriptionID, legacy.URL, legacy.Secret, "", legacy.Status).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		adopted, err := repo.AdoptLegacyWebhookSubscription(t.Context(), legacy)
+		if err != nil || !adopted {
+			t.Fatalf("expected adopted subscription, got: %v, %v", adopted, err)
+		}
+		adopted, err = repo.AdoptLegacyWebhookSubscription(t.Context(), legacy)
+		if err != nil || adopted {
+			t.Fatalf("expected untouched subscription, got: %v, %v", adopted, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("update not found", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE webhook_subscriptions SET url = $1, secret = $2, event_types = $3, status = $4, updated_at = now() WHERE subscription_id = $5")).
+			WithArgs(subscription.URL, subscription.Secret, subscription.EventTypes, subscription.Status, subscription.SubscriptionID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.UpdateWebhookSubscription(t.Context(), subscription); !errors.Is(err, apperrors.ErrWebhookSubscriptionNotFound) {
+			t.Fatalf("expected ErrWebhookSubscriptionNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM webhook_subscriptions WHERE subscription_id = $1")).
+			WithArgs("sub_id_test").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.DeleteWebhookSubscription(t.Context(), "sub_id_test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_ClaimWebhookDeliveries(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	expectQuery := regexp.QuoteMeta("UPDATE webhook_deliveries d SET next_attempt_at = now() + $1 * interval '1 second' FROM webhook_outbox e, webhook_subscriptions s WHERE e.event_id = d.event_id AND s.subscription_id = d.subscription_id AND d.delivery_id IN (SELECT pd.delivery_id FROM webhook_deliveries pd JOIN webhook_subscriptions ps ON ps.subscription_id = pd.subscription_id WHERE pd.status = $2 AND ps.status = $3 AND pd.next_attempt_at <= now() ORDER BY pd.next_attempt_at LIMIT 10 FOR UPDATE OF pd SKIP LOCKED) RETURNING d.delivery_id, d.event_id, d.subscription_id, e.event_type, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.delivered_at, e.payload, s.url, s.secret, e.trace_parent, d.next_attempt_at AS leased_until")
+	columns := []string{"delivery_id", "event_id", "subscription_id", "event_type", "status", "attempts", "next_attempt_at", "last_error", "created_at", "delivered_at", "payload", "url", "secret", "trace_parent", "leased_until"}
+
+	t.Run("success", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(expectQuery).
+			WithArgs(float64(60), "pending", "active").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("delivery_id_test", "event_id_test", "sub_id_test", "session.ip_changed", "pending", 2, now, "timeout", now, nil, []byte(`{}`), "https://example.com/hook", "secret", "", now))
+
+		deliveries, err := repo.ClaimWebhookDeliveries(t.Context(), 10, time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deliveries) != 1 || deliveries[0].DeliveryID != "delivery_id_test" || deliveries[0].Attempts != 2 || deliveries[0].URL != "https://example.com/hook" || !deliveries[0].LeasedUntil.Equal(now) {
+			t.Errorf("unexpected deliveries: %+v", deliveries)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("sql error", func(t *testing.T) {
+		mock.ExpectQuery(expectQuery).
+			WithArgs(float64(60), "pending", "active").
+			WillReturnError(errors.New("db error"))
+
+		if _, err := repo.ClaimWebhookDeliveries(t.Context(), 10, time.Minute); !errors.Is(err, apperrors.ErrCantExecSQLQuery) {
+			t.Fatalf("expected ErrCantExecSQLQuery, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_PausedSubscriptionDeliveries(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	subscription := &webhook_subscriptions.WebhookSubscriptions{
+		SubscriptionID: "sub_id_test",
+		URL:            "https://example.com/hook",
+		Secret:         "secret",
+		Status:         webhook_subscriptions.StatusPaused,
+	}
+	event := &webhook_events.WebhookEvents{EventID: "event_id_test", EventType: webhook_events.EventSessionRevoked, Payload: []byte(`{}`)}
+	updateQuery := regexp.QuoteMeta("UPDATE webhook_subscriptions SET url = $1, secret = $2, event_types = $3, status = $4, updated_at = now() WHERE subscription_id = $5")
+	claimQuery := regexp.QuoteMeta("UPDATE webhook_deliveries d SET next_attempt_at = now() + $1 * interval '1 second' FROM webhook_outbox e, webhook_subscriptions s WHERE e.event_id = d.event_id AND s.subscription_id = d.subscription_id AND d.delivery_id IN (SELECT pd.delivery_id FROM webhook_deliveries pd JOIN webhook_subscriptions ps ON ps.subscription_id = pd.subscription_id WHERE pd.status = $2 AND ps.status = $3 AND pd.next_attempt_at <= now() ORDER BY pd.next_attempt_at LIMIT 10 FOR UPDATE OF pd SKIP LOCKED) RETURNING d.delivery_id, d.event_id, d.subscription_id, e.event_type, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.delivered_at, e.payload, s.url, s.secret, e.trace_parent, d.next_attempt_at AS leased_until")
+	columns := []string{"delivery_id", "event_id", "subscription_id", "event_type", "status", "attempts", "next_attempt_at", "last_error", "created_at", "delivered_at", "payload", "url", "secret", "trace_parent", "leased_until"}
+
+	// подписка приостановлена
+	mock.ExpectExec(updateQuery).
+		WithArgs(subscription.URL, subscription.Secret, "", "paused", subscription.SubscriptionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.UpdateWebhookSubscription(t.Context(), subscription); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// событие, возникшее во время паузы, всё равно ставится ей в доставку
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_outbox (event_id,event_type,payload,trace_parent) VALUES ($1,$2,$3,$4)")).
+		WithArgs(event.EventID, event.EventType, event.Payload, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectWebhookDeliveries(mock, event.EventID)
+	mock.ExpectCommit()
+	if err := repo.InsertWebhookEvents(t.Context(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// пока подписка на паузе, доставку не забирают
+	mock.ExpectQuery(claimQuery).
+		WithArgs(float64(60), "pending", "active").
+		WillReturnRows(sqlmock.NewRows(columns))
+	deliveries, err := repo.ClaimWebhookDeliveries(t.Context(), 10, time.Minute)
+	if err != nil || len(deliveries) != 0 {
+		t.Fatalf("expected no deliveries while paused, got: %+v, %v", deliveries, err)
+	}
+
+	// после возобновления доставка уходит
+	subscription.Status = webhook_subscriptions.StatusActive
+	mock.ExpectExec(updateQuery).
+		WithArgs(subscription.URL, subscription.Secret, "", "active", subscription.SubscriptionID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.UpdateWebhookSubscription(t.Context(), subscription); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	mock.ExpectQuery(claimQuery).
+		WithArgs(float64(60), "pending", "active").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("delivery_id_test", event.EventID, subscription.SubscriptionID, event.EventType, "pending", 0, now, "", now, nil, event.Payload, subscription.URL, subscription.Secret, "", now))
+	deliveries, err = repo.ClaimWebhookDeliveries(t.Context(), 10, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].EventID != event.EventID || deliveries[0].SubscriptionID != subscription.SubscriptionID {
+		t.Errorf("unexpected deliveries: %+v", deliveries)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRepo_SaveWebhookDeliveryAttempt(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	nextAttemptAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	delivery := &webhook_deliveries.WebhookDeliveries{DeliveryID: "delivery_id_test", Status: "dead", Attempts: 10, NextAttemptAt: nextAttemptAt, LastError: "status 500"}
+	attempt := &webhook_deliveries.DeliveryAttempts{AttemptedAt: nextAttemptAt, StatusCode: 500, Error: "status 500", DurationMS: 12}
+
+	leasedUntil := nextAttemptAt.Add(-time.Minute)
+	expectUpdate := regexp.QuoteMeta("UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, delivered_at = $5 WHERE delivery_id = $6 AND next_attempt_at = $7")
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(expectUpdate).
+			WithArgs("dead", 10, nextAttemptAt, "status 500", delivery.DeliveredAt, "delivery_id_test", leasedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO webhook_delivery_attempts (delivery_id,attempted_at,status_code,error,duration_ms) VALUES ($1,$2,$3,$4,$5)")).
+			WithArgs("delivery_id_test", nextAttemptAt, 500, "status 500", int64(12)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		if err := repo.SaveWebhookDeliveryAttempt(t.Context(), delivery, attempt, leasedUntil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("lease lost", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(expectUpdate).
+			WithArgs("dead", 10, nextAttemptAt, "status 500", delivery.DeliveredAt, "delivery_id_test", leasedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		if err := repo.SaveWebhookDeliveryAttempt(t.Context(), delivery, attempt, leasedUntil); !errors.Is(err, apperrors.ErrWebhookDeliveryLeaseLost) {
+			t.Errorf("expected ErrWebhookDeliveryLeaseLost, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_WebhookDeliveriesLog(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	t.Run("list deliveries by status", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT d.delivery_id, d.event_id, d.subscription_id, e.event_type, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.delivered_at FROM webhook_deliveries d JOIN webhook_outbox e ON e.event_id = d.event_id WHERE d.subscription_id = $1 AND d.status = $2 ORDER BY d.created_at DESC LIMIT 50")).
+			WithArgs("sub_id_test", "dead").
+			WillReturnRows(sqlmock.NewRows([]string{"delivery_id", "event_id", "subscription_id", "event_type", "status", "attempts", "next_attempt_at", "last_error", "created_at", "delivered_at"}).
+				AddRow("delivery_id_test", "event_id_test", "sub_id_test", "session.revoked", "dead", 12, now, "status 500", now, nil))
+
+		deliveries, err := repo.ListWebhookDeliveries(t.Context(), "sub_id_test", "dead", 50)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deliveries) != 1 || deliveries[0].EventType != "session.revoked" {
+			t.Errorf("unexpected deliveries: %+v", deliveries)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("list attempts", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT attempt_id, delivery_id, attempted_at, status_code, error, duration_ms FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY attempted_at, attempt_id")).
+			WithArgs("delivery_id_test").
+			WillReturnRows(sqlmock.NewRows([]string{"attempt_id", "delivery_id", "attempted_at", "status_code", "error", "duration_ms"}).
+				AddRow(1, "delivery_id_test", now, 0, "timeout", 10000).
+				AddRow(2, "delivery_id_test", now, 500, "status 500", 15))
+
+		attempts, err := repo.ListWebhookDeliveryAttempts(t.Context(), "delivery_id_test")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(attempts) != 2 || attempts[1].StatusCode != 500 {
+			t.Errorf("unexpected attempts: %+v", attempts)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("replay unknown delivery", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = now(), last_error = $3, delivered_at = $4 WHERE delivery_id = $5")).
+			WithArgs("pending", 0, "", nil, "unknown").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.ReplayWebhookDelivery(t.Context(), "unknown"); !errors.Is(err, apperrors.ErrWebhookDeliveryNotFound) {
+			t.Fatalf("expected ErrWebhookDeliveryNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_AuditEvents(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	newEvent := func() *audit_events.AuditEvents {
+		return &audit_events.AuditEvents{
+			EventType: audit_events.EventLogin,
+			Actor:     audit_events.ActorUser,
+			UserID:    "user_id_test",
+			SessionID: "session_id_test",
+			IPAddr:    "1.2.3.4",
+			UserAgent: "ua",
+			Outcome:   audit_events.OutcomeSuccess,
+		}
+	}
+	expectInsert := func(lastHash *string, prevHash string) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+			WithArgs(auditChainLockKey).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		lastRows := sqlmock.NewRows([]string{"hash"})
+		if lastHash != nil {
+			lastRows.AddRow(*lastHash)
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT hash FROM audit_events ORDER BY audit_id DESC LIMIT 1")).
+			WillReturnRows(lastRows)
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO audit_events (event_type,actor,user_id,session_id,ip_addr,user_agent,outcome,error_code,created_at,prev_hash,hash) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)")).
+			WithArgs("login", "user", "user_id_test", "session_id_test", "1.2.3.4", "ua", "success", "", sqlmock.AnyArg(), prevHash, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	t.Run("insert starts the chain", func(t *testing.T) {
+		expectInsert(nil, "")
+
+		event := newEvent()
+		if err := repo.InsertAuditEvent(t.Context(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event.PrevHash != "" || event.Hash != audit_chain.Hash("", event) {
+			t.Errorf("unexpected chain fields: %+v", event)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("insert links to the last record", func(t *testing.T) {
+		lastHash := "last_hash_test"
+		expectInsert(&lastHash, lastHash)
+
+		event := newEvent()
+		if err := repo.InsertAuditEvent(t.Context(), event); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event.PrevHash != lastHash || event.Hash != audit_chain.Hash(lastHash, event) {
+			t.Errorf("unexpected chain fields: %+v", event)
+		}
+		if event.CreatedAt.IsZero() || event.CreatedAt.Nanosecond()%1000 != 0 {
+			t.Errorf("created_at must be set with microsecond precision: %v", event.CreatedAt)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("list with filters and cursor", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.Add(24 * time.Hour)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT audit_id, event_type, actor, user_id, session_id, ip_addr, user_agent, outcome, error_code, created_at, prev_hash, hash FROM audit_events WHERE created_at >= $1 AND created_at < $2 AND user_id = $3 AND event_type IN ($4,$5) AND audit_id < $6 ORDER BY audit_id DESC LIMIT 11")).
+			WithArgs(from, to, "user_id_test", "login", "logout", int64(100)).
+			WillReturnRows(sqlmock.NewRows([]string{"audit_id", "event_type", "actor", "user_id", "session_id", "ip_addr", "user_agent", "outcome", "error_code", "created_at", "prev_hash", "hash"}).
+				AddRow(99, "login", "user", "user_id_test", "session_id_test", "1.2.3.4", "ua", "success", "", from, "prev", "hash"))
+
+		events, err := repo.ListAuditEvents(t.Context(), &audit_events.AuditEventFilter{
+			From:       from,
+			To:         to,
+			UserID:     "user_id_test",
+			EventTypes: []string{audit_events.EventLogin, audit_events.EventLogout},
+			BeforeID:   100,
+			Limit:      11,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].AuditID != 99 || events[0].IPAddr != "1.2.3.4" {
+			t.Errorf("unexpected events: %+v", events)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRepo_AuditCheckpoints(t *testing.T) {
+	repo, mock, closer, err := setupDataBase(t)
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer closer()
+
+	t.Run("no checkpoints yet", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT checkpoint_id, audit_id, hash, signature, created_at FROM audit_checkpoints ORDER BY checkpoint_id DESC LIMIT 1")).
+			WillReturnRows(sqlmock.NewRows([]string{"checkpoint_id", "audit_id", "hash", "signature", "created_at"}))
+
+		if _, err := repo.GetLastAuditCheckpoint(t.Context()); !errors.Is(err, apperrors.ErrAuditCheckpointNotFound) {
+			t.Fatalf("expected ErrAuditCheckpointNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO audit_checkpoints (audit_id,hash,signature) VALUES ($1,$2,$3)")).
+			WithArgs(int64(42), "hash_test", "signature_test").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repo.InsertAuditCheckpoint(t.Context(), &audit_events.AuditCheckpoints{AuditID: 42, Hash: "hash_test", Signature: "signature_test"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("export page", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT audit_id, event_type, actor, user_id, session_id, ip_addr, user_agent, outcome, error_code, created_at, prev_hash, hash FROM audit_events WHERE audit_id > $1 ORDER BY audit_id LIMIT 500")).
+			WithArgs(int64(41)).
+			WillReturnRows(sqlmock.NewRows([]string{"audit_id", "event_type", "actor", "user_id", "session_id", "ip_addr", "user_agent", "outcome", "error_code", "created_at", "prev_hash", "hash"}).
+				AddRow(42, "logout", "user", "user_id_test", "session_id_test", "1.2.3.4", "ua", "success", "", now, "prev", "hash_test"))
+
+		events, err := repo.ListAuditEventsAfter(t.Context(), 41, 500)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].Hash != "hash_test" {
+			t.Errorf("unexpected events: %+v", events)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+)
+
+// RotateRefreshToken заменяет refresh токен сессии и запоминает использованный в истории семейства.
+// Замена происходит только если в базе всё ещё лежит oldRefreshTokenHash, иначе токен уже был ротирован параллельно.
+// События webhook пишутся в outbox в той же транзакции.
+func (repo *Repo) RotateRefreshToken(ctx context.Context, sessionID string, usedRefreshTokenDigest string, oldRefreshTokenHash string, newRefreshTokenHash string, events ...*webhook_events.WebhookEvents) error {
+	ctx, done := repo.startQuery(ctx, "RotateRefreshToken")
+	defer done()
+
+	updateQuery, updateArgs, err := psql.Update("sessions").
+		Set("refresh_token_hash", newRefreshTokenHash).
+		Set("last_used_at", sq.Expr("now()")).
+		Where(sq.Eq{"session_id": sessionID, "refresh_token_hash": oldRefreshTokenHash}).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	insertQuery, insertArgs, err := psql.Insert("refresh_token_history").
+		Columns("token_digest", "session_id").
+		Values(usedRefreshTokenDigest, sessionID).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return repo.queryFailed(ctx, "RotateRefreshToken", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, updateQuery, updateArgs...)
+	if err != nil {
+		return repo.queryFailed(ctx, "RotateRefreshToken", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "RotateRefreshToken", err)
+	}
+	if updated == 0 {
+		return apperrors.ErrRefreshTokenReused
+	}
+
+	if _, err = tx.ExecContext(ctx, insertQuery, insertArgs...); err != nil {
+		return repo.queryFailed(ctx, "RotateRefreshToken", err)
+	}
+
+	if err = insertWebhookEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return repo.queryFailed(ctx, "RotateRefreshToken", err)
+	}
+	return nil
+}
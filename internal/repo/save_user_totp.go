@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/user_totp"
+)
+
+// SaveUserTOTP сохраняет новый неподтверждённый секрет. Неподтверждённый секрет перезаписывается
+// (пользователь начал привязку заново), подтверждённый — нет.
+func (repo *Repo) SaveUserTOTP(ctx context.Context, userTOTP *user_totp.UserTOTP) error {
+	ctx, done := repo.startQuery(ctx, "SaveUserTOTP")
+	defer done()
+
+	sb := psql.Insert("user_totp").
+		Columns("user_id", "secret_ciphertext").
+		Values(userTOTP.UserID, userTOTP.SecretCiphertext).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET secret_ciphertext = EXCLUDED.secret_ciphertext, last_used_step = 0, created_at = now() WHERE user_totp.confirmed_at IS NULL")
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return repo.queryFailed(ctx, "SaveUserTOTP", err)
+	}
+	saved, err := res.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "SaveUserTOTP", err)
+	}
+	if saved == 0 {
+		return apperrors.ErrTOTPAlreadyEnrolled
+	}
+	return nil
+}
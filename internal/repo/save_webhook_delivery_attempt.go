@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"time"
+)
+
+// SaveWebhookDeliveryAttempt сохраняет новое состояние доставки и запись о попытке в журнал.
+// leasedUntil — next_attempt_at из ClaimWebhookDeliveries: если он изменился, lease истёк и доставку забрал
+// другой экземпляр (или её переотправил администратор), и тогда ничего не пишется — ErrWebhookDeliveryLeaseLost.
+func (repo *Repo) SaveWebhookDeliveryAttempt(ctx context.Context, delivery *webhook_deliveries.WebhookDeliveries, attempt *webhook_deliveries.DeliveryAttempts, leasedUntil time.Time) error {
+	ctx, done := repo.startQuery(ctx, "SaveWebhookDeliveryAttempt")
+	defer done()
+
+	updateQuery, updateArgs, err := psql.Update("webhook_deliveries").
+		Set("status", delivery.Status).
+		Set("attempts", delivery.Attempts).
+		Set("next_attempt_at", delivery.NextAttemptAt).
+		Set("last_error", delivery.LastError).
+		Set("delivered_at", delivery.DeliveredAt).
+		Where(sq.Eq{"delivery_id": delivery.DeliveryID, "next_attempt_at": leasedUntil}).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	insertQuery, insertArgs, err := psql.Insert("webhook_delivery_attempts").
+		Columns("delivery_id", "attempted_at", "status_code", "error", "duration_ms").
+		Values(delivery.DeliveryID, attempt.AttemptedAt, attempt.StatusCode, attempt.Error, attempt.DurationMS).
+		ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return repo.queryFailed(ctx, "SaveWebhookDeliveryAttempt", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, updateQuery, updateArgs...)
+	if err != nil {
+		return repo.queryFailed(ctx, "SaveWebhookDeliveryAttempt", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "SaveWebhookDeliveryAttempt", err)
+	}
+	if updated == 0 {
+		return apperrors.ErrWebhookDeliveryLeaseLost
+	}
+	if _, err = tx.ExecContext(ctx, insertQuery, insertArgs...); err != nil {
+		return repo.queryFailed(ctx, "SaveWebhookDeliveryAttempt", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return repo.queryFailed(ctx, "SaveWebhookDeliveryAttempt", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+// UpdateWebhookSubscription сохраняет изменённую подписку целиком
+func (repo *Repo) UpdateWebhookSubscription(ctx context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) error {
+	ctx, done := repo.startQuery(ctx, "UpdateWebhookSubscription")
+	defer done()
+
+	sb := psql.Update("webhook_subscriptions").
+		Set("url", subscription.URL).
+		Set("secret", subscription.Secret).
+		Set("event_types", subscription.EventTypes).
+		Set("status", subscription.Status).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"subscription_id": subscription.SubscriptionID})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return repo.queryFailed(ctx, "UpdateWebhookSubscription", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "UpdateWebhookSubscription", err)
+	}
+	if updated == 0 {
+		return apperrors.ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// UseTOTPStep отмечает окно кода использованным. Код из того же или более раннего окна
+// уже не примется — так перехваченный код нельзя предъявить повторно (RFC 6238, раздел 5.2).
+func (repo *Repo) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	ctx, done := repo.startQuery(ctx, "UseTOTPStep")
+	defer done()
+
+	sb := psql.Update("user_totp").
+		Set("last_used_step", step).
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Lt{"last_used_step": step})
+
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return apperrors.ErrCantBuildSQLQuery
+	}
+
+	res, err := repo.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return repo.queryFailed(ctx, "UseTOTPStep", err)
+	}
+	used, err := res.RowsAffected()
+	if err != nil {
+		return repo.queryFailed(ctx, "UseTOTPStep", err)
+	}
+	if used == 0 {
+		return apperrors.ErrInvalidTOTPCode
+	}
+	return nil
+}
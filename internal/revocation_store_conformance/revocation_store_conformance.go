@@ -0,0 +1,151 @@
+// Package revocation_store_conformance — общий набор тестов для реализаций auth_service.TokenRevocationStore:
+// Redis и in-memory хранилища должны вести себя одинаково
+package revocation_store_conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TokenRevocationStore interface {
+	Revoke(ctx context.Context, token string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, token string) (bool, error)
+	Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore создаёт пустое хранилище для одного теста; advance сдвигает время хранилища вперёд
+type NewStore func(t *testing.T) (store TokenRevocationStore, advance func(d time.Duration))
+
+// Run прогоняет набор тестов на свежем хранилище для каждого случая
+func Run(t *testing.T, newStore NewStore) {
+	t.Run("unknown token is not revoked", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		revoked, err := store.IsRevoked(t.Context(), "token")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		require.NoError(t, store.Revoke(t.Context(), "token", time.Minute))
+		revoked, err := store.IsRevoked(t.Context(), "token")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		revoked, err = store.IsRevoked(t.Context(), "other")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("revocation expires after ttl", func(t *testing.T) {
+		store, advance := newStore(t)
+		require.NoError(t, store.Revoke(t.Context(), "token", time.Minute))
+
+		advance(time.Minute - time.Second)
+		revoked, err := store.IsRevoked(t.Context(), "token")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		advance(time.Second)
+		revoked, err = store.IsRevoked(t.Context(), "token")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("revoking again resets ttl", func(t *testing.T) {
+		store, advance := newStore(t)
+		require.NoError(t, store.Revoke(t.Context(), "token", time.Minute))
+
+		advance(50 * time.Second)
+		require.NoError(t, store.Revoke(t.Context(), "token", time.Minute))
+
+		advance(50 * time.Second)
+		revoked, err := store.IsRevoked(t.Context(), "token")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("zero ttl never expires", func(t *testing.T) {
+		store, advance := newStore(t)
+		require.NoError(t, store.Revoke(t.Context(), "token", 0))
+
+		advance(24 * time.Hour)
+		revoked, err := store.IsRevoked(t.Context(), "token")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("increment counts until ttl", func(t *testing.T) {
+		store, advance := newStore(t)
+
+		for want := int64(1); want <= 3; want++ {
+			count, err := store.Increment(t.Context(), "counter", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, want, count)
+		}
+		count, err := store.Increment(t.Context(), "other", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		advance(time.Minute)
+		count, err = store.Increment(t.Context(), "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("increment extends ttl", func(t *testing.T) {
+		store, advance := newStore(t)
+		_, err := store.Increment(t.Context(), "counter", time.Minute)
+		require.NoError(t, err)
+
+		advance(50 * time.Second)
+		_, err = store.Increment(t.Context(), "counter", time.Minute)
+		require.NoError(t, err)
+
+		advance(50 * time.Second)
+		count, err := store.Increment(t.Context(), "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("delete resets counter", func(t *testing.T) {
+		store, _ := newStore(t)
+		for i := 0; i < 3; i++ {
+			_, err := store.Increment(t.Context(), "counter", time.Minute)
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, store.Delete(t.Context(), "counter"))
+		count, err := store.Increment(t.Context(), "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		// отсутствующий ключ — не ошибка
+		require.NoError(t, store.Delete(t.Context(), "unknown"))
+	})
+
+	t.Run("request cancelled", func(t *testing.T) {
+		store, _ := newStore(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		assert.ErrorIs(t, store.Revoke(ctx, "token", time.Minute), context.Canceled)
+		_, err := store.IsRevoked(ctx, "token")
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = store.Increment(ctx, "token", time.Minute)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, store.Delete(ctx, "token"), context.Canceled)
+
+		// отмена не должна оставить ключ
+		revoked, err := store.IsRevoked(t.Context(), "token")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+}
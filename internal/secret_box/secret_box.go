@@ -0,0 +1,58 @@
+package secret_box
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// KeySize — AES-256
+const KeySize = 32
+
+// SecretBox шифрует секреты перед сохранением в базу (AES-256-GCM).
+// Шифротекст хранится как nonce || ciphertext || tag.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("secret box key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal шифрует plaintext. additionalData не шифруется, но аутентифицируется:
+// шифротекст, привязанный к одному user_id, не расшифруется для другого.
+func (secretBox *SecretBox) Seal(plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, secretBox.aead.NonceSize(), secretBox.aead.NonceSize()+len(plaintext)+secretBox.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return secretBox.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func (secretBox *SecretBox) Open(ciphertext []byte, additionalData []byte) ([]byte, error) {
+	nonceSize := secretBox.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, apperrors.ErrCantDecryptSecret
+	}
+
+	plaintext, err := secretBox.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], additionalData)
+	if err != nil {
+		return nil, apperrors.ErrCantDecryptSecret
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,63 @@
+package secret_box
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+func TestSecretBox(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	box, err := NewSecretBox(key)
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		ciphertext, err := box.Seal([]byte("totp secret"), []byte("user-1"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(ciphertext), "totp secret")
+
+		plaintext, err := box.Open(ciphertext, []byte("user-1"))
+		require.NoError(t, err)
+		assert.Equal(t, "totp secret", string(plaintext))
+	})
+
+	t.Run("random nonce", func(t *testing.T) {
+		first, err := box.Seal([]byte("totp secret"), nil)
+		require.NoError(t, err)
+		second, err := box.Seal([]byte("totp secret"), nil)
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("other additional data", func(t *testing.T) {
+		ciphertext, err := box.Seal([]byte("totp secret"), []byte("user-1"))
+		require.NoError(t, err)
+
+		_, err = box.Open(ciphertext, []byte("user-2"))
+		assert.ErrorIs(t, err, apperrors.ErrCantDecryptSecret)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		ciphertext, err := box.Seal([]byte("totp secret"), nil)
+		require.NoError(t, err)
+
+		otherBox, err := NewSecretBox(bytes.Repeat([]byte{8}, KeySize))
+		require.NoError(t, err)
+		_, err = otherBox.Open(ciphertext, nil)
+		assert.ErrorIs(t, err, apperrors.ErrCantDecryptSecret)
+	})
+
+	t.Run("truncated ciphertext", func(t *testing.T) {
+		_, err := box.Open([]byte{1, 2, 3}, nil)
+		assert.ErrorIs(t, err, apperrors.ErrCantDecryptSecret)
+	})
+
+	t.Run("wrong key size", func(t *testing.T) {
+		_, err := NewSecretBox([]byte("short"))
+		assert.Error(t, err)
+	})
+}
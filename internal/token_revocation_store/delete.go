@@ -0,0 +1,11 @@
+package token_revocation_store
+
+import "context"
+
+// Delete — DEL ключа <prefix><key>; отсутствующий ключ не ошибка
+func (revocationStore *TokenRevocationStore) Delete(ctx context.Context, key string) (err error) {
+	ctx, done := revocationStore.start(ctx, "delete")
+	defer done(&err)
+
+	return revocationStore.client.Del(ctx, revocationStore.keyPrefix+key).Err()
+}
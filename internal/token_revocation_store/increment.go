@@ -0,0 +1,25 @@
+package token_revocation_store
+
+import (
+	"context"
+	"time"
+)
+
+// Increment — INCR ключа <prefix><key> и PEXPIRE на ttl в одной транзакции, возвращает новое значение счётчика
+func (revocationStore *TokenRevocationStore) Increment(ctx context.Context, key string, ttl time.Duration) (_ int64, err error) {
+	ctx, done := revocationStore.start(ctx, "increment")
+	defer done(&err)
+
+	key = revocationStore.keyPrefix + key
+	pipe := revocationStore.client.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	if ttl > 0 {
+		pipe.PExpire(ctx, key, ttl)
+	} else {
+		pipe.Persist(ctx, key)
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
@@ -3,9 +3,12 @@ package token_revocation_store
 import "context"
 
 // IsRevoked — проверяет наличие ключа в Redis
-func (revocationStore *TokenRevocationStore) IsRevoked(token string) (bool, error) {
+func (revocationStore *TokenRevocationStore) IsRevoked(ctx context.Context, token string) (_ bool, err error) {
+	ctx, done := revocationStore.start(ctx, "is_revoked")
+	defer done(&err)
+
 	key := revocationStore.keyPrefix + token
-	exists, err := revocationStore.client.Exists(context.Background(), key).Result()
+	exists, err := revocationStore.client.Exists(ctx, key).Result()
 	if err != nil {
 		return false, err
 	}
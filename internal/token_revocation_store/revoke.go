@@ -6,7 +6,10 @@ import (
 )
 
 // Revoke — устанавливает в Redis ключ <prefix><tokenID> = true с TTL
-func (revocationStore *TokenRevocationStore) Revoke(token string, ttl time.Duration) error {
+func (revocationStore *TokenRevocationStore) Revoke(ctx context.Context, token string, ttl time.Duration) (err error) {
+	ctx, done := revocationStore.start(ctx, "revoke")
+	defer done(&err)
+
 	key := revocationStore.keyPrefix + token
-	return revocationStore.client.Set(context.Background(), key, "1", ttl).Err()
+	return revocationStore.client.Set(ctx, key, "1", ttl).Err()
 }
@@ -1,17 +1,59 @@
 package token_revocation_store
 
 import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/tracing"
 	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
+type Metrics interface {
+	ObserveRedis(operation string, duration time.Duration)
+}
+
 type TokenRevocationStore struct {
 	client    *redis.Client
 	keyPrefix string
+
+	// timeout ограничивает каждую операцию Redis; 0 — только дедлайн вызывающего
+	timeout time.Duration
+
+	// metrics может быть nil — тогда задержка Redis не измеряется
+	metrics Metrics
 }
 
-func NewTokenRevocationStore(client *redis.Client, keyPrefix string) *TokenRevocationStore {
+func NewTokenRevocationStore(client *redis.Client, keyPrefix string, timeout time.Duration, metrics Metrics) *TokenRevocationStore {
 	return &TokenRevocationStore{
 		client:    client,
 		keyPrefix: keyPrefix,
+		timeout:   timeout,
+		metrics:   metrics,
+	}
+}
+
+var tracer = otel.Tracer("github.com/Turalchik/authentication-service/internal/token_revocation_store")
+
+// start открывает span операции Redis, засекает время и ограничивает операцию timeout;
+// done вызывается через defer с ошибкой операции
+func (revocationStore *TokenRevocationStore) start(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "redis."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(operation)))
+
+	cancel := context.CancelFunc(func() {})
+	if revocationStore.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, revocationStore.timeout)
+	}
+
+	return ctx, func(err *error) {
+		cancel()
+		tracing.End(span, err)
+		if revocationStore.metrics != nil {
+			revocationStore.metrics.ObserveRedis(operation, time.Since(start))
+		}
 	}
 }
@@ -0,0 +1,20 @@
+package token_revocation_store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Turalchik/authentication-service/internal/revocation_store_conformance"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestTokenRevocationStore_Conformance(t *testing.T) {
+	revocation_store_conformance.Run(t, func(t *testing.T) (revocation_store_conformance.TokenRevocationStore, func(time.Duration)) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+
+		return NewTokenRevocationStore(client, "revoked:", 0, nil), server.FastForward
+	})
+}
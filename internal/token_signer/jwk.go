@@ -0,0 +1,75 @@
+package token_signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+)
+
+// NewTokenSignerFromJWK восстанавливает публичный ключ из JWKS. Такой TokenSigner умеет только проверять подписи:
+// нужен офлайн проверкам, у которых нет доступа к приватному ключу сервиса.
+func NewTokenSignerFromJWK(jwk JWK) (*TokenSigner, error) {
+	var tokenSigner *TokenSigner
+	switch jwk.KeyType {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 {
+			return nil, apperrors.ErrCantParseSigningKey
+		}
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		tokenSigner = &TokenSigner{method: jwt.SigningMethodRS256, verifyKey: publicKey, publicKey: publicKey}
+	case "EC":
+		curve, err := jwkCurve(jwk.Curve)
+		if err != nil {
+			return nil, err
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if errX != nil || errY != nil {
+			return nil, apperrors.ErrCantParseSigningKey
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, apperrors.ErrCantParseSigningKey
+		}
+		method, err := ecdsaSigningMethod(curve)
+		if err != nil {
+			return nil, err
+		}
+		tokenSigner = &TokenSigner{method: method, verifyKey: publicKey, publicKey: publicKey}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if jwk.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, apperrors.ErrUnsupportedSigningKey
+		}
+		publicKey := ed25519.PublicKey(x)
+		tokenSigner = &TokenSigner{method: jwt.SigningMethodEdDSA, verifyKey: publicKey, publicKey: publicKey}
+	default:
+		return nil, apperrors.ErrUnsupportedSigningKey
+	}
+
+	tokenSigner.keyID = jwk.KeyID
+	if tokenSigner.keyID == "" {
+		tokenSigner.keyID = jwkThumbprint(jwk)
+	}
+	return tokenSigner, nil
+}
+
+func jwkCurve(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, apperrors.ErrUnsupportedSigningKey
+	}
+}
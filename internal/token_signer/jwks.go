@@ -0,0 +1,86 @@
+package token_signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JWK — публичный ключ в формате RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные ключи для проверки токенов сторонними сервисами.
+// Общий секрет HMAC не публикуется, поэтому в этом режиме набор пустой.
+func (tokenSigner *TokenSigner) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, 1)}
+	if jwk, ok := tokenSigner.publicJWK(); ok {
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+func (tokenSigner *TokenSigner) publicJWK() (JWK, bool) {
+	jwk := JWK{
+		Use:       "sig",
+		Algorithm: tokenSigner.method.Alg(),
+		KeyID:     tokenSigner.keyID,
+	}
+
+	switch key := tokenSigner.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encodeBase64URL(key.N.Bytes())
+		jwk.E = encodeBase64URL(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = key.Curve.Params().Name
+		jwk.X = encodeBase64URL(key.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeBase64URL(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encodeBase64URL(key)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
+
+// jwkThumbprint — RFC 7638: sha256 от обязательных полей ключа в лексикографическом порядке
+func jwkThumbprint(jwk JWK) string {
+	var canonical string
+	switch jwk.KeyType {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Curve, jwk.X, jwk.Y)
+	default:
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, jwk.Curve, jwk.KeyType, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return encodeBase64URL(sum[:])
+}
+
+func encodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
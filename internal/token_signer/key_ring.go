@@ -0,0 +1,152 @@
+package token_signer
+
+import (
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/golang-jwt/jwt/v5"
+	"sync"
+	"time"
+)
+
+// KeyRing — набор ключей подписи: активный подписывает новые токены,
+// предыдущие продолжают приниматься для проверки до retireAt, чтобы ротация
+// не инвалидировала уже выданные access токены.
+type KeyRing struct {
+	mu sync.RWMutex
+
+	active   *TokenSigner
+	previous []retiringKey
+	// upcoming — ключ запланированной ротации; публикуется в JWKS заранее,
+	// чтобы сторонние сервисы успели его закэшировать
+	upcoming *TokenSigner
+
+	now func() time.Time
+}
+
+type retiringKey struct {
+	signer   *TokenSigner
+	retireAt time.Time
+}
+
+func NewKeyRing(active *TokenSigner) *KeyRing {
+	return &KeyRing{
+		active: active,
+		now:    time.Now,
+	}
+}
+
+// AddPreviousKey принимает токены, подписанные key, до истечения retireAfter
+func (keyRing *KeyRing) AddPreviousKey(key *TokenSigner, retireAfter time.Duration) {
+	keyRing.mu.Lock()
+	defer keyRing.mu.Unlock()
+
+	keyRing.previous = append(keyRing.previous, retiringKey{signer: key, retireAt: keyRing.now().Add(retireAfter)})
+}
+
+// Rotate делает next активным ключом, а текущий активный принимается для проверки ещё retireAfter
+func (keyRing *KeyRing) Rotate(next *TokenSigner, retireAfter time.Duration) {
+	keyRing.mu.Lock()
+	defer keyRing.mu.Unlock()
+
+	now := keyRing.now()
+	previous := make([]retiringKey, 0, len(keyRing.previous)+1)
+	for _, key := range keyRing.previous {
+		if now.Before(key.retireAt) && key.signer.keyID != next.keyID {
+			previous = append(previous, key)
+		}
+	}
+	previous = append(previous, retiringKey{signer: keyRing.active, retireAt: now.Add(retireAfter)})
+
+	keyRing.previous = previous
+	keyRing.active = next
+	if keyRing.upcoming != nil && keyRing.upcoming.keyID == next.keyID {
+		keyRing.upcoming = nil
+	}
+}
+
+// ScheduleRotation выполнит Rotate в момент at. Новый ключ сразу появляется в JWKS.
+func (keyRing *KeyRing) ScheduleRotation(next *TokenSigner, at time.Time, retireAfter time.Duration) *time.Timer {
+	keyRing.mu.Lock()
+	keyRing.upcoming = next
+	delay := at.Sub(keyRing.now())
+	keyRing.mu.Unlock()
+
+	return time.AfterFunc(delay, func() {
+		keyRing.Rotate(next, retireAfter)
+	})
+}
+
+func (keyRing *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	keyRing.mu.RLock()
+	active := keyRing.active
+	keyRing.mu.RUnlock()
+
+	return active.Sign(claims)
+}
+
+// Verify выбирает ключ по заголовку kid. Токены без kid выпущены до появления
+// ротации и проверяются активным ключом.
+func (keyRing *KeyRing) Verify(tokenStr string, claims jwt.Claims) error {
+	tok, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key := keyRing.verificationKey(kid)
+		if key == nil {
+			return nil, apperrors.ErrInvalidToken
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, apperrors.ErrInvalidToken
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil || !tok.Valid {
+		return apperrors.ErrInvalidToken
+	}
+	return nil
+}
+
+// JWKS публикует все ключи, которыми сейчас можно проверить токен
+func (keyRing *KeyRing) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0)}
+	for _, key := range keyRing.verificationKeys() {
+		if jwk, ok := key.publicJWK(); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
+
+func (keyRing *KeyRing) verificationKey(kid string) *TokenSigner {
+	keyRing.mu.RLock()
+	defer keyRing.mu.RUnlock()
+
+	if kid == "" {
+		return keyRing.active
+	}
+	for _, key := range keyRing.verificationKeysLocked() {
+		if key.keyID == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+func (keyRing *KeyRing) verificationKeys() []*TokenSigner {
+	keyRing.mu.RLock()
+	defer keyRing.mu.RUnlock()
+
+	return keyRing.verificationKeysLocked()
+}
+
+func (keyRing *KeyRing) verificationKeysLocked() []*TokenSigner {
+	now := keyRing.now()
+	keys := make([]*TokenSigner, 0, len(keyRing.previous)+2)
+	keys = append(keys, keyRing.active)
+	if keyRing.upcoming != nil {
+		keys = append(keys, keyRing.upcoming)
+	}
+	for _, key := range keyRing.previous {
+		if now.Before(key.retireAt) {
+			keys = append(keys, key.signer)
+		}
+	}
+	return keys
+}
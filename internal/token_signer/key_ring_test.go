@@ -0,0 +1,114 @@
+package token_signer
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+func kidOf(t *testing.T, tokenStr string) string {
+	tok, _, err := jwt.NewParser().ParseUnverified(tokenStr, &jwt.RegisteredClaims{})
+	require.NoError(t, err)
+	kid, _ := tok.Header["kid"].(string)
+	return kid
+}
+
+func TestKeyRing_Rotate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	oldKey := NewHMACTokenSigner([]byte("old"))
+	newKey := NewHMACTokenSigner([]byte("new"))
+
+	keyRing := NewKeyRing(oldKey)
+	keyRing.now = func() time.Time { return now }
+
+	oldToken, err := keyRing.Sign(testClaims())
+	require.NoError(t, err)
+	assert.Equal(t, oldKey.KeyID(), kidOf(t, oldToken))
+
+	keyRing.Rotate(newKey, time.Hour)
+
+	newToken, err := keyRing.Sign(testClaims())
+	require.NoError(t, err)
+	assert.Equal(t, newKey.KeyID(), kidOf(t, newToken))
+
+	t.Run("both keys accepted during overlap", func(t *testing.T) {
+		assert.NoError(t, keyRing.Verify(oldToken, &jwt.RegisteredClaims{}))
+		assert.NoError(t, keyRing.Verify(newToken, &jwt.RegisteredClaims{}))
+	})
+
+	t.Run("old key retired", func(t *testing.T) {
+		now = now.Add(time.Hour + time.Second)
+		assert.ErrorIs(t, keyRing.Verify(oldToken, &jwt.RegisteredClaims{}), apperrors.ErrInvalidToken)
+		assert.NoError(t, keyRing.Verify(newToken, &jwt.RegisteredClaims{}))
+	})
+}
+
+func TestKeyRing_Verify(t *testing.T) {
+	active := NewHMACTokenSigner([]byte("active"))
+	keyRing := NewKeyRing(active)
+
+	t.Run("unknown kid", func(t *testing.T) {
+		foreign, err := NewHMACTokenSigner([]byte("foreign")).Sign(testClaims())
+		require.NoError(t, err)
+		assert.ErrorIs(t, keyRing.Verify(foreign, &jwt.RegisteredClaims{}), apperrors.ErrInvalidToken)
+	})
+
+	t.Run("legacy token without kid", func(t *testing.T) {
+		legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS512, testClaims()).SignedString([]byte("active"))
+		require.NoError(t, err)
+		assert.NoError(t, keyRing.Verify(legacy, &jwt.RegisteredClaims{}))
+	})
+
+	t.Run("algorithm does not match kid", func(t *testing.T) {
+		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+		tok.Header["kid"] = active.KeyID()
+		forged, err := tok.SignedString([]byte("active"))
+		require.NoError(t, err)
+		assert.ErrorIs(t, keyRing.Verify(forged, &jwt.RegisteredClaims{}), apperrors.ErrInvalidToken)
+	})
+}
+
+func TestKeyRing_ScheduleRotation(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	next, err := NewTokenSignerFromPEM(pkcs8PEM(t, edKey))
+	require.NoError(t, err)
+	current := NewHMACTokenSigner([]byte("current"))
+
+	keyRing := NewKeyRing(current)
+	timer := keyRing.ScheduleRotation(next, time.Now().Add(50*time.Millisecond), time.Hour)
+	defer timer.Stop()
+
+	// следующий ключ публикуется заранее, до ротации
+	jwks := keyRing.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, next.KeyID(), jwks.Keys[0].KeyID)
+
+	tokenStr, err := keyRing.Sign(testClaims())
+	require.NoError(t, err)
+	assert.Equal(t, current.KeyID(), kidOf(t, tokenStr))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for kidOf(t, mustSign(t, keyRing)) != next.KeyID() {
+		if time.Now().After(deadline) {
+			t.Fatal("key was not rotated")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// токен старого ключа всё ещё принимается
+	assert.NoError(t, keyRing.Verify(tokenStr, &jwt.RegisteredClaims{}))
+}
+
+func mustSign(t *testing.T, keyRing *KeyRing) string {
+	tokenStr, err := keyRing.Sign(testClaims())
+	require.NoError(t, err)
+	return tokenStr
+}
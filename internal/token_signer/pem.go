@@ -0,0 +1,111 @@
+package token_signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+)
+
+// NewTokenSignerFromPEMFile читает приватный ключ из PEM файла
+func NewTokenSignerFromPEMFile(path string) (*TokenSigner, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, apperrors.ErrCantParseSigningKey
+	}
+	return NewTokenSignerFromPEM(pemBytes)
+}
+
+// NewTokenSignerFromPEM выбирает алгоритм подписи по типу ключа:
+// RSA — RS256, ECDSA P-256/P-384/P-521 — ES256/ES384/ES512, Ed25519 — EdDSA
+func NewTokenSignerFromPEM(pemBytes []byte) (*TokenSigner, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, apperrors.ErrCantParseSigningKey
+	}
+
+	privateKey, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokenSigner *TokenSigner
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		tokenSigner = &TokenSigner{
+			method:     jwt.SigningMethodRS256,
+			signingKey: key,
+			verifyKey:  &key.PublicKey,
+			publicKey:  &key.PublicKey,
+		}
+	case *ecdsa.PrivateKey:
+		method, err := ecdsaSigningMethod(key.Curve)
+		if err != nil {
+			return nil, err
+		}
+		tokenSigner = &TokenSigner{
+			method:     method,
+			signingKey: key,
+			verifyKey:  &key.PublicKey,
+			publicKey:  &key.PublicKey,
+		}
+	case ed25519.PrivateKey:
+		publicKey := key.Public().(ed25519.PublicKey)
+		tokenSigner = &TokenSigner{
+			method:     jwt.SigningMethodEdDSA,
+			signingKey: key,
+			verifyKey:  publicKey,
+			publicKey:  publicKey,
+		}
+	default:
+		return nil, apperrors.ErrUnsupportedSigningKey
+	}
+
+	// kid — отпечаток публичного ключа (RFC 7638), одинаковый на всех репликах
+	jwk, _ := tokenSigner.publicJWK()
+	tokenSigner.keyID = jwkThumbprint(jwk)
+	return tokenSigner, nil
+}
+
+func parsePrivateKey(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, apperrors.ErrCantParseSigningKey
+		}
+		return key, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, apperrors.ErrCantParseSigningKey
+		}
+		return key, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, apperrors.ErrCantParseSigningKey
+		}
+		return key, nil
+	default:
+		return nil, apperrors.ErrUnsupportedSigningKey
+	}
+}
+
+func ecdsaSigningMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384, nil
+	case elliptic.P521():
+		return jwt.SigningMethodES512, nil
+	default:
+		return nil, apperrors.ErrUnsupportedSigningKey
+	}
+}
@@ -0,0 +1,9 @@
+package token_signer
+
+import "github.com/golang-jwt/jwt/v5"
+
+func (tokenSigner *TokenSigner) Sign(claims jwt.Claims) (string, error) {
+	tok := jwt.NewWithClaims(tokenSigner.method, claims)
+	tok.Header["kid"] = tokenSigner.keyID
+	return tok.SignedString(tokenSigner.signingKey)
+}
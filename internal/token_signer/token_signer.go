@@ -0,0 +1,34 @@
+package token_signer
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenSigner подписывает и проверяет JWT одним ключом.
+// Для HMAC это общий секрет, для RSA/ECDSA/Ed25519 — приватный ключ, публичная часть которого публикуется в JWKS.
+type TokenSigner struct {
+	keyID      string
+	method     jwt.SigningMethod
+	signingKey interface{}
+	verifyKey  interface{}
+	publicKey  crypto.PublicKey
+}
+
+// NewHMACTokenSigner — симметричная подпись HS512 общим секретом
+func NewHMACTokenSigner(secretKey []byte) *TokenSigner {
+	sum := sha256.Sum256(secretKey)
+	return &TokenSigner{
+		// kid не должен раскрывать секрет, поэтому берём усечённый хэш
+		keyID:      encodeBase64URL(sum[:12]),
+		method:     jwt.SigningMethodHS512,
+		signingKey: secretKey,
+		verifyKey:  secretKey,
+	}
+}
+
+// KeyID — идентификатор ключа, который попадает в заголовок kid и в JWKS
+func (tokenSigner *TokenSigner) KeyID() string {
+	return tokenSigner.keyID
+}
@@ -0,0 +1,204 @@
+package token_signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+func pkcs8PEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func testClaims() *jwt.RegisteredClaims {
+	return &jwt.RegisteredClaims{
+		Subject:   "u",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+// publicKeyFromJWK восстанавливает ключ так, как это сделал бы сторонний сервис
+func publicKeyFromJWK(t *testing.T, jwk JWK) interface{} {
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+
+	switch jwk.KeyType {
+	case "RSA":
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(decode(jwk.N)), E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64())}
+	case "EC":
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(decode(jwk.X)), Y: new(big.Int).SetBytes(decode(jwk.Y))}
+	case "OKP":
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+	t.Fatalf("unexpected kty %s", jwk.KeyType)
+	return nil
+}
+
+func TestTokenSigner_Asymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		pem      []byte
+		alg      string
+		kty      string
+		hasCurve bool
+	}{
+		{"rsa pkcs8", pkcs8PEM(t, rsaKey), "RS256", "RSA", false},
+		{"rsa pkcs1", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), "RS256", "RSA", false},
+		{"ecdsa pkcs8", pkcs8PEM(t, ecKey), "ES256", "EC", true},
+		{"ecdsa sec1", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}), "ES256", "EC", true},
+		{"ed25519", pkcs8PEM(t, edKey), "EdDSA", "OKP", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := NewTokenSignerFromPEM(tc.pem)
+			require.NoError(t, err)
+
+			tokenStr, err := signer.Sign(testClaims())
+			require.NoError(t, err)
+
+			claims := &jwt.RegisteredClaims{}
+			require.NoError(t, signer.Verify(tokenStr, claims))
+			assert.Equal(t, "u", claims.Subject)
+
+			jwks := signer.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			jwk := jwks.Keys[0]
+			assert.Equal(t, tc.alg, jwk.Algorithm)
+			assert.Equal(t, tc.kty, jwk.KeyType)
+			assert.Equal(t, "sig", jwk.Use)
+			assert.Equal(t, signer.KeyID(), jwk.KeyID)
+			assert.NotEmpty(t, jwk.KeyID)
+			assert.Equal(t, tc.hasCurve, jwk.Curve != "")
+
+			// сторонний сервис проверяет токен только по опубликованному ключу
+			publicKey := publicKeyFromJWK(t, jwk)
+			tok, err := jwt.Parse(tokenStr, func(*jwt.Token) (interface{}, error) { return publicKey, nil },
+				jwt.WithValidMethods([]string{jwk.Algorithm}))
+			require.NoError(t, err)
+			assert.True(t, tok.Valid)
+
+			// офлайн проверка по JWKS: тот же kid, подписывать без приватного ключа нельзя
+			verifier, err := NewTokenSignerFromJWK(jwk)
+			require.NoError(t, err)
+			assert.Equal(t, signer.KeyID(), verifier.KeyID())
+			assert.NoError(t, verifier.Verify(tokenStr, &jwt.RegisteredClaims{}))
+			_, err = verifier.Sign(testClaims())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewTokenSignerFromJWK_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		jwk  JWK
+		err  error
+	}{
+		{"unknown kty", JWK{KeyType: "oct"}, apperrors.ErrUnsupportedSigningKey},
+		{"rsa without modulus", JWK{KeyType: "RSA", E: "AQAB"}, apperrors.ErrCantParseSigningKey},
+		{"unknown curve", JWK{KeyType: "EC", Curve: "secp256k1"}, apperrors.ErrUnsupportedSigningKey},
+		{"point not on curve", JWK{KeyType: "EC", Curve: "P-256", X: "AQ", Y: "AQ"}, apperrors.ErrCantParseSigningKey},
+		{"short ed25519 key", JWK{KeyType: "OKP", Curve: "Ed25519", X: "AQ"}, apperrors.ErrUnsupportedSigningKey},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTokenSignerFromJWK(tc.jwk)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestTokenSigner_HMAC(t *testing.T) {
+	signer := NewHMACTokenSigner([]byte("secret"))
+
+	tokenStr, err := signer.Sign(testClaims())
+	require.NoError(t, err)
+	assert.NoError(t, signer.Verify(tokenStr, &jwt.RegisteredClaims{}))
+
+	t.Run("secret is not published", func(t *testing.T) {
+		assert.Empty(t, signer.JWKS().Keys)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		other := NewHMACTokenSigner([]byte("other"))
+		assert.ErrorIs(t, other.Verify(tokenStr, &jwt.RegisteredClaims{}), apperrors.ErrInvalidToken)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired, err := signer.Sign(&jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))})
+		require.NoError(t, err)
+		assert.ErrorIs(t, signer.Verify(expired, &jwt.RegisteredClaims{}), apperrors.ErrInvalidToken)
+	})
+}
+
+func TestTokenSigner_RejectsOtherAlgorithms(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := NewTokenSignerFromPEM(pkcs8PEM(t, edKey))
+	require.NoError(t, err)
+
+	// HS‑токен, подписанный публичным ключом как секретом (algorithm confusion)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte(edKey.Public().(ed25519.PublicKey)))
+	require.NoError(t, err)
+	assert.ErrorIs(t, signer.Verify(forged, &jwt.RegisteredClaims{}), apperrors.ErrInvalidToken)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	assert.ErrorIs(t, signer.Verify(unsigned, &jwt.RegisteredClaims{}), apperrors.ErrInvalidToken)
+}
+
+func TestNewTokenSignerFromPEM_Errors(t *testing.T) {
+	t.Run("not a pem", func(t *testing.T) {
+		_, err := NewTokenSignerFromPEM([]byte("garbage"))
+		assert.ErrorIs(t, err, apperrors.ErrCantParseSigningKey)
+	})
+
+	t.Run("public key instead of private", func(t *testing.T) {
+		_, err := NewTokenSignerFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("x")}))
+		assert.ErrorIs(t, err, apperrors.ErrUnsupportedSigningKey)
+	})
+
+	t.Run("unsupported curve", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		_, err = NewTokenSignerFromPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+		assert.Error(t, err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewTokenSignerFromPEMFile("/nonexistent/key.pem")
+		assert.ErrorIs(t, err, apperrors.ErrCantParseSigningKey)
+	})
+}
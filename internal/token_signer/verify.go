@@ -0,0 +1,18 @@
+package token_signer
+
+import (
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Verify проверяет подпись и срок жизни токена и заполняет claims.
+// Токены, подписанные любым другим алгоритмом, отклоняются.
+func (tokenSigner *TokenSigner) Verify(tokenStr string, claims jwt.Claims) error {
+	tok, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		return tokenSigner.verifyKey, nil
+	}, jwt.WithValidMethods([]string{tokenSigner.method.Alg()}))
+	if err != nil || !tok.Valid {
+		return apperrors.ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238, приложение B (SHA1): последние 6 цифр восьмизначных эталонных значений
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		assert.Equal(t, v.code, Code(secret, Step(time.Unix(v.unix, 0))), v.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111111, 0)
+
+	t.Run("current window", func(t *testing.T) {
+		step, ok := Validate(secret, "050471", now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now), step)
+	})
+
+	t.Run("previous window within skew", func(t *testing.T) {
+		previous := Code(secret, Step(now)-1)
+		step, ok := Validate(secret, previous, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now)-1, step)
+	})
+
+	t.Run("outside skew", func(t *testing.T) {
+		_, ok := Validate(secret, Code(secret, Step(now)-2), now, 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("wrong length", func(t *testing.T) {
+		_, ok := Validate(secret, "50471", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestKeyURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 20)
+
+	uri, err := url.Parse(KeyURI("Auth Service", "alice@example.com", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Auth Service:alice@example.com", uri.Path)
+	assert.Equal(t, EncodeSecret(secret), uri.Query().Get("secret"))
+	assert.Equal(t, "Auth Service", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+	assert.Equal(t, "30", uri.Query().Get("period"))
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+)
+
+// ServiceName — service.name в ресурсе, под ним спаны видны в Jaeger/Tempo
+const ServiceName = "authentication-service"
+
+// NewTracerProvider отправляет спаны в OTLP/HTTP collector. endpoint — базовый адрес collector
+// (например http://otel-collector:4318), путь /v1/traces добавляется, как в OTEL_EXPORTER_OTLP_ENDPOINT.
+// Провайдер становится глобальным: пакеты берут tracer через otel.Tracer.
+func NewTracerProvider(ctx context.Context, endpoint string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(strings.TrimRight(endpoint, "/")+"/v1/traces"))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider, nil
+}
+
+// End завершает span; если *err не nil, span помечается ошибкой. Вызывается через defer с именованной ошибкой.
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		RecordError(span, *err)
+	}
+	span.End()
+}
+
+// RecordError помечает span ошибкой. В span попадает только текст ошибки: у apperrors.Wrap это текст
+// sentinel, причина остаётся в спанах repo и token_revocation_store.
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// propagator — W3C traceparent/tracestate и baggage; не зависит от глобального, чтобы заголовки
+// передавались и при выключенном экспорте
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Extract продолжает трассу из заголовков входящего запроса
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Inject кладёт traceparent текущего span в заголовки исходящего запроса
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceParent — traceparent текущего span; сохраняется вместе с событием webhook,
+// чтобы доставка из фонового dispatcher попала в трассу запроса, который событие породил
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// ContextWithTraceParent восстанавливает трассу из сохранённого traceparent; пустой или битый игнорируется
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": traceParent})
+}
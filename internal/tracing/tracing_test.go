@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestNewTracerProvider(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	// collector в процессе: принимает OTLP/HTTP protobuf и складывает запросы в канал
+	requests := make(chan *collectortrace.ExportTraceServiceRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		request := &collectortrace.ExportTraceServiceRequest{}
+		require.NoError(t, proto.Unmarshal(body, request))
+		requests <- request
+
+		response, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(response)
+	}))
+	defer collector.Close()
+
+	provider, err := NewTracerProvider(t.Context(), collector.URL+"/")
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(t.Context(), "operation")
+	span.End()
+	require.NoError(t, provider.ForceFlush(t.Context()))
+	require.NoError(t, provider.Shutdown(t.Context()))
+
+	request := <-requests
+	require.Len(t, request.ResourceSpans, 1)
+	resourceSpans := request.ResourceSpans[0]
+
+	var serviceName string
+	for _, attribute := range resourceSpans.Resource.Attributes {
+		if attribute.Key == "service.name" {
+			serviceName = attribute.Value.GetStringValue()
+		}
+	}
+	assert.Equal(t, ServiceName, serviceName)
+
+	require.Len(t, resourceSpans.ScopeSpans, 1)
+	require.Len(t, resourceSpans.ScopeSpans[0].Spans, 1)
+	assert.Equal(t, "operation", resourceSpans.ScopeSpans[0].Spans[0].Name)
+}
+
+func TestTraceParent(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		ctx := ContextWithTraceParent(context.Background(), traceParent)
+		spanContext := trace.SpanContextFromContext(ctx)
+		assert.True(t, spanContext.IsRemote())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanContext.TraceID().String())
+		assert.Equal(t, traceParent, TraceParent(ctx))
+	})
+
+	t.Run("no span", func(t *testing.T) {
+		assert.Empty(t, TraceParent(context.Background()))
+	})
+
+	t.Run("empty or malformed is ignored", func(t *testing.T) {
+		for _, value := range []string{"", "garbage"} {
+			ctx := ContextWithTraceParent(context.Background(), value)
+			assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+		}
+	})
+}
+
+func TestExtractInject(t *testing.T) {
+	incoming := http.Header{}
+	incoming.Set("traceparent", traceParent)
+	incoming.Set("baggage", "tenant=acme")
+
+	outgoing := http.Header{}
+	Inject(Extract(context.Background(), incoming), outgoing)
+
+	assert.Equal(t, traceParent, outgoing.Get("traceparent"))
+	assert.Equal(t, "tenant=acme", outgoing.Get("baggage"))
+}
@@ -0,0 +1,98 @@
+package webhook_dispatcher
+
+import (
+	"bytes"
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/Turalchik/authentication-service/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// заголовки запроса к получателю
+const (
+	HeaderWebhookID  = "X-Webhook-ID"
+	HeaderDeliveryID = "X-Webhook-Delivery-ID"
+	HeaderEventType  = "X-Webhook-Event-Type"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Signature"
+)
+
+var tracer = otel.Tracer("github.com/Turalchik/authentication-service/internal/webhook_dispatcher")
+
+// deliver отправляет событие подписчику и возвращает запись для журнала попыток;
+// успехом считается любой 2xx ответ, тогда Error пустой
+func (dispatcher *WebhookDispatcher) deliver(ctx context.Context, delivery *webhook_deliveries.PendingDelivery) *webhook_deliveries.DeliveryAttempts {
+	// span доставки продолжает трассу запроса, в котором возникло событие; без traceparent начинается новая
+	ctx, span := tracer.Start(tracing.ContextWithTraceParent(ctx, delivery.TraceParent), "webhook.deliver "+delivery.EventType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodPost,
+			attribute.String("webhook.event_id", delivery.EventID),
+			attribute.String("webhook.event_type", delivery.EventType),
+			attribute.String("webhook.delivery_id", delivery.DeliveryID),
+			attribute.String("webhook.subscription_id", delivery.SubscriptionID),
+			attribute.Int("webhook.attempt", delivery.Attempts+1),
+		))
+	defer span.End()
+
+	started := dispatcher.now()
+	attempt := &webhook_deliveries.DeliveryAttempts{
+		DeliveryID:  delivery.DeliveryID,
+		AttemptedAt: started,
+	}
+
+	statusCode, err := dispatcher.post(ctx, delivery)
+	attempt.StatusCode = statusCode
+	attempt.DurationMS = dispatcher.now().Sub(started).Milliseconds()
+	switch {
+	case err != nil:
+		attempt.Error = err.Error()
+	case statusCode < 200 || statusCode >= 300:
+		attempt.Error = statusCodeError(statusCode)
+	}
+
+	if statusCode != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+	}
+	if attempt.Error != "" {
+		span.SetStatus(codes.Error, attempt.Error)
+	}
+	return attempt
+}
+
+func (dispatcher *WebhookDispatcher) post(ctx context.Context, delivery *webhook_deliveries.PendingDelivery) (int, error) {
+	timestamp := strconv.FormatInt(dispatcher.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	// traceparent получателю: его обработка события окажется в той же трассе
+	tracing.Inject(ctx, req.Header)
+	req.Header.Set("Content-Type", webhook_events.CloudEventsContentType)
+	// X-Webhook-ID — id события, одинаковый для всех подписчиков и повторов: по нему получатель отбрасывает дубликаты
+	req.Header.Set(HeaderWebhookID, delivery.EventID)
+	req.Header.Set(HeaderDeliveryID, delivery.DeliveryID)
+	// тип дублируется в заголовке, чтобы получатель мог отфильтровать событие, не разбирая тело
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign([]byte(delivery.Secret), timestamp, delivery.Payload))
+
+	resp, err := dispatcher.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// дочитываем тело, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,117 @@
+package webhook_dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"sync"
+	"time"
+)
+
+// DispatchPending делает одну попытку для каждой доставки, которой пора отправляться
+func (dispatcher *WebhookDispatcher) DispatchPending(ctx context.Context) error {
+	_, err := dispatcher.dispatchBatch(ctx)
+	return err
+}
+
+// dispatchBatch забирает не больше batchSize доставок, отправляет их параллельно (не больше concurrency
+// запросов сразу) и возвращает, сколько их было. Отправка ограничена batchTimeout: доставки, до которых
+// не дошла очередь, не отправляются и после lease достаются следующему опросу.
+func (dispatcher *WebhookDispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	deliveries, err := dispatcher.repo.ClaimWebhookDeliveries(ctx, dispatcher.batchSize, dispatcher.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, dispatcher.batchTimeout)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	slots := make(chan struct{}, dispatcher.concurrency)
+	for _, delivery := range deliveries {
+		select {
+		case slots <- struct{}{}:
+		case <-sendCtx.Done():
+		}
+		if sendCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := dispatcher.dispatch(ctx, sendCtx, delivery); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), firstErr
+}
+
+// dispatch делает одну попытку доставки в пределах sendCtx и сохраняет её результат. Если lease истёк
+// и доставку уже забрал другой экземпляр, результат не сохраняется — это не ошибка опроса.
+func (dispatcher *WebhookDispatcher) dispatch(ctx context.Context, sendCtx context.Context, delivery *webhook_deliveries.PendingDelivery) error {
+	attempt := dispatcher.deliver(sendCtx, delivery)
+	dispatcher.applyResult(&delivery.WebhookDeliveries, attempt)
+
+	err := dispatcher.repo.SaveWebhookDeliveryAttempt(ctx, &delivery.WebhookDeliveries, attempt, delivery.LeasedUntil)
+	if errors.Is(err, apperrors.ErrWebhookDeliveryLeaseLost) {
+		dispatcher.logger.WarnContext(ctx, "webhook delivery lease lost",
+			"delivery_id", delivery.DeliveryID,
+			"subscription_id", delivery.SubscriptionID)
+		return nil
+	}
+	return err
+}
+
+// applyResult переводит доставку в следующее состояние по результату попытки
+func (dispatcher *WebhookDispatcher) applyResult(delivery *webhook_deliveries.WebhookDeliveries, attempt *webhook_deliveries.DeliveryAttempts) {
+	now := dispatcher.now()
+	delivery.Attempts++
+
+	if attempt.Error == "" {
+		delivery.Status = webhook_deliveries.StatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = attempt.Error
+	if delivery.Attempts >= dispatcher.maxAttempts {
+		delivery.Status = webhook_deliveries.StatusDead
+		return
+	}
+	delivery.NextAttemptAt = now.Add(dispatcher.backoff(delivery.Attempts))
+}
+
+// backoff — задержка перед следующей попыткой: minBackoff * 2^(attempts-1), но не больше maxBackoff
+func (dispatcher *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := dispatcher.minBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= dispatcher.maxBackoff {
+			return dispatcher.maxBackoff
+		}
+	}
+	return delay
+}
+
+// statusCodeError — текст ошибки попытки, на которую получатель ответил не 2xx
+func statusCodeError(statusCode int) string {
+	return fmt.Sprintf("unexpected status code %d", statusCode)
+}
@@ -0,0 +1,19 @@
+package webhook_dispatcher
+
+import "context"
+
+// Flush при остановке сервиса отправляет всё, чему уже пора уйти, не дожидаясь следующего опроса.
+// Пачки забираются, пока не кончатся готовые доставки или ctx. Неудачные попытки откладываются
+// с обычной задержкой, а доставки, прерванные отменой ctx, после lease заберёт другой экземпляр.
+func (dispatcher *WebhookDispatcher) Flush(ctx context.Context) error {
+	for ctx.Err() == nil {
+		count, err := dispatcher.dispatchBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if count < dispatcher.batchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,12 @@
+package webhook_dispatcher
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"time"
+)
+
+type Repo interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*webhook_deliveries.PendingDelivery, error)
+	SaveWebhookDeliveryAttempt(ctx context.Context, delivery *webhook_deliveries.WebhookDeliveries, attempt *webhook_deliveries.DeliveryAttempts, leasedUntil time.Time) error
+}
@@ -0,0 +1,26 @@
+package webhook_dispatcher
+
+import (
+	"context"
+	"time"
+)
+
+// Run опрашивает outbox, пока не отменят ctx. Отмена не прерывает начатую пачку: запросы к получателям
+// дожидаются ответа (не дольше batchTimeout) и попытки записываются, иначе доставки
+// висели бы невыполненными до конца lease.
+func (dispatcher *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatcher.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := dispatcher.DispatchPending(context.WithoutCancel(ctx)); err != nil {
+			dispatcher.logger.ErrorContext(ctx, "webhook dispatch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
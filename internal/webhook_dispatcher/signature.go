@@ -0,0 +1,41 @@
+package webhook_dispatcher
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign возвращает значение X-Signature: sha256=<hex HMAC-SHA256(secret, "<timestamp>.<body>")>.
+// Метка времени входит в подпись, поэтому перехваченный запрос нельзя переотправить со свежим временем.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature — проверка на стороне получателя: подпись совпадает, а метка времени
+// отличается от now не больше чем на tolerance (защита от повтора старых запросов)
+func VerifySignature(secret []byte, timestamp string, body []byte, signature string, tolerance time.Duration, now time.Time) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,48 @@
+package webhook_dispatcher
+
+import (
+	"github.com/Turalchik/authentication-service/internal/logging"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WebhookDispatcher забирает доставки событий подписчикам и отправляет их.
+// Тело запроса подписывается HMAC-SHA256 ключом подписки вместе с меткой времени, неудачные попытки
+// повторяются с экспоненциальной задержкой, а после maxAttempts доставка уходит в dead letter.
+type WebhookDispatcher struct {
+	repo        Repo
+	httpClient  *http.Client
+	maxAttempts int
+	logger      *slog.Logger
+
+	pollInterval time.Duration
+	batchSize    int
+	// concurrency — сколько доставок пачки отправляется одновременно
+	concurrency int
+	// batchTimeout ограничивает отправку пачки и должен быть меньше lease: после lease доставки
+	// снова доступны другим экземплярам, и отправка после этого дала бы получателю дубликаты
+	batchTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	lease        time.Duration
+	now          func() time.Time
+}
+
+func NewWebhookDispatcher(repo Repo, maxAttempts int, logger *slog.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo:        repo,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: maxAttempts,
+		logger:      logging.OrDiscard(logger).With("component", "webhook_dispatcher"),
+
+		pollInterval: time.Second,
+		batchSize:    100,
+		concurrency:  10,
+		batchTimeout: 45 * time.Second,
+		minBackoff:   10 * time.Second,
+		maxBackoff:   time.Hour,
+		lease:        time.Minute,
+		now:          time.Now,
+	}
+}
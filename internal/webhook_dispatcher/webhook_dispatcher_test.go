@@ -0,0 +1,350 @@
+package webhook_dispatcher
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) ClaimWebhookDeliveries(_ context.Context, limit int, lease time.Duration) ([]*webhook_deliveries.PendingDelivery, error) {
+	args := m.Called(limit, lease)
+	return args.Get(0).([]*webhook_deliveries.PendingDelivery), args.Error(1)
+}
+
+func (m *mockRepo) SaveWebhookDeliveryAttempt(_ context.Context, delivery *webhook_deliveries.WebhookDeliveries, attempt *webhook_deliveries.DeliveryAttempts, leasedUntil time.Time) error {
+	args := m.Called(delivery, attempt, leasedUntil)
+	return args.Error(0)
+}
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// testLease — next_attempt_at, выставленный доставкам при захвате
+var testLease = testNow.Add(time.Minute)
+
+func newTestDispatcher(repo Repo) *WebhookDispatcher {
+	dispatcher := NewWebhookDispatcher(repo, 3, nil)
+	dispatcher.now = func() time.Time { return testNow }
+	return dispatcher
+}
+
+func TestSignature(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"type":"session.ip_changed"}`)
+	timestamp := strconv.FormatInt(testNow.Unix(), 10)
+	signature := Sign(secret, timestamp, body)
+
+	assert.True(t, VerifySignature(secret, timestamp, body, signature, 5*time.Minute, testNow))
+	assert.False(t, VerifySignature([]byte("other"), timestamp, body, signature, 5*time.Minute, testNow))
+	assert.False(t, VerifySignature(secret, timestamp, []byte(`{}`), signature, 5*time.Minute, testNow))
+	assert.False(t, VerifySignature(secret, timestamp, body, signature, 5*time.Minute, testNow.Add(10*time.Minute)))
+	assert.False(t, VerifySignature(secret, "bad", body, signature, 5*time.Minute, testNow))
+}
+
+func newPendingDelivery(url string, attempts int) *webhook_deliveries.PendingDelivery {
+	return &webhook_deliveries.PendingDelivery{
+		WebhookDeliveries: webhook_deliveries.WebhookDeliveries{
+			DeliveryID: "d1",
+			EventID:    "e1",
+			EventType:  webhook_events.EventSessionIPChanged,
+			Status:     webhook_deliveries.StatusPending,
+			Attempts:   attempts,
+		},
+		Payload:     []byte(`{"type":"session.ip_changed"}`),
+		URL:         url,
+		Secret:      "subscription-secret",
+		LeasedUntil: testLease,
+	}
+}
+
+func TestWebhookDispatcher_DispatchPending(t *testing.T) {
+	t.Run("delivered", func(t *testing.T) {
+		var got *http.Request
+		var gotBody []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			gotBody, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		repo := new(mockRepo)
+		delivery := newPendingDelivery(server.URL, 0)
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.MatchedBy(func(attempt *webhook_deliveries.DeliveryAttempts) bool {
+			return attempt.DeliveryID == "d1" && attempt.StatusCode == http.StatusNoContent && attempt.Error == ""
+		}), testLease).Return(nil).Once()
+
+		assert.NoError(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		assert.Equal(t, "e1", got.Header.Get(HeaderWebhookID))
+		assert.Equal(t, "d1", got.Header.Get(HeaderDeliveryID))
+		assert.Equal(t, webhook_events.EventSessionIPChanged, got.Header.Get(HeaderEventType))
+		assert.Equal(t, webhook_events.CloudEventsContentType, got.Header.Get("Content-Type"))
+		assert.Equal(t, delivery.Payload, gotBody)
+		assert.True(t, VerifySignature([]byte("subscription-secret"), got.Header.Get(HeaderTimestamp), gotBody, got.Header.Get(HeaderSignature), time.Minute, testNow))
+		assert.Equal(t, webhook_deliveries.StatusDelivered, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, testNow, *delivery.DeliveredAt)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("continues the trace of the event", func(t *testing.T) {
+		spans := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+		defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+		var gotTraceParent string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotTraceParent = r.Header.Get("traceparent")
+		}))
+		defer server.Close()
+
+		repo := new(mockRepo)
+		delivery := newPendingDelivery(server.URL, 0)
+		delivery.TraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.Anything, testLease).Return(nil).Once()
+
+		assert.NoError(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+
+		ended := spans.Ended()
+		if assert.Len(t, ended, 1) {
+			span := ended[0]
+			assert.Equal(t, "webhook.deliver "+webhook_events.EventSessionIPChanged, span.Name())
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+			// получатель видит span доставки, а не span исходного запроса
+			assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID().String()+"-01", gotTraceParent)
+		}
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("failed attempt is retried with backoff", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		repo := new(mockRepo)
+		delivery := newPendingDelivery(server.URL, 1)
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.MatchedBy(func(attempt *webhook_deliveries.DeliveryAttempts) bool {
+			return attempt.StatusCode == http.StatusInternalServerError && attempt.Error != ""
+		}), testLease).Return(nil).Once()
+
+		assert.NoError(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		assert.Equal(t, webhook_deliveries.StatusPending, delivery.Status)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.Equal(t, testNow.Add(20*time.Second), delivery.NextAttemptAt)
+		assert.Contains(t, delivery.LastError, "500")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("dead letter after max attempts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		repo := new(mockRepo)
+		delivery := newPendingDelivery(server.URL, 2)
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.Anything, testLease).Return(nil).Once()
+
+		assert.NoError(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		assert.Equal(t, webhook_deliveries.StatusDead, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("receiver unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		repo := new(mockRepo)
+		delivery := newPendingDelivery(server.URL, 0)
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.MatchedBy(func(attempt *webhook_deliveries.DeliveryAttempts) bool {
+			return attempt.StatusCode == 0 && attempt.Error != ""
+		}), testLease).Return(nil).Once()
+
+		assert.NoError(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		assert.Equal(t, webhook_deliveries.StatusPending, delivery.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("batch is sent concurrently", func(t *testing.T) {
+		// каждый получатель отвечает, только когда пришли все запросы пачки
+		var arrived sync.WaitGroup
+		arrived.Add(3)
+		allArrived := make(chan struct{})
+		go func() {
+			arrived.Wait()
+			close(allArrived)
+		}()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			arrived.Done()
+			select {
+			case <-allArrived:
+				w.WriteHeader(http.StatusNoContent)
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(http.StatusGatewayTimeout)
+			}
+		}))
+		defer server.Close()
+
+		repo := new(mockRepo)
+		var deliveries []*webhook_deliveries.PendingDelivery
+		for _, deliveryID := range []string{"d1", "d2", "d3"} {
+			delivery := newPendingDelivery(server.URL, 0)
+			delivery.DeliveryID = deliveryID
+			deliveries = append(deliveries, delivery)
+		}
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return(deliveries, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", mock.Anything, mock.MatchedBy(func(attempt *webhook_deliveries.DeliveryAttempts) bool {
+			return attempt.StatusCode == http.StatusNoContent
+		}), testLease).Return(nil).Times(3)
+
+		assert.NoError(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("batch timeout leaves the rest for the next poll", func(t *testing.T) {
+		var requests atomic.Int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		repo := new(mockRepo)
+		first := newPendingDelivery(server.URL, 0)
+		second := newPendingDelivery(server.URL, 0)
+		second.DeliveryID = "d2"
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{first, second}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &first.WebhookDeliveries, mock.MatchedBy(func(attempt *webhook_deliveries.DeliveryAttempts) bool {
+			return attempt.StatusCode == 0 && attempt.Error != ""
+		}), testLease).Return(nil).Once()
+
+		dispatcher := newTestDispatcher(repo)
+		dispatcher.concurrency = 1
+		dispatcher.batchTimeout = 50 * time.Millisecond
+
+		assert.NoError(t, dispatcher.DispatchPending(t.Context()))
+		assert.Equal(t, int32(1), requests.Load())
+		// вторая доставка не отправлялась и не считается попыткой
+		assert.Equal(t, 0, second.Attempts)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("lease lost is not an error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		repo := new(mockRepo)
+		delivery := newPendingDelivery(server.URL, 0)
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.Anything, testLease).Return(apperrors.ErrWebhookDeliveryLeaseLost).Once()
+
+		assert.NoError(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant save attempt", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		repo := new(mockRepo)
+		delivery := newPendingDelivery(server.URL, 0)
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.Anything, testLease).Return(errors.New("fail")).Once()
+
+		assert.Error(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant claim deliveries", func(t *testing.T) {
+		repo := new(mockRepo)
+		repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return(([]*webhook_deliveries.PendingDelivery)(nil), errors.New("fail")).Once()
+
+		assert.Error(t, newTestDispatcher(repo).DispatchPending(t.Context()))
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestWebhookDispatcher_Flush(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Run("drains full batches", func(t *testing.T) {
+		repo := new(mockRepo)
+		dispatcher := newTestDispatcher(repo)
+		dispatcher.batchSize = 1
+		repo.On("ClaimWebhookDeliveries", 1, time.Minute).Return([]*webhook_deliveries.PendingDelivery{newPendingDelivery(server.URL, 0)}, nil).Twice()
+		repo.On("ClaimWebhookDeliveries", 1, time.Minute).Return([]*webhook_deliveries.PendingDelivery{}, nil).Once()
+		repo.On("SaveWebhookDeliveryAttempt", mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+
+		assert.NoError(t, dispatcher.Flush(t.Context()))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("stops when ctx is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		assert.ErrorIs(t, newTestDispatcher(new(mockRepo)).Flush(ctx), context.Canceled)
+	})
+}
+
+func TestWebhookDispatcher_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	// получатель отвечает только после отмены: начатая доставка должна дождаться ответа
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	delivery := newPendingDelivery(server.URL, 0)
+	repo := new(mockRepo)
+	repo.On("ClaimWebhookDeliveries", 100, time.Minute).Return([]*webhook_deliveries.PendingDelivery{delivery}, nil).Once()
+	repo.On("SaveWebhookDeliveryAttempt", &delivery.WebhookDeliveries, mock.MatchedBy(func(attempt *webhook_deliveries.DeliveryAttempts) bool {
+		return attempt.StatusCode == http.StatusNoContent && attempt.Error == ""
+	}), testLease).Return(nil).Once()
+
+	newTestDispatcher(repo).Run(ctx)
+	assert.Equal(t, webhook_deliveries.StatusDelivered, delivery.Status)
+	repo.AssertExpectations(t)
+}
+
+func TestWebhookDispatcher_Backoff(t *testing.T) {
+	dispatcher := newTestDispatcher(new(mockRepo))
+	assert.Equal(t, 10*time.Second, dispatcher.backoff(1))
+	assert.Equal(t, 40*time.Second, dispatcher.backoff(3))
+	assert.Equal(t, time.Hour, dispatcher.backoff(20))
+}
@@ -0,0 +1,28 @@
+package webhook_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+// BootstrapSubscription заводит подписку на все события для WEBHOOK_URL из конфига, как было до подписок.
+// Недоставленные до миграции события уже висят на этой подписке и начинают уходить после её включения.
+// Если подписка уже настроена, в том числе изменена через API, возвращает false и ничего не меняет.
+func (webhookService *WebhookService) BootstrapSubscription(ctx context.Context, url string, secret string) (bool, error) {
+	if err := validateURL(url); err != nil {
+		return false, err
+	}
+
+	subscription := &webhook_subscriptions.WebhookSubscriptions{
+		SubscriptionID: webhook_subscriptions.LegacySubscriptionID,
+		URL:            url,
+		Secret:         secret,
+		Status:         webhook_subscriptions.StatusActive,
+	}
+	adopted, err := webhookService.repo.AdoptLegacyWebhookSubscription(ctx, subscription)
+	if err != nil {
+		return false, apperrors.Wrap(apperrors.ErrCantSaveWebhookSubscription, err)
+	}
+	return adopted, nil
+}
@@ -0,0 +1,43 @@
+package webhook_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"github.com/google/uuid"
+	"time"
+)
+
+// CreateSubscription регистрирует получателя. Если secret не передан, он генерируется;
+// ключ возвращается в ответе, и получатель проверяет им X-Signature.
+func (webhookService *WebhookService) CreateSubscription(ctx context.Context, url string, eventTypes []string, secret string) (*webhook_subscriptions.WebhookSubscriptions, error) {
+	if err := validateURL(url); err != nil {
+		return nil, err
+	}
+	filter, err := normalizeEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret == "" {
+		if secret, err = makeSecret(); err != nil {
+			return nil, apperrors.Wrap(apperrors.ErrCantSaveWebhookSubscription, err)
+		}
+	}
+
+	now := time.Now()
+	subscription := &webhook_subscriptions.WebhookSubscriptions{
+		SubscriptionID: uuid.NewString(),
+		URL:            url,
+		Secret:         secret,
+		EventTypes:     filter,
+		Status:         webhook_subscriptions.StatusActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err = webhookService.repo.CreateWebhookSubscription(ctx, subscription); err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantSaveWebhookSubscription, err)
+	}
+
+	return subscription, nil
+}
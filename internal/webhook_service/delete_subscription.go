@@ -0,0 +1,18 @@
+package webhook_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// DeleteSubscription удаляет подписку вместе с её доставками
+func (webhookService *WebhookService) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	if err := webhookService.repo.DeleteWebhookSubscription(ctx, subscriptionID); err != nil {
+		if errors.Is(err, apperrors.ErrWebhookSubscriptionNotFound) {
+			return apperrors.ErrWebhookSubscriptionNotFound
+		}
+		return apperrors.Wrap(apperrors.ErrCantSaveWebhookSubscription, err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package webhook_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+)
+
+// GetDelivery возвращает доставку и журнал её попыток
+func (webhookService *WebhookService) GetDelivery(ctx context.Context, deliveryID string) (*webhook_deliveries.WebhookDeliveries, []*webhook_deliveries.DeliveryAttempts, error) {
+	delivery, err := webhookService.repo.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrWebhookDeliveryNotFound) {
+			return nil, nil, apperrors.ErrWebhookDeliveryNotFound
+		}
+		return nil, nil, apperrors.Wrap(apperrors.ErrCantGetWebhookDeliveries, err)
+	}
+
+	attempts, err := webhookService.repo.ListWebhookDeliveryAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, nil, apperrors.Wrap(apperrors.ErrCantGetWebhookDeliveries, err)
+	}
+	return delivery, attempts, nil
+}
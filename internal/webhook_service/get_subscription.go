@@ -0,0 +1,19 @@
+package webhook_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+func (webhookService *WebhookService) GetSubscription(ctx context.Context, subscriptionID string) (*webhook_subscriptions.WebhookSubscriptions, error) {
+	subscription, err := webhookService.repo.GetWebhookSubscriptionByID(ctx, subscriptionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrWebhookSubscriptionNotFound) {
+			return nil, apperrors.ErrWebhookSubscriptionNotFound
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantGetWebhookSubscription, err)
+	}
+	return subscription, nil
+}
@@ -0,0 +1,78 @@
+package webhook_service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"net/url"
+	"strings"
+)
+
+// лимиты выборки доставок
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// secretPrefix помечает сгенерированный ключ подписи, чтобы его легко было узнать в конфиге получателя
+const secretPrefix = "whsec_"
+
+// validateURL — получатель должен быть абсолютным http(s) адресом
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apperrors.ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// normalizeEventTypes проверяет фильтр событий и возвращает его в виде для хранения: типы через пробел.
+// Пустой фильтр или * — все события, хранится как пустая строка.
+func normalizeEventTypes(eventTypes []string) (string, error) {
+	if len(eventTypes) == 0 || (len(eventTypes) == 1 && eventTypes[0] == "*") {
+		return "", nil
+	}
+
+	for _, eventType := range eventTypes {
+		if !isKnownEventType(eventType) {
+			return "", apperrors.ErrInvalidWebhookEventType
+		}
+	}
+	return strings.Join(eventTypes, " "), nil
+}
+
+func isKnownEventType(eventType string) bool {
+	for _, known := range webhook_events.EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSubscriptionStatus(status string) error {
+	if status != webhook_subscriptions.StatusActive && status != webhook_subscriptions.StatusPaused {
+		return apperrors.ErrInvalidWebhookStatus
+	}
+	return nil
+}
+
+func validateDeliveryStatus(status string) error {
+	switch status {
+	case "", webhook_deliveries.StatusPending, webhook_deliveries.StatusDelivered, webhook_deliveries.StatusDead:
+		return nil
+	}
+	return apperrors.ErrInvalidWebhookStatus
+}
+
+// makeSecret генерирует ключ подписи, если администратор не передал свой
+func makeSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
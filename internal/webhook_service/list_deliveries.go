@@ -0,0 +1,30 @@
+package webhook_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+)
+
+// ListDeliveries возвращает последние доставки подписки, например все dead для повтора
+func (webhookService *WebhookService) ListDeliveries(ctx context.Context, subscriptionID string, status string, limit int) ([]*webhook_deliveries.WebhookDeliveries, error) {
+	if err := validateDeliveryStatus(status); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	if limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+
+	if _, err := webhookService.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := webhookService.repo.ListWebhookDeliveries(ctx, subscriptionID, status, limit)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantGetWebhookDeliveries, err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,15 @@
+package webhook_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+func (webhookService *WebhookService) ListSubscriptions(ctx context.Context) ([]*webhook_subscriptions.WebhookSubscriptions, error) {
+	subscriptions, err := webhookService.repo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.ErrCantGetWebhookSubscription, err)
+	}
+	return subscriptions, nil
+}
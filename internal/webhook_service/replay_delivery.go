@@ -0,0 +1,19 @@
+package webhook_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+)
+
+// ReplayDelivery ставит доставку в очередь заново с чистым счётчиком попыток — например, dead доставку
+// после того, как получатель починили. Журнал прошлых попыток сохраняется.
+func (webhookService *WebhookService) ReplayDelivery(ctx context.Context, deliveryID string) error {
+	if err := webhookService.repo.ReplayWebhookDelivery(ctx, deliveryID); err != nil {
+		if errors.Is(err, apperrors.ErrWebhookDeliveryNotFound) {
+			return apperrors.ErrWebhookDeliveryNotFound
+		}
+		return apperrors.Wrap(apperrors.ErrCantReplayWebhookDelivery, err)
+	}
+	return nil
+}
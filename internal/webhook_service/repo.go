@@ -0,0 +1,20 @@
+package webhook_service
+
+import (
+	"context"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+)
+
+type Repo interface {
+	CreateWebhookSubscription(ctx context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) error
+	GetWebhookSubscriptionByID(ctx context.Context, subscriptionID string) (*webhook_subscriptions.WebhookSubscriptions, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]*webhook_subscriptions.WebhookSubscriptions, error)
+	UpdateWebhookSubscription(ctx context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) error
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error
+	GetWebhookDeliveryByID(ctx context.Context, deliveryID string) (*webhook_deliveries.WebhookDeliveries, error)
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, status string, limit int) ([]*webhook_deliveries.WebhookDeliveries, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID string) ([]*webhook_deliveries.DeliveryAttempts, error)
+	ReplayWebhookDelivery(ctx context.Context, deliveryID string) error
+	AdoptLegacyWebhookSubscription(ctx context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) (bool, error)
+}
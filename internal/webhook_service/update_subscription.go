@@ -0,0 +1,49 @@
+package webhook_service
+
+import (
+	"context"
+	"errors"
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"time"
+)
+
+// UpdateSubscription меняет переданные поля подписки. Пауза и возобновление — смена Status:
+// на приостановленную подписку новые события не ставятся, а накопленные доставки ждут возобновления.
+func (webhookService *WebhookService) UpdateSubscription(ctx context.Context, subscriptionID string, update *webhook_subscriptions.WebhookSubscriptionUpdate) (*webhook_subscriptions.WebhookSubscriptions, error) {
+	subscription, err := webhookService.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.URL != nil {
+		if err = validateURL(*update.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *update.URL
+	}
+	if update.EventTypes != nil {
+		if subscription.EventTypes, err = normalizeEventTypes(*update.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+	if update.Status != nil {
+		if err = validateSubscriptionStatus(*update.Status); err != nil {
+			return nil, err
+		}
+		subscription.Status = *update.Status
+	}
+	if update.Secret != nil && *update.Secret != "" {
+		subscription.Secret = *update.Secret
+	}
+
+	if err = webhookService.repo.UpdateWebhookSubscription(ctx, subscription); err != nil {
+		if errors.Is(err, apperrors.ErrWebhookSubscriptionNotFound) {
+			return nil, apperrors.ErrWebhookSubscriptionNotFound
+		}
+		return nil, apperrors.Wrap(apperrors.ErrCantSaveWebhookSubscription, err)
+	}
+
+	subscription.UpdatedAt = time.Now()
+	return subscription, nil
+}
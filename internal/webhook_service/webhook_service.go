@@ -0,0 +1,12 @@
+package webhook_service
+
+// WebhookService управляет подписками на webhooks и журналом их доставок
+type WebhookService struct {
+	repo Repo
+}
+
+func NewWebhookService(repo Repo) *WebhookService {
+	return &WebhookService{
+		repo: repo,
+	}
+}
@@ -0,0 +1,222 @@
+package webhook_service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Turalchik/authentication-service/internal/apperrors"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_deliveries"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_events"
+	"github.com/Turalchik/authentication-service/internal/entities/webhook_subscriptions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRepo struct{ mock.Mock }
+
+func (m *mockRepo) CreateWebhookSubscription(_ context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) error {
+	return m.Called(subscription).Error(0)
+}
+func (m *mockRepo) GetWebhookSubscriptionByID(_ context.Context, subscriptionID string) (*webhook_subscriptions.WebhookSubscriptions, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).(*webhook_subscriptions.WebhookSubscriptions), args.Error(1)
+}
+func (m *mockRepo) ListWebhookSubscriptions(_ context.Context) ([]*webhook_subscriptions.WebhookSubscriptions, error) {
+	args := m.Called()
+	return args.Get(0).([]*webhook_subscriptions.WebhookSubscriptions), args.Error(1)
+}
+func (m *mockRepo) UpdateWebhookSubscription(_ context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) error {
+	return m.Called(subscription).Error(0)
+}
+func (m *mockRepo) DeleteWebhookSubscription(_ context.Context, subscriptionID string) error {
+	return m.Called(subscriptionID).Error(0)
+}
+func (m *mockRepo) GetWebhookDeliveryByID(_ context.Context, deliveryID string) (*webhook_deliveries.WebhookDeliveries, error) {
+	args := m.Called(deliveryID)
+	return args.Get(0).(*webhook_deliveries.WebhookDeliveries), args.Error(1)
+}
+func (m *mockRepo) ListWebhookDeliveries(_ context.Context, subscriptionID string, status string, limit int) ([]*webhook_deliveries.WebhookDeliveries, error) {
+	args := m.Called(subscriptionID, status, limit)
+	return args.Get(0).([]*webhook_deliveries.WebhookDeliveries), args.Error(1)
+}
+func (m *mockRepo) ListWebhookDeliveryAttempts(_ context.Context, deliveryID string) ([]*webhook_deliveries.DeliveryAttempts, error) {
+	args := m.Called(deliveryID)
+	return args.Get(0).([]*webhook_deliveries.DeliveryAttempts), args.Error(1)
+}
+func (m *mockRepo) ReplayWebhookDelivery(_ context.Context, deliveryID string) error {
+	return m.Called(deliveryID).Error(0)
+}
+
+func (m *mockRepo) AdoptLegacyWebhookSubscription(_ context.Context, subscription *webhook_subscriptions.WebhookSubscriptions) (bool, error) {
+	args := m.Called(subscription)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewWebhookService(repo)
+
+	t.Run("invalid url", func(t *testing.T) {
+		_, err := svc.CreateSubscription(t.Context(), "ftp://example.com", nil, "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidWebhookURL)
+		_, err = svc.CreateSubscription(t.Context(), "/relative", nil, "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidWebhookURL)
+	})
+
+	t.Run("unknown event type", func(t *testing.T) {
+		_, err := svc.CreateSubscription(t.Context(), "https://example.com/hook", []string{"session.deleted"}, "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidWebhookEventType)
+	})
+
+	t.Run("cant save", func(t *testing.T) {
+		dbErr := errors.New("fail")
+		repo.On("CreateWebhookSubscription", mock.Anything).Return(dbErr).Once()
+		_, err := svc.CreateSubscription(t.Context(), "https://example.com/hook", nil, "secret")
+		assert.ErrorIs(t, err, apperrors.ErrCantSaveWebhookSubscription)
+		// исходная ошибка сохраняется для поля cause в логе
+		assert.ErrorIs(t, err, dbErr)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("success with generated secret", func(t *testing.T) {
+		repo.On("CreateWebhookSubscription", mock.MatchedBy(func(s *webhook_subscriptions.WebhookSubscriptions) bool {
+			return s.URL == "https://example.com/hook" && s.EventTypes == "session.revoked refresh.reuse_detected" && s.Status == webhook_subscriptions.StatusActive
+		})).Return(nil).Once()
+		subscription, err := svc.CreateSubscription(t.Context(), "https://example.com/hook", []string{webhook_events.EventSessionRevoked, webhook_events.EventRefreshReuseDetected}, "")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, subscription.SubscriptionID)
+		assert.True(t, strings.HasPrefix(subscription.Secret, secretPrefix))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("all events", func(t *testing.T) {
+		repo.On("CreateWebhookSubscription", mock.MatchedBy(func(s *webhook_subscriptions.WebhookSubscriptions) bool {
+			return s.EventTypes == "" && s.Secret == "own-secret"
+		})).Return(nil).Once()
+		_, err := svc.CreateSubscription(t.Context(), "http://receiver:9000/hook", []string{"*"}, "own-secret")
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestWebhookService_BootstrapSubscription(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewWebhookService(repo)
+	legacy := &webhook_subscriptions.WebhookSubscriptions{
+		SubscriptionID: webhook_subscriptions.LegacySubscriptionID,
+		URL:            "https://example.com/hook",
+		Secret:         "secret",
+		Status:         webhook_subscriptions.StatusActive,
+	}
+
+	t.Run("invalid url", func(t *testing.T) {
+		_, err := svc.BootstrapSubscription(t.Context(), "example.com/hook", "secret")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidWebhookURL)
+	})
+
+	t.Run("adopted", func(t *testing.T) {
+		repo.On("AdoptLegacyWebhookSubscription", legacy).Return(true, nil).Once()
+		adopted, err := svc.BootstrapSubscription(t.Context(), "https://example.com/hook", "secret")
+		assert.NoError(t, err)
+		assert.True(t, adopted)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("already configured", func(t *testing.T) {
+		repo.On("AdoptLegacyWebhookSubscription", legacy).Return(false, nil).Once()
+		adopted, err := svc.BootstrapSubscription(t.Context(), "https://example.com/hook", "secret")
+		assert.NoError(t, err)
+		assert.False(t, adopted)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cant save", func(t *testing.T) {
+		repo.On("AdoptLegacyWebhookSubscription", legacy).Return(false, errors.New("fail")).Once()
+		_, err := svc.BootstrapSubscription(t.Context(), "https://example.com/hook", "secret")
+		assert.ErrorIs(t, err, apperrors.ErrCantSaveWebhookSubscription)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestWebhookService_UpdateSubscription(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewWebhookService(repo)
+	paused := webhook_subscriptions.StatusPaused
+
+	t.Run("not found", func(t *testing.T) {
+		repo.On("GetWebhookSubscriptionByID", "unknown").Return((*webhook_subscriptions.WebhookSubscriptions)(nil), apperrors.ErrWebhookSubscriptionNotFound).Once()
+		_, err := svc.UpdateSubscription(t.Context(), "unknown", &webhook_subscriptions.WebhookSubscriptionUpdate{Status: &paused})
+		assert.ErrorIs(t, err, apperrors.ErrWebhookSubscriptionNotFound)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		deleted := "deleted"
+		repo.On("GetWebhookSubscriptionByID", "sub").Return(&webhook_subscriptions.WebhookSubscriptions{SubscriptionID: "sub", Status: webhook_subscriptions.StatusActive}, nil).Once()
+		_, err := svc.UpdateSubscription(t.Context(), "sub", &webhook_subscriptions.WebhookSubscriptionUpdate{Status: &deleted})
+		assert.ErrorIs(t, err, apperrors.ErrInvalidWebhookStatus)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("pause keeps other fields", func(t *testing.T) {
+		repo.On("GetWebhookSubscriptionByID", "sub").Return(&webhook_subscriptions.WebhookSubscriptions{SubscriptionID: "sub", URL: "https://example.com/hook", Secret: "secret", EventTypes: "session.revoked", Status: webhook_subscriptions.StatusActive}, nil).Once()
+		repo.On("UpdateWebhookSubscription", &webhook_subscriptions.WebhookSubscriptions{SubscriptionID: "sub", URL: "https://example.com/hook", Secret: "secret", EventTypes: "session.revoked", Status: paused}).Return(nil).Once()
+		subscription, err := svc.UpdateSubscription(t.Context(), "sub", &webhook_subscriptions.WebhookSubscriptionUpdate{Status: &paused})
+		assert.NoError(t, err)
+		assert.Equal(t, paused, subscription.Status)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestWebhookService_Deliveries(t *testing.T) {
+	repo := new(mockRepo)
+	svc := NewWebhookService(repo)
+
+	t.Run("invalid status filter", func(t *testing.T) {
+		_, err := svc.ListDeliveries(t.Context(), "sub", "failed", 10)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidWebhookStatus)
+	})
+
+	t.Run("unknown subscription", func(t *testing.T) {
+		repo.On("GetWebhookSubscriptionByID", "unknown").Return((*webhook_subscriptions.WebhookSubscriptions)(nil), apperrors.ErrWebhookSubscriptionNotFound).Once()
+		_, err := svc.ListDeliveries(t.Context(), "unknown", "", 0)
+		assert.ErrorIs(t, err, apperrors.ErrWebhookSubscriptionNotFound)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("limit is clamped", func(t *testing.T) {
+		repo.On("GetWebhookSubscriptionByID", "sub").Return(&webhook_subscriptions.WebhookSubscriptions{SubscriptionID: "sub"}, nil).Once()
+		repo.On("ListWebhookDeliveries", "sub", webhook_deliveries.StatusDead, maxDeliveriesLimit).Return([]*webhook_deliveries.WebhookDeliveries{{DeliveryID: "d1"}}, nil).Once()
+		deliveries, err := svc.ListDeliveries(t.Context(), "sub", webhook_deliveries.StatusDead, 100000)
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("delivery with attempts", func(t *testing.T) {
+		repo.On("GetWebhookDeliveryByID", "d1").Return(&webhook_deliveries.WebhookDeliveries{DeliveryID: "d1"}, nil).Once()
+		repo.On("ListWebhookDeliveryAttempts", "d1").Return([]*webhook_deliveries.DeliveryAttempts{{AttemptID: 1, StatusCode: 500}}, nil).Once()
+		delivery, attempts, err := svc.GetDelivery(t.Context(), "d1")
+		assert.NoError(t, err)
+		assert.Equal(t, "d1", delivery.DeliveryID)
+		assert.Len(t, attempts, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("replay unknown delivery", func(t *testing.T) {
+		repo.On("ReplayWebhookDelivery", "unknown").Return(apperrors.ErrWebhookDeliveryNotFound).Once()
+		assert.ErrorIs(t, svc.ReplayDelivery(t.Context(), "unknown"), apperrors.ErrWebhookDeliveryNotFound)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("replay fails", func(t *testing.T) {
+		dbErr := errors.New("fail")
+		repo.On("ReplayWebhookDelivery", "d1").Return(dbErr).Once()
+		err := svc.ReplayDelivery(t.Context(), "d1")
+		assert.ErrorIs(t, err, apperrors.ErrCantReplayWebhookDelivery)
+		assert.ErrorIs(t, err, dbErr)
+		repo.AssertExpectations(t)
+	})
+}
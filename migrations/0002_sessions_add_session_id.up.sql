@@ -0,0 +1,10 @@
+-- у пользователя теперь может быть несколько сессий (по одной на устройство)
+ALTER TABLE sessions ADD COLUMN session_id UUID;
+
+UPDATE sessions SET session_id = gen_random_uuid() WHERE session_id IS NULL;
+
+ALTER TABLE sessions ALTER COLUMN session_id SET NOT NULL;
+ALTER TABLE sessions DROP CONSTRAINT sessions_pkey;
+ALTER TABLE sessions ADD PRIMARY KEY (session_id);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
@@ -0,0 +1,8 @@
+-- уже использованные (ротированные) refresh токены сессии; повторное предъявление любого из них — признак кражи
+CREATE TABLE refresh_token_history (
+    token_digest TEXT PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES sessions (session_id) ON DELETE CASCADE,
+    rotated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refresh_token_history_session_id_idx ON refresh_token_history (session_id);
@@ -0,0 +1,4 @@
+-- списки через пробел, как параметр scope в OAuth 2.0
+ALTER TABLE oauth_clients
+    ADD COLUMN scopes TEXT NOT NULL DEFAULT '',
+    ADD COLUMN grant_types TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- разрешённые redirect_uri клиента через пробел; у публичного клиента (SPA, мобильное приложение) client_secret_hash пустой
+ALTER TABLE oauth_clients ADD COLUMN redirect_uris TEXT NOT NULL DEFAULT '';
+
+-- сессия, открытая через authorization code flow, помнит клиента и выданный ему scope
+ALTER TABLE sessions ADD COLUMN client_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN scope TEXT NOT NULL DEFAULT '';
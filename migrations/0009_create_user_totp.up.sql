@@ -0,0 +1,13 @@
+-- TOTP второй фактор: секрет зашифрован AES-GCM ключом из TOTP_ENCRYPTION_KEY,
+-- confirmed_at пустой, пока пользователь не подтвердил привязку первым кодом,
+-- last_used_step — номер последнего принятого 30-секундного окна, чтобы один код нельзя было предъявить дважды
+CREATE TABLE user_totp (
+    user_id UUID PRIMARY KEY,
+    secret_ciphertext BYTEA NOT NULL,
+    confirmed_at TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- методы аутентификации сессии (RFC 8176) через пробел, попадают в claim amr
+ALTER TABLE sessions ADD COLUMN amr TEXT NOT NULL DEFAULT '';
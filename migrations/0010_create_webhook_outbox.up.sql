@@ -0,0 +1,15 @@
+-- transactional outbox: событие пишется в той же транзакции, что и изменение сессии,
+-- а фоновый dispatcher доставляет его с повторами; после исчерпания попыток событие уходит в status = 'dead'
+CREATE TABLE webhook_outbox (
+    event_id UUID PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_outbox_pending_idx ON webhook_outbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,60 @@
+-- подписки на webhooks вместо единственного WEBHOOK_URL: у каждой свой URL, ключ подписи и фильтр событий.
+-- event_types — типы через пробел, пустая строка — все события
+CREATE TABLE webhook_subscriptions (
+    subscription_id UUID PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- доставка события одной подписке; создаётся в одной транзакции с событием для всех активных подписок,
+-- которым оно подходит по фильтру. Состояние доставки переезжает сюда из webhook_outbox.
+CREATE TABLE webhook_deliveries (
+    delivery_id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES webhook_outbox (event_id) ON DELETE CASCADE,
+    subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (subscription_id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at);
+
+-- журнал попыток доставки: код ответа получателя или ошибка соединения
+CREATE TABLE webhook_delivery_attempts (
+    attempt_id BIGSERIAL PRIMARY KEY,
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries (delivery_id) ON DELETE CASCADE,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    status_code INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX webhook_delivery_attempts_delivery_idx ON webhook_delivery_attempts (delivery_id, attempted_at);
+
+-- недоставленные события из единственного WEBHOOK_URL переезжают в доставки подписки-заглушки с известным id.
+-- URL здесь неизвестен, поэтому заглушка создаётся приостановленной; сервис при старте с WEBHOOK_URL
+-- заполняет её URL и ключом подписи и включает, и доставки продолжаются с прежними счётчиками попыток
+INSERT INTO webhook_subscriptions (subscription_id, url, secret, status)
+SELECT '00000000-0000-0000-0000-000000000001', '', '', 'paused'
+WHERE EXISTS (SELECT 1 FROM webhook_outbox WHERE status IN ('pending', 'dead'));
+
+INSERT INTO webhook_deliveries (delivery_id, event_id, subscription_id, status, attempts, next_attempt_at, last_error, created_at)
+SELECT gen_random_uuid(), event_id, '00000000-0000-0000-0000-000000000001', status, attempts, next_attempt_at, last_error, created_at
+FROM webhook_outbox
+WHERE status IN ('pending', 'dead');
+
+-- webhook_outbox остаётся журналом событий, вместе с колонками уходит и индекс по status
+ALTER TABLE webhook_outbox
+    DROP COLUMN status,
+    DROP COLUMN attempts,
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN last_error,
+    DROP COLUMN delivered_at;
@@ -0,0 +1,17 @@
+-- журнал аутентификации: выдача, обновление и завершение сессий, включая неудачные попытки.
+-- Строки только добавляются; audit_id задаёт порядок записей и служит курсором выборки.
+CREATE TABLE audit_events (
+    audit_id BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    user_id TEXT NOT NULL DEFAULT '',
+    session_id TEXT NOT NULL DEFAULT '',
+    ip_addr TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL,
+    error_code TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_events_user_idx ON audit_events (user_id, audit_id);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
@@ -0,0 +1,15 @@
+-- hash chain журнала: каждая запись хранит хеш предыдущей, поэтому правка или удаление записи
+-- ломает цепочку. Записи, сделанные до этой миграции, остаются без хешей и цепочкой не защищены.
+ALTER TABLE audit_events
+    ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+-- контрольные точки: хеш записи audit_id, подписанный ключом подписи сервиса,
+-- закрепляет всю цепочку до неё — её нельзя пересчитать заново без ключа
+CREATE TABLE audit_checkpoints (
+    checkpoint_id BIGSERIAL PRIMARY KEY,
+    audit_id BIGINT NOT NULL REFERENCES audit_events (audit_id),
+    hash TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
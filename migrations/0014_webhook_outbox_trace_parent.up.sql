@@ -0,0 +1,4 @@
+-- W3C traceparent запроса, в котором возникло событие: dispatcher продолжает эту трассу при доставке.
+-- У событий, записанных до миграции, трассы нет
+ALTER TABLE webhook_outbox
+    ADD COLUMN trace_parent TEXT NOT NULL DEFAULT '';